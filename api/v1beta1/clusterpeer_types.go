@@ -0,0 +1,65 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterPeerSpec defines k8gb cluster which participates on the gslb
+type ClusterPeerSpec struct {
+	// Geo tag of the peer cluster, e.g. us-east-1
+	GeoTag string `json:"geoTag"`
+	// Address (IP or hostname) of the peer nameserver. By default, peer is reached on its
+	// gslb-ns-<dnsZone>-<geoTag>.<edgeDNSZone> nameserver
+	// +optional
+	NSAddress string `json:"nsAddress,omitempty"`
+	// HTTPS URL of the peer status API, e.g. https://k8gb.eu.example.com:8443. When set, targets are read from
+	// the API and the peer nameserver is queried only if the API can't be reached
+	// +kubebuilder:validation:Pattern=`^https://`
+	// +optional
+	StatusEndpoint string `json:"statusEndpoint,omitempty"`
+	// Disabled peer is excluded from the membership, even if it is listed in EXT_GSLB_CLUSTERS_GEO_TAGS
+	// +kubebuilder:default=true
+	Enabled bool `json:"enabled"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="GeoTag",type=string,JSONPath=`.spec.geoTag`
+// +kubebuilder:printcolumn:name="Enabled",type=boolean,JSONPath=`.spec.enabled`
+
+// ClusterPeer is the Schema for the clusterpeers API
+type ClusterPeer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterPeerSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterPeerList contains a list of ClusterPeer
+type ClusterPeerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterPeer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterPeer{}, &ClusterPeerList{})
+}
@@ -37,6 +37,20 @@ type Strategy struct {
 	SplitBrainThresholdSeconds int `json:"splitBrainThresholdSeconds,omitempty"`
 }
 
+// Load balancing strategy types
+const (
+	RoundRobinStrategy = "roundRobin"
+	FailoverStrategy   = "failover"
+)
+
+// Annotations of Ingress defining strategy of Gslb created out of the Ingress
+const (
+	StrategyAnnotation                   = "k8gb.io/strategy"
+	PrimaryGeoTagAnnotation              = "k8gb.io/primary-geotag"
+	DNSTtlSecondsAnnotation              = "k8gb.io/dns-ttl-seconds"
+	SplitBrainThresholdSecondsAnnotation = "k8gb.io/splitbrain-threshold-seconds"
+)
+
 // GslbSpec defines the desired state of Gslb
 // +k8s:openapi-gen=true
 type GslbSpec struct {
@@ -54,10 +68,68 @@ type GslbStatus struct {
 	HealthyRecords map[string][]string `json:"healthyRecords"`
 	// Cluster Geo Tag
 	GeoTag string `json:"geoTag"`
+	// External clusters as observed by the last reconciliation
+	// +optional
+	Peers []PeerStatus `json:"peers,omitempty"`
+	// Generation of the Gslb observed by the last reconciliation
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions of the last reconciliation; Ready, DNSEndpointSynced, DelegationSynced and Degraded
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// Condition types of Gslb status
+const (
+	// ConditionReady is True when all steps of the last reconciliation succeeded
+	ConditionReady = "Ready"
+	// ConditionDNSEndpointSynced is True when DNSEndpoint with records of Gslb hosts is saved
+	ConditionDNSEndpointSynced = "DNSEndpointSynced"
+	// ConditionDelegationSynced is True when zone delegation in edge DNS is up to date
+	ConditionDelegationSynced = "DelegationSynced"
+	// ConditionDegraded is True when the last reconciliation failed or some external clusters are unreachable
+	ConditionDegraded = "Degraded"
+)
+
+// Reasons of Gslb status conditions
+const (
+	ReasonReconciled          = "Reconciled"
+	ReasonInvalidSpec         = "InvalidSpec"
+	ReasonIngressFailed       = "IngressFailed"
+	ReasonServiceHealthFailed = "ServiceHealthFailed"
+	ReasonIngressIPUnresolved = "IngressIPUnresolved"
+	ReasonZoneMismatch        = "ZoneMismatch"
+	ReasonDNSEndpointFailed   = "DNSEndpointFailed"
+	ReasonDelegationFailed    = "DelegationFailed"
+	ReasonStatusFailed        = "StatusFailed"
+	ReasonPeersUnreachable    = "PeersUnreachable"
+)
+
+// PeerStatus describes external cluster as observed by the last reconciliation
+type PeerStatus struct {
+	// Geo tag of the external cluster
+	GeoTag string `json:"geoTag"`
+	// Reachable is true when the cluster answered the last query for targets of any Gslb host
+	Reachable bool `json:"reachable"`
+	// Timestamp of the last split brain TXT heartbeat of the cluster. Empty when the heartbeat wasn't inspected
+	// +optional
+	LastHeartbeat *metav1.Time `json:"lastHeartbeat,omitempty"`
+	// Targets contributed by the cluster per Gslb host
+	// +optional
+	Targets map[string][]string `json:"targets,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Strategy",type=string,JSONPath=`.spec.strategy.type`
+// +kubebuilder:printcolumn:name="GeoTag",type=string,JSONPath=`.status.geoTag`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reachable",type=string,JSONPath=`.status.peers[?(@.reachable==true)].geoTag`
+// +kubebuilder:printcolumn:name="Unreachable",type=string,JSONPath=`.status.peers[?(@.reachable==false)].geoTag`
+// +kubebuilder:printcolumn:name="LastHeartbeat",type=string,JSONPath=`.status.peers[*].lastHeartbeat`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Gslb is the Schema for the gslbs API
 type Gslb struct {
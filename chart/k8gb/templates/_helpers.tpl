@@ -61,3 +61,28 @@ Create the name of the service account to use
     {{ default "default" .Values.serviceAccount.name }}
 {{- end -}}
 {{- end -}}
+
+{{/*
+Additional delegated zones in the DNSZone:EdgeDNSZone format of ADDITIONAL_DNS_ZONES
+*/}}
+{{- define "k8gb.additionalDNSZones" -}}
+{{- $zones := list -}}
+{{- range .Values.k8gb.additionalDNSZones -}}
+{{- $zones = append $zones (printf "%s:%s" .dnsZone .edgeDNSZone) -}}
+{{- end -}}
+{{- join "," $zones -}}
+{{- end -}}
+
+{{/*
+Name of cluster scoped object of the release, e.g. ClusterRole or webhook configuration. Release k8gb keeps the plain
+name, names of other releases are suffixed by the release name, so that several k8gb instances don't collide
+*/}}
+{{- define "k8gb.clusterScopedName" -}}
+{{- $name := index . 0 -}}
+{{- $release := (index . 1).Release.Name -}}
+{{- if eq $release "k8gb" -}}
+{{- $name -}}
+{{- else -}}
+{{- printf "%s-%s" $name $release | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+{{- end -}}
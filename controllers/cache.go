@@ -0,0 +1,159 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// NewScopedCache returns cache of the manager restricted to WatchNamespaces and k8gb namespace. Cluster scoped
+// objects, e.g. ClusterPeers, are cached cluster-wide. Cache isn't restricted when WatchNamespaces is empty
+func NewScopedCache(config *depresolver.Config) cache.NewCacheFunc {
+	if len(config.WatchNamespaces) == 0 {
+		return cache.New
+	}
+	namespaces := []string{config.K8gbNamespace}
+	for _, ns := range config.WatchNamespaces {
+		if ns != config.K8gbNamespace {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return func(restConfig *rest.Config, opts cache.Options) (cache.Cache, error) {
+		var err error
+		if opts.Scheme == nil {
+			opts.Scheme = scheme.Scheme
+		}
+		if opts.Mapper == nil {
+			opts.Mapper, err = apiutil.NewDiscoveryRESTMapper(restConfig)
+			if err != nil {
+				return nil, fmt.Errorf("could not create RESTMapper from config: %w", err)
+			}
+		}
+		namespaced, err := cache.MultiNamespacedCacheBuilder(namespaces)(restConfig, opts)
+		if err != nil {
+			return nil, err
+		}
+		opts.Namespace = ""
+		cluster, err := cache.New(restConfig, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &scopedCache{namespaced: namespaced, cluster: cluster, scheme: opts.Scheme, mapper: opts.Mapper}, nil
+	}
+}
+
+// scopedCache routes namespaced objects to the cache of watched namespaces and cluster scoped objects to the
+// cluster-wide cache. Multi namespace cache can't read cluster scoped objects
+type scopedCache struct {
+	namespaced cache.Cache
+	cluster    cache.Cache
+	scheme     *runtime.Scheme
+	mapper     meta.RESTMapper
+}
+
+func (c *scopedCache) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	target, err := c.cacheFor(obj)
+	if err != nil {
+		return err
+	}
+	return target.Get(ctx, key, obj)
+}
+
+func (c *scopedCache) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	target, err := c.cacheFor(list)
+	if err != nil {
+		return err
+	}
+	return target.List(ctx, list, opts...)
+}
+
+func (c *scopedCache) GetInformer(ctx context.Context, obj runtime.Object) (cache.Informer, error) {
+	target, err := c.cacheFor(obj)
+	if err != nil {
+		return nil, err
+	}
+	return target.GetInformer(ctx, obj)
+}
+
+func (c *scopedCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind) (cache.Informer, error) {
+	target, err := c.cacheForKind(gvk)
+	if err != nil {
+		return nil, err
+	}
+	return target.GetInformerForKind(ctx, gvk)
+}
+
+func (c *scopedCache) IndexField(ctx context.Context, obj runtime.Object, field string, extractValue client.IndexerFunc) error {
+	target, err := c.cacheFor(obj)
+	if err != nil {
+		return err
+	}
+	return target.IndexField(ctx, obj, field, extractValue)
+}
+
+// Start runs both caches until stop is closed
+func (c *scopedCache) Start(stop <-chan struct{}) error {
+	errs := make(chan error, 2)
+	go func() { errs <- c.namespaced.Start(stop) }()
+	go func() { errs <- c.cluster.Start(stop) }()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *scopedCache) WaitForCacheSync(stop <-chan struct{}) bool {
+	namespacedSynced := c.namespaced.WaitForCacheSync(stop)
+	clusterSynced := c.cluster.WaitForCacheSync(stop)
+	return namespacedSynced && clusterSynced
+}
+
+func (c *scopedCache) cacheFor(obj runtime.Object) (cache.Cache, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return nil, err
+	}
+	if meta.IsListType(obj) {
+		gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	}
+	return c.cacheForKind(gvk)
+}
+
+func (c *scopedCache) cacheForKind(gvk schema.GroupVersionKind) (cache.Cache, error) {
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		return c.cluster, nil
+	}
+	return c.namespaced, nil
+}
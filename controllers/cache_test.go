@@ -0,0 +1,112 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+// stubCache distinguishes caches the scopedCache routes to
+type stubCache struct {
+	cache.Cache
+	name string
+}
+
+func TestScopedCacheRoutesClusterScopedObjectsToClusterCache(t *testing.T) {
+	// arrange
+	c := newTestScopedCache(t)
+	// act
+	objectCache, err := c.cacheFor(&k8gbv1beta1.ClusterPeer{})
+	require.NoError(t, err)
+	listCache, err := c.cacheFor(&k8gbv1beta1.ClusterPeerList{})
+	require.NoError(t, err)
+	// assert
+	assert.Same(t, c.cluster, objectCache)
+	assert.Same(t, c.cluster, listCache)
+}
+
+func TestScopedCacheRoutesNamespacedObjectsToNamespacedCache(t *testing.T) {
+	// arrange
+	c := newTestScopedCache(t)
+	// act
+	objectCache, err := c.cacheFor(&k8gbv1beta1.Gslb{})
+	require.NoError(t, err)
+	listCache, err := c.cacheFor(&k8gbv1beta1.GslbList{})
+	require.NoError(t, err)
+	kindCache, err := c.cacheForKind(corev1.SchemeGroupVersion.WithKind("Endpoints"))
+	require.NoError(t, err)
+	// assert
+	assert.Same(t, c.namespaced, objectCache)
+	assert.Same(t, c.namespaced, listCache)
+	assert.Same(t, c.namespaced, kindCache)
+}
+
+func TestScopedCacheFailsOnUnknownKind(t *testing.T) {
+	// arrange
+	c := newTestScopedCache(t)
+	// act
+	_, err := c.GetInformerForKind(context.TODO(), schema.GroupVersionKind{Group: "unknown.io", Version: "v1", Kind: "Unknown"})
+	// assert
+	assert.Error(t, err)
+}
+
+func TestNewScopedCache(t *testing.T) {
+	var tests = []struct {
+		name       string
+		namespaces []string
+		scoped     bool
+	}{
+		{name: "all namespaces", namespaces: []string{}, scoped: false},
+		{name: "watched namespaces", namespaces: []string{"team-a", "k8gb"}, scoped: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// arrange
+			c := newTestScopedCache(t)
+			config := &depresolver.Config{K8gbNamespace: "k8gb", WatchNamespaces: test.namespaces}
+			// act
+			result, err := NewScopedCache(config)(&rest.Config{Host: "localhost"}, cache.Options{Scheme: c.scheme, Mapper: c.mapper})
+			// assert
+			require.NoError(t, err)
+			_, scoped := result.(*scopedCache)
+			assert.Equal(t, test.scoped, scoped)
+		})
+	}
+}
+
+func newTestScopedCache(t *testing.T) *scopedCache {
+	s := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(s))
+	require.NoError(t, k8gbv1beta1.AddToScheme(s))
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{k8gbv1beta1.GroupVersion, corev1.SchemeGroupVersion})
+	mapper.Add(k8gbv1beta1.GroupVersion.WithKind("ClusterPeer"), meta.RESTScopeRoot)
+	mapper.Add(k8gbv1beta1.GroupVersion.WithKind("Gslb"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Endpoints"), meta.RESTScopeNamespace)
+	return &scopedCache{namespaced: &stubCache{name: "namespaced"}, cluster: &stubCache{name: "cluster"}, scheme: s, mapper: mapper}
+}
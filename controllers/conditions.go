@@ -0,0 +1,114 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// reasonError carries condition reason of the failed reconciliation step
+type reasonError struct {
+	reason string
+	err    error
+}
+
+func (e *reasonError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reasonError) Unwrap() error {
+	return e.err
+}
+
+// withReason annotates err by condition reason
+func withReason(reason string, err error) error {
+	return &reasonError{reason: reason, err: err}
+}
+
+// reasonOf returns condition reason carried by err or fallback
+func reasonOf(err error, fallback string) string {
+	var re *reasonError
+	if errors.As(err, &re) {
+		return re.reason
+	}
+	return fallback
+}
+
+// setCondition sets condition of given type observed in current generation of gslb
+func setCondition(gslb *k8gbv1beta1.Gslb, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&gslb.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: gslb.Generation,
+	})
+}
+
+// failed records err in conditionType, Ready and Degraded conditions of gslb and returns err.
+// conditionType is empty when failed step has no condition of its own
+func (r *GslbReconciler) failed(gslb *k8gbv1beta1.Gslb, conditionType, fallbackReason string, err error) error {
+	reason := reasonOf(err, fallbackReason)
+	if conditionType != "" {
+		setCondition(gslb, conditionType, metav1.ConditionFalse, reason, err.Error())
+	}
+	setCondition(gslb, k8gbv1beta1.ConditionReady, metav1.ConditionFalse, reason, err.Error())
+	setCondition(gslb, k8gbv1beta1.ConditionDegraded, metav1.ConditionTrue, reason, err.Error())
+	gslb.Status.ObservedGeneration = gslb.Generation
+	// serviceHealth and healthyRecords are required by CRD schema
+	if gslb.Status.ServiceHealth == nil {
+		gslb.Status.ServiceHealth = map[string]string{}
+	}
+	if gslb.Status.HealthyRecords == nil {
+		gslb.Status.HealthyRecords = map[string][]string{}
+	}
+	if statusErr := r.updateStatus(gslb); statusErr != nil {
+		log.Error(statusErr, "Failed to update Gslb conditions", "Gslb.Namespace", gslb.Namespace, "Gslb.Name", gslb.Name)
+	}
+	return err
+}
+
+// updateStatus writes status of gslb. Gslb is read back from the server without the spec defaults resolved in memory,
+// so the defaults are resolved again
+func (r *GslbReconciler) updateStatus(gslb *k8gbv1beta1.Gslb) error {
+	err := r.Status().Update(context.TODO(), gslb)
+	if err != nil {
+		return err
+	}
+	depresolver.DefaultGslbSpec(&gslb.Spec)
+	return nil
+}
+
+// ready records successful reconciliation of gslb. Gslb is degraded while some of external clusters are unreachable
+func ready(gslb *k8gbv1beta1.Gslb) {
+	setCondition(gslb, k8gbv1beta1.ConditionReady, metav1.ConditionTrue, k8gbv1beta1.ReasonReconciled, "Gslb is reconciled")
+	setCondition(gslb, k8gbv1beta1.ConditionDegraded, metav1.ConditionFalse, k8gbv1beta1.ReasonReconciled, "All clusters are reachable")
+	for _, peer := range gslb.Status.Peers {
+		if !peer.Reachable {
+			setCondition(gslb, k8gbv1beta1.ConditionDegraded, metav1.ConditionTrue, k8gbv1beta1.ReasonPeersUnreachable,
+				"Cluster "+peer.GeoTag+" is unreachable")
+			break
+		}
+	}
+	gslb.Status.ObservedGeneration = gslb.Generation
+}
@@ -19,15 +19,16 @@ limitations under the License.
 // - abstracts multiple configurations into single point of access
 // - provides predefined values when configuration is missing
 // - validates configuration
-// - executes once
+// - executes once, hot-reloadable fields can be reloaded from the config file
 package depresolver
 
 import (
+	"strings"
 	"sync"
 
-	"github.com/AbsaOSS/k8gb/api/v1beta1"
-
 	"github.com/rs/zerolog"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // LogFormat specifies how the logger prints values
@@ -70,6 +71,10 @@ const (
 	DNSTypeRoute53
 	// DNSTypeNS1 type
 	DNSTypeNS1
+	// DNSTypeCloudflare type
+	DNSTypeCloudflare
+	// DNSTypePlugin type
+	DNSTypePlugin
 )
 
 // Log configuration
@@ -98,6 +103,90 @@ type Infoblox struct {
 	HTTPRequestTimeout int
 	// HTTPPoolConnections seconds; default = 10
 	HTTPPoolConnections int
+	// View is DNS view where delegated zone and TXT records are created; default = default
+	View string
+	// TenantID sets "Tenant ID" extensible attribute of the objects created by k8gb; default = ""
+	TenantID string
+	// ExtensibleAttributes applied to delegated zone and TXT records; e.g. owner=team-a,cost-centre=1234
+	ExtensibleAttributes map[string]string
+}
+
+// Cloudflare configuration
+type Cloudflare struct {
+	// ZoneID of the Cloudflare zone which contains EdgeDNSZone records
+	ZoneID string
+	// APIToken with Zone.DNS edit permission. Usually provided from the secret
+	APIToken string
+	// APITokenFile path to the file containing APIToken, e.g. mounted secret. Has precedence over APIToken
+	APITokenFile string
+	// DelegationTTL of NS and glue records shared by all Gslbs of the zone; 0 or 1 is automatic TTL, lower values
+	// than 60 are raised to 60
+	DelegationTTL int
+}
+
+// Plugin configuration of out-of-process edge DNS provider
+type Plugin struct {
+	// Endpoint of the plugin, e.g. http://localhost:8090. Plugin is enabled when Endpoint is set
+	Endpoint string
+	// Timeout of plugin requests in seconds; default = 20
+	Timeout int
+}
+
+// DoT configures DNS over TLS used by queries to edge DNS server and to external clusters
+type DoT struct {
+	// Enabled if true, queries are sent over TLS to port 853; default = false
+	Enabled bool
+	// CAFile PEM bundle verifying DNS server certificates. System roots are used when empty
+	CAFile string
+	// ServerName verified in DNS server certificates. Name of the queried server is used when empty
+	ServerName string
+}
+
+// PeerAuth configures authentication of data received from other clusters
+type PeerAuth struct {
+	// TSIGKeyName name of TSIG key signing queries to other clusters. When set, unsigned or badly signed answers are rejected
+	TSIGKeyName string
+	// TSIGSecret base64 encoded secret of TSIG key
+	TSIGSecret string
+	// TSIGAlgorithm HMAC algorithm of TSIG key; default = hmac-sha256
+	TSIGAlgorithm string
+	// HeartbeatSecret shared secret signing split brain TXT heartbeats by HMAC-SHA256. When set, unsigned or badly
+	// signed heartbeats are rejected
+	HeartbeatSecret string
+}
+
+// PeerStatus configures HTTPS API exposing local health and targets to other clusters
+type PeerStatus struct {
+	// Address the API listens on, e.g. :8443. API isn't served when empty
+	Address string
+	// CertFile PEM certificate of the API server
+	CertFile string
+	// KeyFile PEM private key of the API server
+	KeyFile string
+	// CAFile PEM bundle verifying certificates of other clusters' APIs. System roots are used when empty
+	CAFile string
+	// Token shared by all clusters authenticating API requests. APIs of other clusters are queried only when set
+	Token string
+	// Timeout of requests to other clusters in seconds; default = 2
+	Timeout int
+}
+
+// DelegationZone is zone controlled by gslb and delegated from the zone of edge DNS
+type DelegationZone struct {
+	// DNSZone controlled by gslb; e.g. cloud.example.com
+	DNSZone string
+	// EdgeDNSZone main zone which would contain DNSZone to delegate; e.g. example.com
+	EdgeDNSZone string
+}
+
+// CredentialRef references credential stored out of environment variables. Exactly one of File and SecretName is set
+type CredentialRef struct {
+	// File containing the credential, e.g. key of Secret mounted as volume
+	File string
+	// SecretName of Secret in K8gbNamespace containing the credential
+	SecretName string
+	// SecretKey of the credential in the Secret
+	SecretKey string
 }
 
 // Override configuration
@@ -110,12 +199,24 @@ type Override struct {
 
 // Config is operator configuration returned by depResolver
 type Config struct {
-	// Reschedule of Reconcile loop to pickup external Gslb targets
+	// Reschedule of Reconcile loop to pickup external Gslb targets; safety net behind the peer watcher
 	ReconcileRequeueSeconds int
+	// PeerWatchIntervalSeconds how often targets and heartbeats of external clusters are polled. Only Gslbs whose
+	// external view changed are reconciled; 0 disables the watcher; default = 10
+	PeerWatchIntervalSeconds int
 	// ClusterGeoTag to determine specific location
 	ClusterGeoTag string
 	// ExtClustersGeoTags to identify clusters in other locations in format separated by comma. i.e.: "eu,uk,us"
 	ExtClustersGeoTags []string
+	// K8gbVersion version of the operator advertised to other clusters
+	K8gbVersion string
+	// ClusterDraining if true, other clusters stop delegating to this cluster; default = false
+	ClusterDraining bool
+	// ClusterCapacity hint of relative capacity of this cluster advertised to other clusters; 0 = unknown
+	ClusterCapacity int
+	// LegacyHeartbeat if true, split brain TXT heartbeat contains bare timestamp readable by clusters of older versions;
+	// draining and capacity are not announced then; default = false
+	LegacyHeartbeat bool
 	// EdgeDNSType is READONLY and is set automatically by configuration
 	EdgeDNSType EdgeDNSType
 	// EdgeDNSServer
@@ -124,20 +225,114 @@ type Config struct {
 	EdgeDNSZone string
 	// DNSZone controlled by gslb; e.g. cloud.example.com
 	DNSZone string
+	// AdditionalDNSZones delegated next to DNSZone, each from its own edge DNS zone; e.g. api.example.org:example.org
+	AdditionalDNSZones []DelegationZone
 	// K8gbNamespace k8gb namespace
 	K8gbNamespace string
+	// WatchNamespaces restricts the operator to Gslbs and their resources in listed namespaces. All namespaces are
+	// watched when empty; e.g. "team-a,team-b"
+	WatchNamespaces []string
+	// GslbLabelSelector restricts the operator to Gslbs and annotated Ingresses matching the selector. All Gslbs are
+	// managed when empty; e.g. "k8gb.io/instance=internal"
+	GslbLabelSelector string
 	// Infoblox configuration
 	Infoblox Infoblox
+	// Cloudflare configuration
+	Cloudflare Cloudflare
+	// Plugin configuration
+	Plugin Plugin
+	// DoT configuration
+	DoT DoT
+	// PeerAuth configuration
+	PeerAuth PeerAuth
+	// PeerStatus configuration
+	PeerStatus PeerStatus
+	// Credentials references credentials by keys of their environment variables. Referenced credentials are read into
+	// the fields of the configuration and are hot-reloaded when they change; e.g. Infoblox password referenced by
+	// EXTERNAL_DNS_INFOBLOX_WAPI_PASSWORD_SECRET=infoblox/password
+	Credentials map[string]CredentialRef
 	// Override the behavior of GSLB in the test environments
 	Override Override
 	// route53Enabled hidden. EdgeDNSType defines all enabled Enabled types
 	route53Enabled bool
 	// ns1Enabled flag
 	ns1Enabled bool
+	// cloudflareEnabled flag
+	cloudflareEnabled bool
 	// CoreDNSExposed flag
 	CoreDNSExposed bool
+	// DryRun if true, changes of edge DNS are only recorded and logged, but not applied; default = false
+	DryRun bool
+	// WebhookEnabled if true, admission webhooks of Gslb are served; default = false
+	WebhookEnabled bool
 	// Log configuration
 	Log Log
+	// ConfigFile path of yaml file overriding environment variables, usually mounted from ConfigMap. Hot-reloadable
+	// fields are reloaded when the file changes; e.g. /etc/k8gb/config/config.yaml
+	ConfigFile string
+}
+
+// GslbSelector returns parsed GslbLabelSelector. Empty GslbLabelSelector matches everything, invalid one matches
+// nothing; invalid selector is reported by ResolveOperatorConfig
+func (c *Config) GslbSelector() labels.Selector {
+	selector, err := labels.Parse(c.GslbLabelSelector)
+	if err != nil {
+		return labels.Nothing()
+	}
+	return selector
+}
+
+// IsWatchedNamespace returns true if namespace is in WatchNamespaces or WatchNamespaces is empty
+func (c *Config) IsWatchedNamespace(namespace string) bool {
+	return len(c.WatchNamespaces) == 0 || contains(c.WatchNamespaces, namespace)
+}
+
+// IsManaged returns true if object of given namespace and labels is in the scope of the operator
+func (c *Config) IsManaged(namespace string, objectLabels map[string]string) bool {
+	return c.IsWatchedNamespace(namespace) && c.GslbSelector().Matches(labels.Set(objectLabels))
+}
+
+// DelegationZones returns DNSZone followed by AdditionalDNSZones
+func (c *Config) DelegationZones() []DelegationZone {
+	zones := []DelegationZone{{DNSZone: c.DNSZone, EdgeDNSZone: c.EdgeDNSZone}}
+	return append(zones, c.AdditionalDNSZones...)
+}
+
+// EdgeDNSZones returns comma separated distinct edge DNS zones of DelegationZones
+func (c *Config) EdgeDNSZones() string {
+	var zones []string
+	for _, zone := range c.DelegationZones() {
+		if !contains(zones, zone.EdgeDNSZone) {
+			zones = append(zones, zone.EdgeDNSZone)
+		}
+	}
+	return strings.Join(zones, ",")
+}
+
+// ZoneOf returns delegation zone of host. Host must be within EdgeDNSZone of the zone. When more zones match,
+// the longest DNSZone containing the host wins, followed by the longest EdgeDNSZone containing the host
+func (c *Config) ZoneOf(host string) (zone DelegationZone, found bool) {
+	for _, z := range c.DelegationZones() {
+		if HostInZone(host, z.DNSZone) && HostInZone(host, z.EdgeDNSZone) && (!found || len(z.DNSZone) > len(zone.DNSZone)) {
+			zone, found = z, true
+		}
+	}
+	if found {
+		return
+	}
+	for _, z := range c.DelegationZones() {
+		if HostInZone(host, z.EdgeDNSZone) && (!found || len(z.EdgeDNSZone) > len(zone.EdgeDNSZone)) {
+			zone, found = z, true
+		}
+	}
+	return
+}
+
+// ForZone returns copy of the configuration where DNSZone and EdgeDNSZone are set to zone
+func (c Config) ForZone(zone DelegationZone) Config {
+	c.DNSZone = zone.DNSZone
+	c.EdgeDNSZone = zone.EdgeDNSZone
+	return c
 }
 
 // DependencyResolver resolves configuration for GSLB
@@ -145,8 +340,12 @@ type DependencyResolver struct {
 	config      *Config
 	onceConfig  sync.Once
 	errorConfig error
-	errorSpec   error
-	spec        v1beta1.GslbSpec
+	// secrets reads Secrets referenced by Config.Credentials
+	secrets client.Reader
+	// live is config with hot-reloaded fields applied by ReloadOperatorConfig
+	live       *Config
+	generation int
+	liveMu     sync.RWMutex
 }
 
 // NewDependencyResolver returns a new depresolver.DependencyResolver
@@ -154,3 +353,10 @@ func NewDependencyResolver() *DependencyResolver {
 	resolver := new(DependencyResolver)
 	return resolver
 }
+
+// WithSecretReader makes resolver read credentials referenced by Secret name and key. Credentials can't be
+// referenced by Secrets without reader
+func (dr *DependencyResolver) WithSecretReader(reader client.Reader) *DependencyResolver {
+	dr.secrets = reader
+	return dr
+}
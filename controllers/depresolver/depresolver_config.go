@@ -22,68 +22,164 @@ import (
 
 	"github.com/AbsaOSS/gopkg/env"
 	"github.com/rs/zerolog"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // Environment variables keys
 const (
-	ReconcileRequeueSecondsKey = "RECONCILE_REQUEUE_SECONDS"
-	ClusterGeoTagKey           = "CLUSTER_GEO_TAG"
-	ExtClustersGeoTagsKey      = "EXT_GSLB_CLUSTERS_GEO_TAGS"
-	Route53EnabledKey          = "ROUTE53_ENABLED"
-	NS1EnabledKey              = "NS1_ENABLED"
-	EdgeDNSServerKey           = "EDGE_DNS_SERVER"
-	EdgeDNSZoneKey             = "EDGE_DNS_ZONE"
-	DNSZoneKey                 = "DNS_ZONE"
-	InfobloxGridHostKey        = "INFOBLOX_GRID_HOST"
-	InfobloxVersionKey         = "INFOBLOX_WAPI_VERSION"
-	InfobloxPortKey            = "INFOBLOX_WAPI_PORT"
-	InfobloxUsernameKey        = "EXTERNAL_DNS_INFOBLOX_WAPI_USERNAME"
+	ReconcileRequeueSecondsKey  = "RECONCILE_REQUEUE_SECONDS"
+	PeerWatchIntervalSecondsKey = "PEER_WATCH_INTERVAL_SECONDS"
+	ClusterGeoTagKey            = "CLUSTER_GEO_TAG"
+	ExtClustersGeoTagsKey       = "EXT_GSLB_CLUSTERS_GEO_TAGS"
+	K8gbVersionKey              = "K8GB_VERSION"
+	ClusterDrainingKey          = "CLUSTER_DRAINING"
+	ClusterCapacityKey          = "CLUSTER_CAPACITY"
+	LegacyHeartbeatKey          = "LEGACY_HEARTBEAT_ENABLED"
+	Route53EnabledKey           = "ROUTE53_ENABLED"
+	NS1EnabledKey               = "NS1_ENABLED"
+	EdgeDNSServerKey            = "EDGE_DNS_SERVER"
+	EdgeDNSZoneKey              = "EDGE_DNS_ZONE"
+	DNSZoneKey                  = "DNS_ZONE"
+	AdditionalDNSZonesKey       = "ADDITIONAL_DNS_ZONES"
+	InfobloxGridHostKey         = "INFOBLOX_GRID_HOST"
+	InfobloxVersionKey          = "INFOBLOX_WAPI_VERSION"
+	InfobloxPortKey             = "INFOBLOX_WAPI_PORT"
+	InfobloxUsernameKey         = "EXTERNAL_DNS_INFOBLOX_WAPI_USERNAME"
 	// #nosec G101; ignore false positive gosec; see: https://securego.io/docs/rules/g101.html
 	InfobloxPasswordKey            = "EXTERNAL_DNS_INFOBLOX_WAPI_PASSWORD"
 	InfobloxHTTPRequestTimeoutKey  = "INFOBLOX_HTTP_REQUEST_TIMEOUT"
 	InfobloxHTTPPoolConnectionsKey = "INFOBLOX_HTTP_POOL_CONNECTIONS"
+	InfobloxViewKey                = "INFOBLOX_VIEW"
+	InfobloxTenantIDKey            = "INFOBLOX_TENANT_ID"
+	InfobloxExtensibleAttrsKey     = "INFOBLOX_EXTENSIBLE_ATTRIBUTES"
 	OverrideWithFakeDNSKey         = "OVERRIDE_WITH_FAKE_EXT_DNS"
 	OverrideFakeInfobloxKey        = "FAKE_INFOBLOX"
 	K8gbNamespaceKey               = "POD_NAMESPACE"
 	CoreDNSExposedKey              = "COREDNS_EXPOSED"
+	DryRunKey                      = "DRY_RUN_ENABLED"
 	LogLevelKey                    = "LOG_LEVEL"
 	LogFormatKey                   = "LOG_FORMAT"
 	LogNoColorKey                  = "LOG_NO_COLOR"
+	CloudflareEnabledKey           = "CLOUDFLARE_ENABLED"
+	CloudflareZoneIDKey            = "CLOUDFLARE_ZONE_ID"
+	// #nosec G101; ignore false positive gosec; see: https://securego.io/docs/rules/g101.html
+	CloudflareAPITokenKey = "CLOUDFLARE_API_TOKEN"
+	// #nosec G101; ignore false positive gosec; see: https://securego.io/docs/rules/g101.html
+	CloudflareAPITokenFileKey  = "CLOUDFLARE_API_TOKEN_FILE"
+	CloudflareDelegationTTLKey = "CLOUDFLARE_DELEGATION_TTL"
+	DNSPluginEndpointKey       = "DNS_PLUGIN_ENDPOINT"
+	DNSPluginTimeoutKey        = "DNS_PLUGIN_TIMEOUT"
+	DoTEnabledKey              = "DOT_ENABLED"
+	DoTCAFileKey               = "DOT_CA_FILE"
+	DoTServerNameKey           = "DOT_SERVER_NAME"
+	PeerTSIGKeyNameKey         = "PEER_TSIG_KEY_NAME"
+	PeerTSIGAlgorithmKey       = "PEER_TSIG_ALGORITHM"
+	// #nosec G101; ignore false positive gosec; see: https://securego.io/docs/rules/g101.html
+	PeerTSIGSecretKey = "PEER_TSIG_SECRET"
+	// #nosec G101; ignore false positive gosec; see: https://securego.io/docs/rules/g101.html
+	HeartbeatHMACSecretKey = "HEARTBEAT_HMAC_SECRET"
+	PeerStatusAddressKey   = "PEER_STATUS_ADDRESS"
+	PeerStatusCertFileKey  = "PEER_STATUS_CERT_FILE"
+	PeerStatusKeyFileKey   = "PEER_STATUS_KEY_FILE"
+	PeerStatusCAFileKey    = "PEER_STATUS_CA_FILE"
+	// #nosec G101; ignore false positive gosec; see: https://securego.io/docs/rules/g101.html
+	PeerStatusTokenKey   = "PEER_STATUS_TOKEN"
+	PeerStatusTimeoutKey = "PEER_STATUS_TIMEOUT"
+	WebhookEnabledKey    = "WEBHOOK_ENABLED"
+	WatchNamespacesKey   = "WATCH_NAMESPACES"
+	GslbLabelSelectorKey = "GSLB_LABEL_SELECTOR"
+	ConfigFileKey        = "CONFIG_FILE"
 )
 
 // ResolveOperatorConfig executes once. It reads operator's configuration
-// from environment variables into &Config and validates
+// from environment variables into &Config, overrides it by the config file and validates
 func (dr *DependencyResolver) ResolveOperatorConfig() (*Config, error) {
 	dr.onceConfig.Do(func() {
-		dr.config = &Config{}
-		dr.config.ReconcileRequeueSeconds, _ = env.GetEnvAsIntOrFallback(ReconcileRequeueSecondsKey, 30)
-		dr.config.ClusterGeoTag = env.GetEnvAsStringOrFallback(ClusterGeoTagKey, "")
-		dr.config.ExtClustersGeoTags = env.GetEnvAsArrayOfStringsOrFallback(ExtClustersGeoTagsKey, []string{})
-		dr.config.route53Enabled = env.GetEnvAsBoolOrFallback(Route53EnabledKey, false)
-		dr.config.ns1Enabled = env.GetEnvAsBoolOrFallback(NS1EnabledKey, false)
-		dr.config.CoreDNSExposed = env.GetEnvAsBoolOrFallback(CoreDNSExposedKey, false)
-		dr.config.EdgeDNSServer = env.GetEnvAsStringOrFallback(EdgeDNSServerKey, "")
-		dr.config.EdgeDNSZone = env.GetEnvAsStringOrFallback(EdgeDNSZoneKey, "")
-		dr.config.DNSZone = env.GetEnvAsStringOrFallback(DNSZoneKey, "")
-		dr.config.K8gbNamespace = env.GetEnvAsStringOrFallback(K8gbNamespaceKey, "")
-		dr.config.Infoblox.Host = env.GetEnvAsStringOrFallback(InfobloxGridHostKey, "")
-		dr.config.Infoblox.Version = env.GetEnvAsStringOrFallback(InfobloxVersionKey, "")
-		dr.config.Infoblox.Port, _ = env.GetEnvAsIntOrFallback(InfobloxPortKey, 0)
-		dr.config.Infoblox.Username = env.GetEnvAsStringOrFallback(InfobloxUsernameKey, "")
-		dr.config.Infoblox.Password = env.GetEnvAsStringOrFallback(InfobloxPasswordKey, "")
-		dr.config.Infoblox.HTTPPoolConnections, _ = env.GetEnvAsIntOrFallback(InfobloxHTTPPoolConnectionsKey, 10)
-		dr.config.Infoblox.HTTPRequestTimeout, _ = env.GetEnvAsIntOrFallback(InfobloxHTTPRequestTimeoutKey, 20)
-		dr.config.Override.FakeDNSEnabled = env.GetEnvAsBoolOrFallback(OverrideWithFakeDNSKey, false)
-		dr.config.Override.FakeInfobloxEnabled = env.GetEnvAsBoolOrFallback(OverrideFakeInfobloxKey, false)
-		dr.config.Log.Level, _ = zerolog.ParseLevel(strings.ToLower(env.GetEnvAsStringOrFallback(LogLevelKey, zerolog.InfoLevel.String())))
-		dr.config.Log.Format = parseLogOutputFormat(strings.ToLower(env.GetEnvAsStringOrFallback(LogFormatKey, JSONFormat.String())))
-		dr.config.Log.NoColor = env.GetEnvAsBoolOrFallback(LogNoColorKey, true)
-		dr.errorConfig = dr.validateConfig(dr.config)
-		dr.config.EdgeDNSType = getEdgeDNSType(dr.config)
+		dr.config, dr.errorConfig = dr.readConfig()
+		dr.liveMu.Lock()
+		defer dr.liveMu.Unlock()
+		dr.live = dr.config
+		if dr.errorConfig == nil {
+			dr.generation = 1
+		}
 	})
 	return dr.config, dr.errorConfig
 }
 
+// readConfig reads configuration from environment variables. When ConfigFileKey is set, values of the config file
+// take precedence over environment variables. Referenced credentials take precedence over their environment variables
+func (dr *DependencyResolver) readConfig() (config *Config, err error) {
+	config = &Config{}
+	config.ReconcileRequeueSeconds, _ = env.GetEnvAsIntOrFallback(ReconcileRequeueSecondsKey, 300)
+	config.PeerWatchIntervalSeconds, _ = env.GetEnvAsIntOrFallback(PeerWatchIntervalSecondsKey, 10)
+	config.ClusterGeoTag = env.GetEnvAsStringOrFallback(ClusterGeoTagKey, "")
+	config.ExtClustersGeoTags = env.GetEnvAsArrayOfStringsOrFallback(ExtClustersGeoTagsKey, []string{})
+	config.K8gbVersion = env.GetEnvAsStringOrFallback(K8gbVersionKey, "")
+	config.ClusterDraining = env.GetEnvAsBoolOrFallback(ClusterDrainingKey, false)
+	config.ClusterCapacity, _ = env.GetEnvAsIntOrFallback(ClusterCapacityKey, 0)
+	config.LegacyHeartbeat = env.GetEnvAsBoolOrFallback(LegacyHeartbeatKey, false)
+	config.route53Enabled = env.GetEnvAsBoolOrFallback(Route53EnabledKey, false)
+	config.ns1Enabled = env.GetEnvAsBoolOrFallback(NS1EnabledKey, false)
+	config.cloudflareEnabled = env.GetEnvAsBoolOrFallback(CloudflareEnabledKey, false)
+	config.CoreDNSExposed = env.GetEnvAsBoolOrFallback(CoreDNSExposedKey, false)
+	config.DryRun = env.GetEnvAsBoolOrFallback(DryRunKey, false)
+	config.WebhookEnabled = env.GetEnvAsBoolOrFallback(WebhookEnabledKey, false)
+	config.EdgeDNSServer = env.GetEnvAsStringOrFallback(EdgeDNSServerKey, "")
+	config.EdgeDNSZone = env.GetEnvAsStringOrFallback(EdgeDNSZoneKey, "")
+	config.DNSZone = env.GetEnvAsStringOrFallback(DNSZoneKey, "")
+	config.AdditionalDNSZones = parseDelegationZones(env.GetEnvAsStringOrFallback(AdditionalDNSZonesKey, ""))
+	config.K8gbNamespace = env.GetEnvAsStringOrFallback(K8gbNamespaceKey, "")
+	config.WatchNamespaces = env.GetEnvAsArrayOfStringsOrFallback(WatchNamespacesKey, []string{})
+	config.GslbLabelSelector = env.GetEnvAsStringOrFallback(GslbLabelSelectorKey, "")
+	config.Infoblox.Host = env.GetEnvAsStringOrFallback(InfobloxGridHostKey, "")
+	config.Infoblox.Version = env.GetEnvAsStringOrFallback(InfobloxVersionKey, "")
+	config.Infoblox.Port, _ = env.GetEnvAsIntOrFallback(InfobloxPortKey, 0)
+	config.Infoblox.Username = env.GetEnvAsStringOrFallback(InfobloxUsernameKey, "")
+	config.Infoblox.Password = env.GetEnvAsStringOrFallback(InfobloxPasswordKey, "")
+	config.Infoblox.HTTPPoolConnections, _ = env.GetEnvAsIntOrFallback(InfobloxHTTPPoolConnectionsKey, 10)
+	config.Infoblox.HTTPRequestTimeout, _ = env.GetEnvAsIntOrFallback(InfobloxHTTPRequestTimeoutKey, 20)
+	config.Infoblox.View = env.GetEnvAsStringOrFallback(InfobloxViewKey, "default")
+	config.Infoblox.TenantID = env.GetEnvAsStringOrFallback(InfobloxTenantIDKey, "")
+	config.Infoblox.ExtensibleAttributes = parseExtensibleAttributes(env.GetEnvAsStringOrFallback(InfobloxExtensibleAttrsKey, ""))
+	config.Cloudflare.ZoneID = env.GetEnvAsStringOrFallback(CloudflareZoneIDKey, "")
+	config.Cloudflare.APIToken = env.GetEnvAsStringOrFallback(CloudflareAPITokenKey, "")
+	config.Cloudflare.APITokenFile = env.GetEnvAsStringOrFallback(CloudflareAPITokenFileKey, "")
+	config.Cloudflare.DelegationTTL, _ = env.GetEnvAsIntOrFallback(CloudflareDelegationTTLKey, 0)
+	config.Plugin.Endpoint = env.GetEnvAsStringOrFallback(DNSPluginEndpointKey, "")
+	config.Plugin.Timeout, _ = env.GetEnvAsIntOrFallback(DNSPluginTimeoutKey, 20)
+	config.DoT.Enabled = env.GetEnvAsBoolOrFallback(DoTEnabledKey, false)
+	config.DoT.CAFile = env.GetEnvAsStringOrFallback(DoTCAFileKey, "")
+	config.DoT.ServerName = env.GetEnvAsStringOrFallback(DoTServerNameKey, "")
+	config.PeerAuth.TSIGKeyName = env.GetEnvAsStringOrFallback(PeerTSIGKeyNameKey, "")
+	config.PeerAuth.TSIGSecret = env.GetEnvAsStringOrFallback(PeerTSIGSecretKey, "")
+	config.PeerAuth.TSIGAlgorithm = env.GetEnvAsStringOrFallback(PeerTSIGAlgorithmKey, "hmac-sha256")
+	config.PeerAuth.HeartbeatSecret = env.GetEnvAsStringOrFallback(HeartbeatHMACSecretKey, "")
+	config.PeerStatus.Address = env.GetEnvAsStringOrFallback(PeerStatusAddressKey, "")
+	config.PeerStatus.CertFile = env.GetEnvAsStringOrFallback(PeerStatusCertFileKey, "")
+	config.PeerStatus.KeyFile = env.GetEnvAsStringOrFallback(PeerStatusKeyFileKey, "")
+	config.PeerStatus.CAFile = env.GetEnvAsStringOrFallback(PeerStatusCAFileKey, "")
+	config.PeerStatus.Token = env.GetEnvAsStringOrFallback(PeerStatusTokenKey, "")
+	config.PeerStatus.Timeout, _ = env.GetEnvAsIntOrFallback(PeerStatusTimeoutKey, 2)
+	config.Override.FakeDNSEnabled = env.GetEnvAsBoolOrFallback(OverrideWithFakeDNSKey, false)
+	config.Override.FakeInfobloxEnabled = env.GetEnvAsBoolOrFallback(OverrideFakeInfobloxKey, false)
+	config.Log.Level, _ = zerolog.ParseLevel(strings.ToLower(env.GetEnvAsStringOrFallback(LogLevelKey, zerolog.InfoLevel.String())))
+	config.Log.Format = parseLogOutputFormat(strings.ToLower(env.GetEnvAsStringOrFallback(LogFormatKey, JSONFormat.String())))
+	config.Log.NoColor = env.GetEnvAsBoolOrFallback(LogNoColorKey, true)
+	config.Credentials = readCredentialRefs()
+	config.ConfigFile = env.GetEnvAsStringOrFallback(ConfigFileKey, "")
+	if config.ConfigFile != "" {
+		err = readConfigFile(config.ConfigFile, config)
+	}
+	if err == nil {
+		err = dr.resolveCredentials(config)
+	}
+	if err == nil {
+		err = dr.validateConfig(config)
+	}
+	config.EdgeDNSType = getEdgeDNSType(config)
+	return
+}
+
 func (dr *DependencyResolver) validateConfig(config *Config) (err error) {
 	if config.Log.Level == zerolog.NoLevel {
 		return fmt.Errorf("invalid %s, allowed values ['','%s','%s','%s','%s','%s','%s','%s']", LogLevelKey,
@@ -97,10 +193,27 @@ func (dr *DependencyResolver) validateConfig(config *Config) (err error) {
 	if err != nil {
 		return err
 	}
+	err = field("watchNamespaces", config.WatchNamespaces).hasUniqueItems().err
+	if err != nil {
+		return err
+	}
+	for i, ns := range config.WatchNamespaces {
+		err = field(fmt.Sprintf("watchNamespaces[%v]", i), ns).isNotEmpty().matchRegexp(k8sNamespaceRegex).err
+		if err != nil {
+			return err
+		}
+	}
+	if _, err = labels.Parse(config.GslbLabelSelector); err != nil {
+		return fmt.Errorf("invalid %s: %w", GslbLabelSelectorKey, err)
+	}
 	err = field("reconcileRequeueSeconds", config.ReconcileRequeueSeconds).isHigherThanZero().err
 	if err != nil {
 		return err
 	}
+	err = field("peerWatchIntervalSeconds", config.PeerWatchIntervalSeconds).isHigherOrEqualToZero().err
+	if err != nil {
+		return err
+	}
 	err = field("clusterGeoTag", config.ClusterGeoTag).isNotEmpty().matchRegexp(geoTagRegex).err
 	if err != nil {
 		return err
@@ -127,6 +240,22 @@ func (dr *DependencyResolver) validateConfig(config *Config) (err error) {
 	if err != nil {
 		return err
 	}
+	dnsZones := []string{strings.ToLower(config.DNSZone)}
+	for i, zone := range config.AdditionalDNSZones {
+		err = field(fmt.Sprintf("additionalDNSZones[%v].DNSZone", i), zone.DNSZone).isNotEmpty().matchRegexp(hostNameRegex).err
+		if err != nil {
+			return err
+		}
+		err = field(fmt.Sprintf("additionalDNSZones[%v].edgeDNSZone", i), zone.EdgeDNSZone).isNotEmpty().matchRegexp(hostNameRegex).err
+		if err != nil {
+			return err
+		}
+		dnsZones = append(dnsZones, strings.ToLower(zone.DNSZone))
+	}
+	err = field("DNSZones", dnsZones).hasUniqueItems().err
+	if err != nil {
+		return err
+	}
 	// do full Infoblox validation only in case that Host exists
 	if isNotEmpty(config.Infoblox.Host) {
 		err = field("InfobloxGridHost", config.Infoblox.Host).matchRegexps(hostNameRegex, ipAddressRegex).err
@@ -157,6 +286,100 @@ func (dr *DependencyResolver) validateConfig(config *Config) (err error) {
 		if err != nil {
 			return err
 		}
+		err = field("InfobloxView", config.Infoblox.View).isNotEmpty().err
+		if err != nil {
+			return err
+		}
+		for name, value := range config.Infoblox.ExtensibleAttributes {
+			err = field("InfobloxExtensibleAttributes", name).isNotEmpty().err
+			if err != nil {
+				return err
+			}
+			err = field(fmt.Sprintf("InfobloxExtensibleAttributes[%s]", name), value).isNotEmpty().err
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if config.cloudflareEnabled {
+		err = field("CloudflareZoneID", config.Cloudflare.ZoneID).isNotEmpty().err
+		if err != nil {
+			return err
+		}
+		if !isNotEmpty(config.Cloudflare.APIToken) && !isNotEmpty(config.Cloudflare.APITokenFile) {
+			return fmt.Errorf("%s or %s must be set when Cloudflare is enabled", CloudflareAPITokenKey, CloudflareAPITokenFileKey)
+		}
+		// all records are written into single Cloudflare zone
+		for _, zone := range config.AdditionalDNSZones {
+			if !strings.EqualFold(zone.EdgeDNSZone, config.EdgeDNSZone) {
+				return fmt.Errorf("%s %s must be delegated from %s when Cloudflare is enabled", AdditionalDNSZonesKey,
+					zone.DNSZone, config.EdgeDNSZone)
+			}
+		}
+	}
+	if isNotEmpty(config.Plugin.Endpoint) {
+		err = field("DNSPluginEndpoint", config.Plugin.Endpoint).matchRegexp(pluginEndpointRegex).err
+		if err != nil {
+			return err
+		}
+		err = field("DNSPluginTimeout", config.Plugin.Timeout).isHigherThanZero().err
+		if err != nil {
+			return err
+		}
+	}
+	if isNotEmpty(config.K8gbVersion) {
+		err = field("k8gbVersion", config.K8gbVersion).matchRegexp(versionNumberRegex).err
+		if err != nil {
+			return err
+		}
+	}
+	err = field("clusterCapacity", config.ClusterCapacity).isHigherOrEqualToZero().err
+	if err != nil {
+		return err
+	}
+	if isNotEmpty(config.PeerAuth.TSIGKeyName) {
+		err = field("PeerTSIGKeyName", config.PeerAuth.TSIGKeyName).matchRegexp(hostNameRegex).err
+		if err != nil {
+			return err
+		}
+		err = field("PeerTSIGSecret", config.PeerAuth.TSIGSecret).isNotEmpty().matchRegexp(base64Regex).err
+		if err != nil {
+			return err
+		}
+		err = field("PeerTSIGAlgorithm", config.PeerAuth.TSIGAlgorithm).isNotEmpty().matchRegexp(tsigAlgorithmRegex).err
+		if err != nil {
+			return err
+		}
+	}
+	if isNotEmpty(config.DoT.ServerName) {
+		err = field("DoTServerName", config.DoT.ServerName).matchRegexp(hostNameRegex).err
+		if err != nil {
+			return err
+		}
+	}
+	if isNotEmpty(config.PeerStatus.Address) {
+		err = field("PeerStatusAddress", config.PeerStatus.Address).matchRegexp(listenAddressRegex).err
+		if err != nil {
+			return err
+		}
+		err = field("PeerStatusCertFile", config.PeerStatus.CertFile).isNotEmpty().err
+		if err != nil {
+			return err
+		}
+		err = field("PeerStatusKeyFile", config.PeerStatus.KeyFile).isNotEmpty().err
+		if err != nil {
+			return err
+		}
+		err = field("PeerStatusToken", config.PeerStatus.Token).isNotEmpty().err
+		if err != nil {
+			return err
+		}
+	}
+	if isNotEmpty(config.PeerStatus.Token) {
+		err = field("PeerStatusTimeout", config.PeerStatus.Timeout).isHigherThanZero().err
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -170,15 +393,59 @@ func getEdgeDNSType(config *Config) EdgeDNSType {
 	if config.route53Enabled {
 		t |= DNSTypeRoute53
 	}
+	if config.cloudflareEnabled {
+		t |= DNSTypeCloudflare
+	}
 	if isNotEmpty(config.Infoblox.Host) {
 		t |= DNSTypeInfoblox
 	}
+	if isNotEmpty(config.Plugin.Endpoint) {
+		t |= DNSTypePlugin
+	}
 	if t > DNSTypeNoEdgeDNS {
 		t -= DNSTypeNoEdgeDNS
 	}
 	return t
 }
 
+// parseExtensibleAttributes reads comma separated list of name=value pairs, e.g. "owner=team-a,cost-centre=1234".
+// Unlike other array variables, spaces are preserved because Infoblox attribute names and values may contain them.
+// Malformed items are kept with empty name or value so that validateConfig can report them.
+func parseExtensibleAttributes(value string) map[string]string {
+	attributes := make(map[string]string)
+	if strings.TrimSpace(value) == "" {
+		return attributes
+	}
+	for _, item := range strings.Split(value, ",") {
+		kv := strings.SplitN(item, "=", 2)
+		name := strings.TrimSpace(kv[0])
+		attributes[name] = ""
+		if len(kv) == 2 {
+			attributes[name] = strings.TrimSpace(kv[1])
+		}
+	}
+	return attributes
+}
+
+// parseDelegationZones reads comma separated list of DNSZone:EdgeDNSZone pairs, e.g.
+// "api.example.org:example.org,cloud.example.net:example.net". Malformed items are kept with empty zones so that
+// validateConfig can report them
+func parseDelegationZones(value string) []DelegationZone {
+	var zones []DelegationZone
+	if strings.TrimSpace(value) == "" {
+		return zones
+	}
+	for _, item := range strings.Split(value, ",") {
+		kv := strings.SplitN(item, ":", 2)
+		zone := DelegationZone{DNSZone: strings.TrimSpace(kv[0])}
+		if len(kv) == 2 {
+			zone.EdgeDNSZone = strings.TrimSpace(kv[1])
+		}
+		zones = append(zones, zone)
+	}
+	return zones
+}
+
 func parseLogOutputFormat(value string) LogFormat {
 	switch value {
 	case json:
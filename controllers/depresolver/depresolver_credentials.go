@@ -0,0 +1,145 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package depresolver
+
+import (
+	"context"
+	coreerrors "errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/AbsaOSS/gopkg/env"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// CredentialFileSuffix suffixes key of credential environment variable to reference file containing the credential;
+	// e.g. EXTERNAL_DNS_INFOBLOX_WAPI_PASSWORD_FILE=/etc/k8gb/infoblox/password
+	CredentialFileSuffix = "_FILE"
+	// CredentialSecretSuffix suffixes key of credential environment variable to reference Secret in K8gbNamespace
+	// containing the credential in format <name>/<key>; e.g. EXTERNAL_DNS_INFOBLOX_WAPI_PASSWORD_SECRET=infoblox/password
+	CredentialSecretSuffix = "_SECRET"
+)
+
+// errCredentialNotFound is returned when referenced Secret, its key or file doesn't exist
+var errCredentialNotFound = coreerrors.New("credential not found")
+
+// optionalCredentials are empty when their Secret, key or file doesn't exist, like environment variables populated by
+// optional secretKeyRef. Running operator reads them again, so that a credential created later is applied without restart
+var optionalCredentials = map[string]bool{
+	HeartbeatHMACSecretKey: true,
+}
+
+// credentials returns credential fields of config by keys of their environment variables. Every credential can be
+// referenced by environment variable with CredentialFileSuffix or CredentialSecretSuffix
+func credentials(config *Config) map[string]*string {
+	return map[string]*string{
+		InfobloxUsernameKey:    &config.Infoblox.Username,
+		InfobloxPasswordKey:    &config.Infoblox.Password,
+		CloudflareAPITokenKey:  &config.Cloudflare.APIToken,
+		PeerTSIGSecretKey:      &config.PeerAuth.TSIGSecret,
+		HeartbeatHMACSecretKey: &config.PeerAuth.HeartbeatSecret,
+		PeerStatusTokenKey:     &config.PeerStatus.Token,
+	}
+}
+
+// readCredentialRefs reads references of credentials from environment variables. Malformed Secret references are
+// kept with empty name or key so that resolveCredentials can report them
+func readCredentialRefs() (refs map[string]CredentialRef) {
+	for key := range credentials(&Config{}) {
+		ref := CredentialRef{File: env.GetEnvAsStringOrFallback(key+CredentialFileSuffix, "")}
+		if key+CredentialFileSuffix == CloudflareAPITokenFileKey {
+			// predates references; the file is read by Cloudflare provider on every request
+			ref.File = ""
+		}
+		if secret := env.GetEnvAsStringOrFallback(key+CredentialSecretSuffix, ""); secret != "" {
+			kv := strings.SplitN(secret, "/", 2)
+			ref.SecretName = strings.TrimSpace(kv[0])
+			if len(kv) == 2 {
+				ref.SecretKey = strings.TrimSpace(kv[1])
+			}
+		}
+		if ref == (CredentialRef{}) {
+			continue
+		}
+		if refs == nil {
+			refs = make(map[string]CredentialRef)
+		}
+		refs[key] = ref
+	}
+	return refs
+}
+
+// resolveCredentials reads referenced credentials into the fields of config
+func (dr *DependencyResolver) resolveCredentials(config *Config) (err error) {
+	fields := credentials(config)
+	for key, ref := range config.Credentials {
+		if ref.File != "" && (ref.SecretName != "" || ref.SecretKey != "") {
+			return fmt.Errorf("only one of %s%s and %s%s can be set", key, CredentialFileSuffix, key, CredentialSecretSuffix)
+		}
+		if ref.File == "" {
+			err = field(key+CredentialSecretSuffix+".name", ref.SecretName).isNotEmpty().matchRegexp(k8sNameRegex).err
+			if err != nil {
+				return err
+			}
+			err = field(key+CredentialSecretSuffix+".key", ref.SecretKey).isNotEmpty().matchRegexp(secretKeyRegex).err
+			if err != nil {
+				return err
+			}
+		}
+		value, err := dr.readCredential(config.K8gbNamespace, ref)
+		if err != nil && !(optionalCredentials[key] && coreerrors.Is(err, errCredentialNotFound)) {
+			return fmt.Errorf("can't read %s: %w", key, err)
+		}
+		*fields[key] = value
+	}
+	return nil
+}
+
+// readCredential reads credential from file or Secret. Surrounding whitespace, e.g. trailing newline, is trimmed
+func (dr *DependencyResolver) readCredential(namespace string, ref CredentialRef) (string, error) {
+	if ref.File != "" {
+		data, err := ioutil.ReadFile(ref.File)
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%w: file %s", errCredentialNotFound, ref.File)
+		}
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if dr.secrets == nil {
+		return "", fmt.Errorf("can't read Secret %s/%s without Kubernetes client", namespace, ref.SecretName)
+	}
+	secret := &corev1.Secret{}
+	err := dr.secrets.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: ref.SecretName}, secret)
+	if errors.IsNotFound(err) {
+		return "", fmt.Errorf("%w: Secret %s/%s", errCredentialNotFound, namespace, ref.SecretName)
+	}
+	if err != nil {
+		return "", err
+	}
+	value, found := secret.Data[ref.SecretKey]
+	if !found {
+		return "", fmt.Errorf("%w: key %s in Secret %s/%s", errCredentialNotFound, ref.SecretKey, namespace, ref.SecretName)
+	}
+	return strings.TrimSpace(string(value)), nil
+}
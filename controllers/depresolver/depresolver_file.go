@@ -0,0 +1,205 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package depresolver
+
+import (
+	"bytes"
+	encjson "encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/rs/zerolog"
+)
+
+// fileConfig is the structure of the config file referenced by ConfigFileKey, usually mounted from ConfigMap.
+// Fields missing in the file keep values of environment variables
+type fileConfig struct {
+	ReconcileRequeueSeconds  *int                 `json:"reconcileRequeueSeconds"`
+	PeerWatchIntervalSeconds *int                 `json:"peerWatchIntervalSeconds"`
+	ClusterGeoTag            *string              `json:"clusterGeoTag"`
+	ExtClustersGeoTags       []string             `json:"extClustersGeoTags"`
+	ClusterDraining          *bool                `json:"clusterDraining"`
+	ClusterCapacity          *int                 `json:"clusterCapacity"`
+	LegacyHeartbeat          *bool                `json:"legacyHeartbeat"`
+	EdgeDNSServer            *string              `json:"edgeDNSServer"`
+	EdgeDNSZone              *string              `json:"edgeDNSZone"`
+	DNSZone                  *string              `json:"dnsZone"`
+	AdditionalDNSZones       []fileDelegationZone `json:"additionalDNSZones"`
+	WatchNamespaces          []string             `json:"watchNamespaces"`
+	GslbLabelSelector        *string              `json:"gslbLabelSelector"`
+	DryRun                   *bool                `json:"dryRun"`
+	Log                      *fileLog             `json:"log"`
+}
+
+type fileDelegationZone struct {
+	DNSZone     string `json:"dnsZone"`
+	EdgeDNSZone string `json:"edgeDNSZone"`
+}
+
+type fileLog struct {
+	Level   *string `json:"level"`
+	Format  *string `json:"format"`
+	NoColor *bool   `json:"noColor"`
+}
+
+// readConfigFile overrides config by values of the yaml config file. Unknown fields are rejected, so that typos
+// don't silently fall back to environment variables
+func readConfigFile(path string, config *Config) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("can't read %s %s: %w", ConfigFileKey, path, err)
+	}
+	raw, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return fmt.Errorf("invalid %s %s: %w", ConfigFileKey, path, err)
+	}
+	file := fileConfig{}
+	decoder := encjson.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&file); err != nil {
+		return fmt.Errorf("invalid %s %s: %w", ConfigFileKey, path, err)
+	}
+	file.applyTo(config)
+	return nil
+}
+
+func (f *fileConfig) applyTo(config *Config) {
+	setInt(&config.ReconcileRequeueSeconds, f.ReconcileRequeueSeconds)
+	setInt(&config.PeerWatchIntervalSeconds, f.PeerWatchIntervalSeconds)
+	setString(&config.ClusterGeoTag, f.ClusterGeoTag)
+	if f.ExtClustersGeoTags != nil {
+		config.ExtClustersGeoTags = f.ExtClustersGeoTags
+	}
+	setBool(&config.ClusterDraining, f.ClusterDraining)
+	setInt(&config.ClusterCapacity, f.ClusterCapacity)
+	setBool(&config.LegacyHeartbeat, f.LegacyHeartbeat)
+	setString(&config.EdgeDNSServer, f.EdgeDNSServer)
+	setString(&config.EdgeDNSZone, f.EdgeDNSZone)
+	setString(&config.DNSZone, f.DNSZone)
+	if f.AdditionalDNSZones != nil {
+		config.AdditionalDNSZones = []DelegationZone{}
+		for _, zone := range f.AdditionalDNSZones {
+			config.AdditionalDNSZones = append(config.AdditionalDNSZones, DelegationZone(zone))
+		}
+	}
+	if f.WatchNamespaces != nil {
+		config.WatchNamespaces = f.WatchNamespaces
+	}
+	setString(&config.GslbLabelSelector, f.GslbLabelSelector)
+	setBool(&config.DryRun, f.DryRun)
+	if f.Log != nil {
+		if f.Log.Level != nil {
+			config.Log.Level, _ = zerolog.ParseLevel(strings.ToLower(*f.Log.Level))
+		}
+		if f.Log.Format != nil {
+			config.Log.Format = parseLogOutputFormat(strings.ToLower(*f.Log.Format))
+		}
+		setBool(&config.Log.NoColor, f.Log.NoColor)
+	}
+}
+
+func setInt(dst *int, src *int) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func setString(dst *string, src *string) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func setBool(dst *bool, src *bool) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+// ReloadOperatorConfig reads configuration again, the same way as ResolveOperatorConfig. Hot-reloadable fields of
+// valid configuration (log level, requeue interval, geo tags of external clusters, draining, capacity and
+// credentials) are applied to the live configuration and the config generation is incremented if any of them changed. Invalid configuration
+// is rejected as a whole. Names of other changed fields are returned, they are applied after restart of the operator
+func (dr *DependencyResolver) ReloadOperatorConfig() (live *Config, restart []string, err error) {
+	if _, err = dr.ResolveOperatorConfig(); err != nil {
+		return nil, nil, err
+	}
+	fresh, err := dr.readConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	dr.liveMu.Lock()
+	defer dr.liveMu.Unlock()
+	// fields which are not hot-reloadable are compared with configuration the operator was started with
+	cold := *fresh
+	copyHotReloadable(&cold, dr.config)
+	restart = changedFields(*dr.config, cold)
+	next := *dr.live
+	copyHotReloadable(&next, fresh)
+	if !reflect.DeepEqual(next, *dr.live) {
+		dr.live = &next
+		dr.generation++
+	}
+	return dr.live, restart, nil
+}
+
+// LiveConfig returns configuration with hot-reloaded fields applied. Returns nil until ResolveOperatorConfig
+// is executed
+func (dr *DependencyResolver) LiveConfig() *Config {
+	dr.liveMu.RLock()
+	defer dr.liveMu.RUnlock()
+	return dr.live
+}
+
+// ConfigGeneration is 1 for valid configuration resolved by ResolveOperatorConfig and is incremented by every
+// reload changing hot-reloadable fields. Returns 0 until valid configuration is resolved
+func (dr *DependencyResolver) ConfigGeneration() int {
+	dr.liveMu.RLock()
+	defer dr.liveMu.RUnlock()
+	return dr.generation
+}
+
+// copyHotReloadable copies fields which are applied without restart of the operator
+func copyHotReloadable(dst, src *Config) {
+	dst.Log.Level = src.Log.Level
+	dst.ReconcileRequeueSeconds = src.ReconcileRequeueSeconds
+	dst.ExtClustersGeoTags = src.ExtClustersGeoTags
+	dst.ClusterDraining = src.ClusterDraining
+	dst.ClusterCapacity = src.ClusterCapacity
+	dst.LegacyHeartbeat = src.LegacyHeartbeat
+	srcCredentials := credentials(src)
+	for key, credential := range credentials(dst) {
+		*credential = *srcCredentials[key]
+	}
+}
+
+// changedFields returns names of exported fields which differ. Unexported fields are reflected by EdgeDNSType
+func changedFields(a, b Config) (fields []string) {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := 0; i < va.NumField(); i++ {
+		if va.Type().Field(i).PkgPath != "" {
+			continue
+		}
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			fields = append(fields, va.Type().Field(i).Name)
+		}
+	}
+	return
+}
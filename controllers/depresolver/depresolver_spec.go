@@ -17,51 +17,148 @@ limitations under the License.
 package depresolver
 
 import (
-	"context"
 	"fmt"
-	"reflect"
-
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
+	"strings"
 
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
 )
 
+// maxDNSTtlSeconds is the highest TTL of Gslb records; clients must notice failover within a day at worst
+const maxDNSTtlSeconds = 86400
+
 var predefinedStrategy = k8gbv1beta1.Strategy{
 	DNSTtlSeconds:              30,
 	SplitBrainThresholdSeconds: 300,
 }
 
-// ResolveGslbSpec fills Gslb by spec values. It executes always, when gslb is initialised.
-// If spec value is not defined, it will use the default value. Function returns error if input is invalid.
-func (dr *DependencyResolver) ResolveGslbSpec(ctx context.Context, gslb *k8gbv1beta1.Gslb, client client.Client) error {
-	if client == nil {
-		return fmt.Errorf("nil client")
+// ResolveGslbSpec fills missing values of gslb spec by defaults and validates the spec. Only the gslb in memory
+// is changed; the defaults are persisted by the defaulting webhook when it is enabled, otherwise the reconciler
+// resolves them again after every write reading the Gslb back. Function returns error if input is invalid.
+func (dr *DependencyResolver) ResolveGslbSpec(gslb *k8gbv1beta1.Gslb) error {
+	DefaultGslbSpec(&gslb.Spec)
+	return validateStrategy(gslb.Spec.Strategy, specFields)
+}
+
+// DefaultGslbSpec sets predefined values of spec fields missing in the yaml
+func DefaultGslbSpec(spec *k8gbv1beta1.GslbSpec) {
+	if spec.Strategy.DNSTtlSeconds == 0 {
+		spec.Strategy.DNSTtlSeconds = predefinedStrategy.DNSTtlSeconds
 	}
-	if !reflect.DeepEqual(gslb.Spec, dr.spec) {
-		// set predefined values if missing in the yaml
-		if gslb.Spec.Strategy.DNSTtlSeconds == 0 {
-			gslb.Spec.Strategy.DNSTtlSeconds = predefinedStrategy.DNSTtlSeconds
-		}
-		if gslb.Spec.Strategy.SplitBrainThresholdSeconds == 0 {
-			gslb.Spec.Strategy.SplitBrainThresholdSeconds = predefinedStrategy.SplitBrainThresholdSeconds
+	if spec.Strategy.SplitBrainThresholdSeconds == 0 {
+		spec.Strategy.SplitBrainThresholdSeconds = predefinedStrategy.SplitBrainThresholdSeconds
+	}
+}
+
+// strategyFields names fields of strategy in validation errors
+type strategyFields struct {
+	strategyType               string
+	primaryGeoTag              string
+	dnsTtlSeconds              string
+	splitBrainThresholdSeconds string
+}
+
+var specFields = strategyFields{
+	strategyType:               "spec.strategy.type",
+	primaryGeoTag:              "spec.strategy.primaryGeoTag",
+	dnsTtlSeconds:              "spec.strategy.dnsTtlSeconds",
+	splitBrainThresholdSeconds: "spec.strategy.splitBrainThresholdSeconds",
+}
+
+var annotationFields = strategyFields{
+	strategyType:               k8gbv1beta1.StrategyAnnotation,
+	primaryGeoTag:              k8gbv1beta1.PrimaryGeoTagAnnotation,
+	dnsTtlSeconds:              k8gbv1beta1.DNSTtlSecondsAnnotation,
+	splitBrainThresholdSeconds: k8gbv1beta1.SplitBrainThresholdSecondsAnnotation,
+}
+
+// ValidateGslbSpec returns error if Gslb with spec can't be reconciled by operator with config. geoTags are geo tags
+// of all clusters known to the operator; primary geo tag of failover strategy must be one of them
+func ValidateGslbSpec(spec k8gbv1beta1.GslbSpec, config *Config, geoTags []string) (err error) {
+	err = validateStrategy(spec.Strategy, specFields)
+	if err != nil {
+		return
+	}
+	if spec.Strategy.Type == k8gbv1beta1.FailoverStrategy && !contains(geoTags, spec.Strategy.PrimaryGeoTag) {
+		return fmt.Errorf(`"spec.strategy.primaryGeoTag" "%s" is not geo tag of any known cluster %v`,
+			spec.Strategy.PrimaryGeoTag, geoTags)
+	}
+	for _, rule := range spec.Ingress.Rules {
+		err = field("spec.ingress.rules.host", rule.Host).isNotEmpty().matchRegexp(hostNameRegex).err
+		if err != nil {
+			return
 		}
-		dr.errorSpec = dr.validateSpec(gslb.Spec.Strategy)
-		if dr.errorSpec == nil {
-			dr.errorSpec = client.Update(ctx, gslb)
+		if _, found := config.ZoneOf(rule.Host); !found {
+			return fmt.Errorf("ingress host %s does not match delegated zone %s", rule.Host, config.EdgeDNSZones())
 		}
-		dr.spec = gslb.Spec
 	}
-	return dr.errorSpec
+	return
 }
 
-func (dr *DependencyResolver) validateSpec(strategy k8gbv1beta1.Strategy) (err error) {
-	err = field("DNSTtlSeconds", strategy.DNSTtlSeconds).isHigherOrEqualToZero().err
+// ResolveStrategyAnnotations returns strategy defined by k8gb annotations of Ingress. Annotations are validated by
+// the same rules as spec of Gslb; missing TTL and split brain threshold are left for defaulting
+func ResolveStrategyAnnotations(annotations map[string]string) (strategy k8gbv1beta1.Strategy, err error) {
+	strategy.Type = annotations[k8gbv1beta1.StrategyAnnotation]
+	if strategy.Type == k8gbv1beta1.FailoverStrategy {
+		strategy.PrimaryGeoTag = annotations[k8gbv1beta1.PrimaryGeoTagAnnotation]
+	}
+	strategy.DNSTtlSeconds, err = intAnnotation(annotations, k8gbv1beta1.DNSTtlSecondsAnnotation)
 	if err != nil {
 		return
 	}
-	err = field("SplitBrainThresholdSeconds", strategy.SplitBrainThresholdSeconds).isHigherOrEqualToZero().err
+	strategy.SplitBrainThresholdSeconds, err = intAnnotation(annotations, k8gbv1beta1.SplitBrainThresholdSecondsAnnotation)
 	if err != nil {
 		return
 	}
+	err = validateStrategy(strategy, annotationFields)
 	return
 }
+
+func validateStrategy(strategy k8gbv1beta1.Strategy, fields strategyFields) (err error) {
+	switch strategy.Type {
+	case k8gbv1beta1.RoundRobinStrategy, k8gbv1beta1.FailoverStrategy:
+	default:
+		return fmt.Errorf(`"%s" must be one of [%s %s], got "%s"`,
+			fields.strategyType, k8gbv1beta1.RoundRobinStrategy, k8gbv1beta1.FailoverStrategy, strategy.Type)
+	}
+	if strategy.Type == k8gbv1beta1.FailoverStrategy {
+		err = field(fields.primaryGeoTag, strategy.PrimaryGeoTag).isNotEmpty().matchRegexp(geoTagRegex).err
+		if err != nil {
+			return
+		}
+	}
+	err = field(fields.dnsTtlSeconds, strategy.DNSTtlSeconds).isHigherOrEqualToZero().isLessOrEqualTo(maxDNSTtlSeconds).err
+	if err != nil {
+		return
+	}
+	err = field(fields.splitBrainThresholdSeconds, strategy.SplitBrainThresholdSeconds).isHigherOrEqualToZero().err
+	return
+}
+
+// intAnnotation returns integer value of annotation with key or 0 if the annotation is missing
+func intAnnotation(annotations map[string]string, key string) (int, error) {
+	value, found := annotations[key]
+	if !found {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf(`"%s" must be integer, got "%s"`, key, value)
+	}
+	return i, nil
+}
+
+// HostInZone returns true if host is zone or its subdomain
+func HostInZone(host, zone string) bool {
+	host, zone = strings.TrimSuffix(host, "."), strings.TrimSuffix(zone, ".")
+	return strings.EqualFold(host, zone) || strings.HasSuffix(strings.ToLower(host), "."+strings.ToLower(zone))
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -29,6 +29,9 @@ import (
 	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -38,7 +41,7 @@ import (
 )
 
 var predefinedConfig = Config{
-	ReconcileRequeueSeconds: 30,
+	ReconcileRequeueSeconds: 300,
 	ClusterGeoTag:           "us",
 	ExtClustersGeoTags:      []string{"uk", "eu"},
 	EdgeDNSType:             DNSTypeInfoblox,
@@ -46,6 +49,7 @@ var predefinedConfig = Config{
 	EdgeDNSZone:             "8.8.8.8",
 	DNSZone:                 "example.com",
 	K8gbNamespace:           "k8gb",
+	WatchNamespaces:         []string{},
 	Infoblox: Infoblox{
 		"Infoblox.host.com",
 		"0.0.3",
@@ -54,6 +58,12 @@ var predefinedConfig = Config{
 		"secret",
 		21,
 		11,
+		"default",
+		"",
+		map[string]string{},
+	},
+	PeerAuth: PeerAuth{
+		TSIGAlgorithm: "hmac-sha256",
 	},
 	Override: Override{
 		false,
@@ -66,10 +76,10 @@ var predefinedConfig = Config{
 
 func TestResolveSpecWithFilledFields(t *testing.T) {
 	// arrange
-	cl, gslb := getTestContext("./testdata/filled_omitempty.yaml")
+	_, gslb := getTestContext("./testdata/filled_omitempty.yaml")
 	resolver := NewDependencyResolver()
 	// act
-	err := resolver.ResolveGslbSpec(context.TODO(), gslb, cl)
+	err := resolver.ResolveGslbSpec(gslb)
 	// assert
 	assert.NoError(t, err)
 	assert.Equal(t, 35, gslb.Spec.Strategy.DNSTtlSeconds)
@@ -78,10 +88,10 @@ func TestResolveSpecWithFilledFields(t *testing.T) {
 
 func TestResolveSpecWithoutFields(t *testing.T) {
 	// arrange
-	cl, gslb := getTestContext("./testdata/free_omitempty.yaml")
+	_, gslb := getTestContext("./testdata/free_omitempty.yaml")
 	resolver := NewDependencyResolver()
 	// act
-	err := resolver.ResolveGslbSpec(context.TODO(), gslb, cl)
+	err := resolver.ResolveGslbSpec(gslb)
 	// assert
 	assert.NoError(t, err)
 	assert.Equal(t, predefinedStrategy.DNSTtlSeconds, gslb.Spec.Strategy.DNSTtlSeconds)
@@ -90,10 +100,10 @@ func TestResolveSpecWithoutFields(t *testing.T) {
 
 func TestResolveSpecWithZeroSplitBrain(t *testing.T) {
 	// arrange
-	cl, gslb := getTestContext("./testdata/filled_omitempty_with_zero_splitbrain.yaml")
+	_, gslb := getTestContext("./testdata/filled_omitempty_with_zero_splitbrain.yaml")
 	resolver := NewDependencyResolver()
 	// act
-	err := resolver.ResolveGslbSpec(context.TODO(), gslb, cl)
+	err := resolver.ResolveGslbSpec(gslb)
 	// assert
 	assert.NoError(t, err)
 	assert.Equal(t, 35, gslb.Spec.Strategy.DNSTtlSeconds)
@@ -102,10 +112,10 @@ func TestResolveSpecWithZeroSplitBrain(t *testing.T) {
 
 func TestResolveSpecWithEmptyFields(t *testing.T) {
 	// arrange
-	cl, gslb := getTestContext("./testdata/invalid_omitempty_empty.yaml")
+	_, gslb := getTestContext("./testdata/invalid_omitempty_empty.yaml")
 	resolver := NewDependencyResolver()
 	// act
-	err := resolver.ResolveGslbSpec(context.TODO(), gslb, cl)
+	err := resolver.ResolveGslbSpec(gslb)
 	// assert
 	assert.NoError(t, err)
 	assert.Equal(t, predefinedStrategy.DNSTtlSeconds, gslb.Spec.Strategy.DNSTtlSeconds)
@@ -114,39 +124,161 @@ func TestResolveSpecWithEmptyFields(t *testing.T) {
 
 func TestResolveSpecWithNegativeFields(t *testing.T) {
 	// arrange
-	cl, gslb := getTestContext("./testdata/invalid_omitempty_negative.yaml")
+	_, gslb := getTestContext("./testdata/invalid_omitempty_negative.yaml")
 	resolver := NewDependencyResolver()
 	// act
-	err := resolver.ResolveGslbSpec(context.TODO(), gslb, cl)
+	err := resolver.ResolveGslbSpec(gslb)
 	// assert
 	assert.Error(t, err)
 }
 
+func TestResolveSpecWithTooHighTTL(t *testing.T) {
+	// arrange
+	_, gslb := getTestContext("./testdata/filled_omitempty.yaml")
+	gslb.Spec.Strategy.DNSTtlSeconds = maxDNSTtlSeconds + 1
+	resolver := NewDependencyResolver()
+	// act
+	err := resolver.ResolveGslbSpec(gslb)
+	// assert
+	assert.EqualError(t, err, `"spec.strategy.dnsTtlSeconds" is higher than 86400`)
+}
+
 func TestSpecRunWhenChanged(t *testing.T) {
 	// arrange
-	cl, gslb := getTestContext("./testdata/filled_omitempty.yaml")
-	ctx := context.Background()
+	_, gslb := getTestContext("./testdata/filled_omitempty.yaml")
 	resolver := NewDependencyResolver()
 	// act
-	err1 := resolver.ResolveGslbSpec(ctx, gslb, cl)
+	err1 := resolver.ResolveGslbSpec(gslb)
 	gslb.Spec.Strategy.SplitBrainThresholdSeconds = 0
-	err2 := resolver.ResolveGslbSpec(ctx, gslb, cl)
+	err2 := resolver.ResolveGslbSpec(gslb)
 	// assert
 	assert.NoError(t, err1)
-	// err2 would not be empty
 	assert.NoError(t, err2)
 	assert.Equal(t, predefinedStrategy.SplitBrainThresholdSeconds, gslb.Spec.Strategy.SplitBrainThresholdSeconds)
 	assert.Equal(t, 35, gslb.Spec.Strategy.DNSTtlSeconds)
 }
 
-func TestResolveSpecWithNilClient(t *testing.T) {
+func TestResolveSpecOfSeveralGslbs(t *testing.T) {
 	// arrange
-	_, gslb := getTestContext("./testdata/filled_omitempty.yaml")
+	_, filled := getTestContext("./testdata/filled_omitempty.yaml")
+	_, negative := getTestContext("./testdata/invalid_omitempty_negative.yaml")
+	_, free := getTestContext("./testdata/free_omitempty.yaml")
 	resolver := NewDependencyResolver()
 	// act
-	err := resolver.ResolveGslbSpec(context.TODO(), gslb, nil)
+	err1 := resolver.ResolveGslbSpec(filled)
+	err2 := resolver.ResolveGslbSpec(negative)
+	err3 := resolver.ResolveGslbSpec(free)
 	// assert
-	assert.Error(t, err)
+	assert.NoError(t, err1)
+	assert.Error(t, err2)
+	assert.NoError(t, err3)
+	assert.Equal(t, 35, filled.Spec.Strategy.DNSTtlSeconds)
+	assert.Equal(t, predefinedStrategy.DNSTtlSeconds, free.Spec.Strategy.DNSTtlSeconds)
+}
+
+func TestResolveSpecDoesNotUpdateGslb(t *testing.T) {
+	// arrange
+	cl, gslb := getTestContext("./testdata/free_omitempty.yaml")
+	resolver := NewDependencyResolver()
+	// act
+	err := resolver.ResolveGslbSpec(gslb)
+	// assert
+	require.NoError(t, err)
+	stored := &k8gbv1beta1.Gslb{}
+	require.NoError(t, cl.Get(context.TODO(), client.ObjectKey{Namespace: gslb.Namespace, Name: gslb.Name}, stored))
+	assert.Equal(t, 0, stored.Spec.Strategy.DNSTtlSeconds)
+	assert.Equal(t, 0, stored.Spec.Strategy.SplitBrainThresholdSeconds)
+}
+
+func TestDefaultGslbSpec(t *testing.T) {
+	// arrange
+	_, gslb := getTestContext("./testdata/filled_omitempty_with_zero_splitbrain.yaml")
+	// act
+	DefaultGslbSpec(&gslb.Spec)
+	// assert
+	assert.Equal(t, 35, gslb.Spec.Strategy.DNSTtlSeconds)
+	assert.Equal(t, predefinedStrategy.SplitBrainThresholdSeconds, gslb.Spec.Strategy.SplitBrainThresholdSeconds)
+}
+
+func TestValidateGslbSpec(t *testing.T) {
+	// arrange
+	_, gslb := getTestContext("./testdata/filled_omitempty.yaml")
+	config := &Config{EdgeDNSZone: "example.com"}
+	gslb.Spec.Strategy.Type = k8gbv1beta1.FailoverStrategy
+	gslb.Spec.Strategy.PrimaryGeoTag = "eu"
+	// act
+	err := ValidateGslbSpec(gslb.Spec, config, []string{"us", "eu"})
+	// assert
+	assert.NoError(t, err)
+}
+
+func TestValidateGslbSpecWithInvalidValues(t *testing.T) {
+	// arrange
+	config := &Config{EdgeDNSZone: "example.com"}
+	for name, invalidate := range map[string]func(*k8gbv1beta1.GslbSpec){
+		"unknown strategy":         func(s *k8gbv1beta1.GslbSpec) { s.Strategy.Type = "weighted" },
+		"failover without primary": func(s *k8gbv1beta1.GslbSpec) { s.Strategy.Type = k8gbv1beta1.FailoverStrategy },
+		"unknown primary geo tag": func(s *k8gbv1beta1.GslbSpec) {
+			s.Strategy.Type, s.Strategy.PrimaryGeoTag = k8gbv1beta1.FailoverStrategy, "za"
+		},
+		"negative TTL":              func(s *k8gbv1beta1.GslbSpec) { s.Strategy.DNSTtlSeconds = -1 },
+		"too high TTL":              func(s *k8gbv1beta1.GslbSpec) { s.Strategy.DNSTtlSeconds = maxDNSTtlSeconds + 1 },
+		"negative split brain":      func(s *k8gbv1beta1.GslbSpec) { s.Strategy.SplitBrainThresholdSeconds = -1 },
+		"empty host":                func(s *k8gbv1beta1.GslbSpec) { s.Ingress.Rules[0].Host = "" },
+		"host outside of zone":      func(s *k8gbv1beta1.GslbSpec) { s.Ingress.Rules[0].Host = "roundrobin.cloud.example.org" },
+		"host containing zone name": func(s *k8gbv1beta1.GslbSpec) { s.Ingress.Rules[0].Host = "example.com.evil.org" },
+	} {
+		_, gslb := getTestContext("./testdata/filled_omitempty.yaml")
+		invalidate(&gslb.Spec)
+		// act
+		err := ValidateGslbSpec(gslb.Spec, config, []string{"us", "eu"})
+		// assert
+		assert.Error(t, err, name)
+	}
+}
+
+func TestResolveStrategyAnnotations(t *testing.T) {
+	// arrange
+	annotations := map[string]string{
+		k8gbv1beta1.StrategyAnnotation:                   k8gbv1beta1.FailoverStrategy,
+		k8gbv1beta1.PrimaryGeoTagAnnotation:              "eu",
+		k8gbv1beta1.DNSTtlSecondsAnnotation:              "60",
+		k8gbv1beta1.SplitBrainThresholdSecondsAnnotation: " 600 ",
+	}
+	// act
+	strategy, err := ResolveStrategyAnnotations(annotations)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, k8gbv1beta1.Strategy{Type: k8gbv1beta1.FailoverStrategy, PrimaryGeoTag: "eu", DNSTtlSeconds: 60,
+		SplitBrainThresholdSeconds: 600}, strategy)
+}
+
+func TestResolveStrategyAnnotationsWithInvalidValues(t *testing.T) {
+	for name, annotations := range map[string]map[string]string{
+		"unknown strategy":         {k8gbv1beta1.StrategyAnnotation: "weighted"},
+		"failover without primary": {k8gbv1beta1.StrategyAnnotation: k8gbv1beta1.FailoverStrategy},
+		"invalid primary geo tag": {k8gbv1beta1.StrategyAnnotation: k8gbv1beta1.FailoverStrategy,
+			k8gbv1beta1.PrimaryGeoTagAnnotation: "eu_west"},
+		"TTL is not integer": {k8gbv1beta1.StrategyAnnotation: k8gbv1beta1.RoundRobinStrategy,
+			k8gbv1beta1.DNSTtlSecondsAnnotation: "30s"},
+		"too high TTL": {k8gbv1beta1.StrategyAnnotation: k8gbv1beta1.RoundRobinStrategy,
+			k8gbv1beta1.DNSTtlSecondsAnnotation: "86401"},
+		"negative split brain": {k8gbv1beta1.StrategyAnnotation: k8gbv1beta1.RoundRobinStrategy,
+			k8gbv1beta1.SplitBrainThresholdSecondsAnnotation: "-1"},
+	} {
+		// act
+		_, err := ResolveStrategyAnnotations(annotations)
+		// assert
+		assert.Error(t, err, name)
+	}
+}
+
+func TestHostInZone(t *testing.T) {
+	assert.True(t, HostInZone("roundrobin.cloud.example.com", "example.com"))
+	assert.True(t, HostInZone("Roundrobin.Example.com.", "example.com"))
+	assert.True(t, HostInZone("example.com", "example.com"))
+	assert.False(t, HostInZone("roundrobin.notexample.com", "example.com"))
+	assert.False(t, HostInZone("example.com.evil.org", "example.com"))
 }
 
 func TestResolveConfigWithMultipleInvalidEnv(t *testing.T) {
@@ -163,11 +295,18 @@ func TestResolveConfigWithoutEnvVarsSet(t *testing.T) {
 	// arrange
 	defer cleanup()
 	defaultConfig := Config{}
-	defaultConfig.ReconcileRequeueSeconds = 30
+	defaultConfig.ReconcileRequeueSeconds = 300
+	defaultConfig.PeerWatchIntervalSeconds = 10
 	defaultConfig.Infoblox.HTTPRequestTimeout = 20
 	defaultConfig.Infoblox.HTTPPoolConnections = 10
+	defaultConfig.Infoblox.View = "default"
+	defaultConfig.Infoblox.ExtensibleAttributes = map[string]string{}
+	defaultConfig.Plugin.Timeout = 20
+	defaultConfig.PeerAuth.TSIGAlgorithm = "hmac-sha256"
+	defaultConfig.PeerStatus.Timeout = 2
 	defaultConfig.EdgeDNSType = DNSTypeNoEdgeDNS
 	defaultConfig.ExtClustersGeoTags = []string{}
+	defaultConfig.WatchNamespaces = []string{}
 	defaultConfig.Log.Level = zerolog.InfoLevel
 	defaultConfig.Log.Format = JSONFormat
 	defaultConfig.Log.NoColor = true
@@ -371,6 +510,220 @@ func TestResolveConfigWithEmptyNS1(t *testing.T) {
 	assert.Equal(t, false, config.ns1Enabled)
 }
 
+func TestResolveConfigWithDryRunEnabled(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.DryRun = true
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigWithoutDryRun(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.DryRun = false
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError, DryRunKey)
+}
+
+func TestResolveConfigWithWebhookEnabled(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.WebhookEnabled = true
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigWithoutWebhook(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.WebhookEnabled = false
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError, WebhookEnabledKey)
+}
+
+func TestResolveConfigWithWatchNamespaces(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.WatchNamespaces = []string{"team-a", "team-b"}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigWithInvalidWatchNamespace(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.WatchNamespaces = []string{"team-a", "Team_B"}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestResolveConfigWithRedundantWatchNamespaces(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.WatchNamespaces = []string{"team-a", "team-a"}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestResolveConfigWithGslbLabelSelector(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.GslbLabelSelector = "k8gb.io/instance=internal,tier in (gold,silver)"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigWithInvalidGslbLabelSelector(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.GslbLabelSelector = "k8gb.io/instance in (internal"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestConfigIsManaged(t *testing.T) {
+	var tests = []struct {
+		name       string
+		namespaces []string
+		selector   string
+		namespace  string
+		labels     map[string]string
+		expected   bool
+	}{
+		{name: "no scope", namespace: "team-a", expected: true},
+		{name: "watched namespace", namespaces: []string{"team-a", "team-b"}, namespace: "team-b", expected: true},
+		{name: "not watched namespace", namespaces: []string{"team-a"}, namespace: "team-b", expected: false},
+		{name: "matching labels", selector: "k8gb.io/instance=internal", namespace: "team-a",
+			labels: map[string]string{"k8gb.io/instance": "internal"}, expected: true},
+		{name: "not matching labels", selector: "k8gb.io/instance=internal", namespace: "team-a",
+			labels: map[string]string{"k8gb.io/instance": "public"}, expected: false},
+		{name: "missing labels", selector: "k8gb.io/instance=internal", namespace: "team-a", expected: false},
+		{name: "invalid selector", selector: "k8gb.io/instance in (internal", namespace: "team-a", expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// arrange
+			config := Config{WatchNamespaces: test.namespaces, GslbLabelSelector: test.selector}
+			// act
+			managed := config.IsManaged(test.namespace, test.labels)
+			// assert
+			assert.Equal(t, test.expected, managed)
+		})
+	}
+}
+
+func TestResolveConfigWithAdditionalDNSZones(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.AdditionalDNSZones = []DelegationZone{
+		{DNSZone: "api.example.org", EdgeDNSZone: "example.org"},
+		{DNSZone: "cloud.example.net", EdgeDNSZone: "example.net"},
+	}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigWithMalformedAdditionalDNSZones(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.AdditionalDNSZones = []DelegationZone{{DNSZone: "api.example.org"}}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestResolveConfigWithRedundantAdditionalDNSZone(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.AdditionalDNSZones = []DelegationZone{{DNSZone: strings.ToUpper(predefinedConfig.DNSZone), EdgeDNSZone: "example.org"}}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestConfigZoneOf(t *testing.T) {
+	// arrange
+	config := Config{
+		DNSZone:     "cloud.example.com",
+		EdgeDNSZone: "example.com",
+		AdditionalDNSZones: []DelegationZone{
+			{DNSZone: "eu.cloud.example.com", EdgeDNSZone: "example.com"},
+			{DNSZone: "api.example.org", EdgeDNSZone: "example.org"},
+		},
+	}
+	var tests = []struct {
+		host     string
+		expected string
+		found    bool
+	}{
+		{host: "app.cloud.example.com", expected: "cloud.example.com", found: true},
+		{host: "app.eu.cloud.example.com", expected: "eu.cloud.example.com", found: true},
+		{host: "app.api.example.org", expected: "api.example.org", found: true},
+		{host: "app.example.org", expected: "api.example.org", found: true},
+		{host: "app.example.com", expected: "cloud.example.com", found: true},
+		{host: "app.example.net", found: false},
+	}
+	for _, test := range tests {
+		t.Run(test.host, func(t *testing.T) {
+			// act
+			zone, found := config.ZoneOf(test.host)
+			// assert
+			assert.Equal(t, test.found, found)
+			assert.Equal(t, test.expected, zone.DNSZone)
+		})
+	}
+}
+
+func TestConfigForZone(t *testing.T) {
+	// arrange
+	config := predefinedConfig
+	zone := DelegationZone{DNSZone: "api.example.org", EdgeDNSZone: "example.org"}
+	// act
+	zoneConfig := config.ForZone(zone)
+	// assert
+	assert.Equal(t, "api.example.org", zoneConfig.DNSZone)
+	assert.Equal(t, "example.org", zoneConfig.EdgeDNSZone)
+	assert.Equal(t, predefinedConfig.DNSZone, config.DNSZone)
+}
+
+func TestResolveConfigWithPeerWatchInterval(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.PeerWatchIntervalSeconds = 5
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestResolveConfigWithoutPeerWatchInterval(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.PeerWatchIntervalSeconds = 10
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError, PeerWatchIntervalSecondsKey)
+}
+
+func TestResolveConfigWithNegativePeerWatchInterval(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.PeerWatchIntervalSeconds = -1
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
 func TestResolveConfigWithProperCoreDNSExposed(t *testing.T) {
 	// arrange
 	defer cleanup()
@@ -684,6 +1037,261 @@ func TestRoute53IsEnabledButInfobloxIsNotConfigured(t *testing.T) {
 	arrangeVariablesAndAssert(t, expected, assert.NoError)
 }
 
+func TestCloudflareIsEnabled(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.cloudflareEnabled = true
+	expected.EdgeDNSType = DNSTypeCloudflare
+	expected.Infoblox.Host = ""
+	expected.Cloudflare.ZoneID = "023e105f4ecef8ad9ca31a8372d0c353"
+	expected.Cloudflare.APIToken = "token"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestCloudflareIsEnabledWithTokenFile(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.cloudflareEnabled = true
+	expected.EdgeDNSType = DNSTypeCloudflare | DNSTypeInfoblox
+	expected.Cloudflare.ZoneID = "023e105f4ecef8ad9ca31a8372d0c353"
+	expected.Cloudflare.APITokenFile = "/var/run/secrets/cloudflare/token"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestCloudflareIsEnabledWithAdditionalDNSZoneOfOtherEdgeZone(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.cloudflareEnabled = true
+	expected.EdgeDNSType = DNSTypeCloudflare | DNSTypeInfoblox
+	expected.Cloudflare.ZoneID = "023e105f4ecef8ad9ca31a8372d0c353"
+	expected.Cloudflare.APIToken = "token"
+	expected.AdditionalDNSZones = []DelegationZone{{DNSZone: "api.example.org", EdgeDNSZone: "example.org"}}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestCloudflareIsEnabledWithoutZoneID(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.cloudflareEnabled = true
+	expected.EdgeDNSType = DNSTypeCloudflare
+	expected.Infoblox.Host = ""
+	expected.Cloudflare.APIToken = "token"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestCloudflareIsEnabledWithoutToken(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.cloudflareEnabled = true
+	expected.EdgeDNSType = DNSTypeCloudflare
+	expected.Infoblox.Host = ""
+	expected.Cloudflare.ZoneID = "023e105f4ecef8ad9ca31a8372d0c353"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestCloudflareIsDisabledWithoutZoneID(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.cloudflareEnabled = false
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestDNSPluginIsConfigured(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EdgeDNSType = DNSTypePlugin
+	expected.Infoblox.Host = ""
+	expected.Plugin.Endpoint = "http://localhost:8090"
+	expected.Plugin.Timeout = 5
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestDNSPluginWithDefaultTimeout(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EdgeDNSType = DNSTypePlugin | DNSTypeInfoblox
+	expected.Plugin.Endpoint = "https://dns-plugin.k8gb.svc:8443/api"
+	expected.Plugin.Timeout = 20
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError, DNSPluginTimeoutKey)
+}
+
+func TestDNSPluginWithInvalidEndpoint(t *testing.T) {
+	// arrange
+	defer cleanup()
+	for _, endpoint := range []string{"localhost:8090", "ftp://localhost", "http://", "http://local host"} {
+		expected := predefinedConfig
+		expected.EdgeDNSType = DNSTypePlugin | DNSTypeInfoblox
+		expected.Plugin.Endpoint = endpoint
+		expected.Plugin.Timeout = 20
+		// act,assert
+		arrangeVariablesAndAssert(t, expected, assert.Error)
+	}
+}
+
+func TestDNSPluginWithInvalidTimeout(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EdgeDNSType = DNSTypePlugin | DNSTypeInfoblox
+	expected.Plugin.Endpoint = "http://localhost:8090"
+	expected.Plugin.Timeout = 0
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestDoTIsConfigured(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.DoT.Enabled = true
+	expected.DoT.CAFile = "/etc/k8gb/dot/ca.crt"
+	expected.DoT.ServerName = "dns.example.com"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestDoTIsDisabledByDefault(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.DoT = DoT{}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError, DoTEnabledKey, DoTCAFileKey, DoTServerNameKey)
+}
+
+func TestDoTWithInvalidServerName(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.DoT.Enabled = true
+	expected.DoT.ServerName = "dns example com"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestClusterStateIsConfigured(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.K8gbVersion = "v0.7.1"
+	expected.ClusterDraining = true
+	expected.ClusterCapacity = 100
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestLegacyHeartbeatIsConfigured(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.LegacyHeartbeat = true
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestClusterStateWithInvalidValues(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.K8gbVersion = "v0.7.1;geo=za"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+	expected.K8gbVersion = ""
+	expected.ClusterCapacity = -1
+	arrangeVariablesAndAssert(t, expected, assert.Error)
+}
+
+func TestPeerAuthIsConfigured(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.PeerAuth.TSIGKeyName = "k8gb-peers"
+	expected.PeerAuth.TSIGSecret = "c2VjcmV0LXNoYXJlZC1ieS1hbGwtY2x1c3RlcnM="
+	expected.PeerAuth.TSIGAlgorithm = "hmac-sha512"
+	expected.PeerAuth.HeartbeatSecret = "heartbeat-secret"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestPeerAuthWithDefaultTSIGAlgorithm(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.PeerAuth.TSIGKeyName = "k8gb-peers"
+	expected.PeerAuth.TSIGSecret = "c2VjcmV0"
+	expected.PeerAuth.TSIGAlgorithm = "hmac-sha256"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError, PeerTSIGAlgorithmKey)
+}
+
+func TestPeerAuthWithInvalidTSIG(t *testing.T) {
+	// arrange
+	defer cleanup()
+	for _, auth := range []PeerAuth{
+		{TSIGKeyName: "k8gb-peers", TSIGSecret: "", TSIGAlgorithm: "hmac-sha256"},
+		{TSIGKeyName: "k8gb-peers", TSIGSecret: "not base64!", TSIGAlgorithm: "hmac-sha256"},
+		{TSIGKeyName: "k8gb-peers", TSIGSecret: "c2VjcmV0", TSIGAlgorithm: "hmac-md5"},
+		{TSIGKeyName: "k8gb peers", TSIGSecret: "c2VjcmV0", TSIGAlgorithm: "hmac-sha256"},
+	} {
+		expected := predefinedConfig
+		expected.PeerAuth = auth
+		// act,assert
+		arrangeVariablesAndAssert(t, expected, assert.Error)
+	}
+}
+
+func TestPeerStatusIsConfigured(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.PeerStatus = PeerStatus{Address: ":8443", CertFile: "/etc/k8gb/peer-status/tls.crt",
+		KeyFile: "/etc/k8gb/peer-status/tls.key", CAFile: "/etc/k8gb/peer-status/ca.crt", Token: "token", Timeout: 5}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestPeerStatusWithDefaultTimeout(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.PeerStatus = PeerStatus{Token: "token", Timeout: 2}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError, PeerStatusTimeoutKey)
+}
+
+func TestPeerStatusWithInvalidValues(t *testing.T) {
+	// arrange
+	defer cleanup()
+	for _, status := range []PeerStatus{
+		{Address: "8443", CertFile: "tls.crt", KeyFile: "tls.key", Token: "token", Timeout: 2},
+		{Address: ":8443", KeyFile: "tls.key", Token: "token", Timeout: 2},
+		{Address: ":8443", CertFile: "tls.crt", Token: "token", Timeout: 2},
+		{Address: ":8443", CertFile: "tls.crt", KeyFile: "tls.key", Timeout: 2},
+		{Token: "token", Timeout: 0},
+	} {
+		expected := predefinedConfig
+		expected.PeerStatus = status
+		// act,assert
+		arrangeVariablesAndAssert(t, expected, assert.Error)
+	}
+}
+
 func TestInfobloxGridHostIsEmpty(t *testing.T) {
 	// arrange
 	defer cleanup()
@@ -1018,6 +1626,72 @@ func TestNegativeInfobloxHTTPRequestTimeout(t *testing.T) {
 
 }
 
+func TestValidInfobloxViewAndTenantID(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.Infoblox.View = "internal view"
+	expected.Infoblox.TenantID = "k8gb-tenant"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestUnsetInfobloxView(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.Infoblox.View = "default"
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError, InfobloxViewKey)
+}
+
+func TestEmptyInfobloxViewIsIgnoredWithoutInfoblox(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.EdgeDNSType = DNSTypeNoEdgeDNS
+	expected.Infoblox.Host = ""
+	expected.Infoblox.View = " "
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestValidInfobloxExtensibleAttributes(t *testing.T) {
+	// arrange
+	defer cleanup()
+	expected := predefinedConfig
+	expected.Infoblox.ExtensibleAttributes = map[string]string{"owner": "team-a", "Cost Centre": "1234"}
+	// act,assert
+	arrangeVariablesAndAssert(t, expected, assert.NoError)
+}
+
+func TestInfobloxExtensibleAttributesWithSpaces(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	_ = os.Setenv(InfobloxExtensibleAttrsKey, " owner = team a , Cost Centre=1234 ")
+	resolver := NewDependencyResolver()
+	// act
+	config, err := resolver.ResolveOperatorConfig()
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "team a", "Cost Centre": "1234"}, config.Infoblox.ExtensibleAttributes)
+}
+
+func TestInvalidInfobloxExtensibleAttributes(t *testing.T) {
+	// arrange
+	defer cleanup()
+	for _, ea := range []string{"owner", "owner=", "=team-a", "owner=team-a,,", "owner=team-a,cost-centre"} {
+		configureEnvVar(predefinedConfig)
+		_ = os.Setenv(InfobloxExtensibleAttrsKey, ea)
+		resolver := NewDependencyResolver()
+		// act
+		_, err := resolver.ResolveOperatorConfig()
+		// assert
+		assert.Error(t, err, ea)
+	}
+}
+
 func TestResolveConfigEnableFakeDNSAsTrue(t *testing.T) {
 	// arrange
 	defer cleanup()
@@ -1242,6 +1916,298 @@ func TestResolveLoggerEmptyValues(t *testing.T) {
 	assert.Equal(t, JSONFormat, config.Log.Format)
 }
 
+func TestResolveConfigFromConfigFile(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	_ = os.Setenv(ConfigFileKey, writeConfigFile(t, `
+reconcileRequeueSeconds: 60
+extClustersGeoTags: [za]
+additionalDNSZones:
+  - dnsZone: api.example.org
+    edgeDNSZone: example.org
+log:
+  level: warn
+  format: simple
+`))
+	expected := predefinedConfig
+	expected.ReconcileRequeueSeconds = 60
+	expected.ExtClustersGeoTags = []string{"za"}
+	expected.AdditionalDNSZones = []DelegationZone{{DNSZone: "api.example.org", EdgeDNSZone: "example.org"}}
+	expected.Log.Level = zerolog.WarnLevel
+	expected.Log.Format = SimpleFormat
+	expected.ConfigFile = os.Getenv(ConfigFileKey)
+	resolver := NewDependencyResolver()
+	// act
+	config, err := resolver.ResolveOperatorConfig()
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, expected, *config)
+	assert.Equal(t, 1, resolver.ConfigGeneration())
+}
+
+func TestResolveConfigFromEmptyConfigFile(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	_ = os.Setenv(ConfigFileKey, writeConfigFile(t, ""))
+	expected := predefinedConfig
+	expected.ConfigFile = os.Getenv(ConfigFileKey)
+	resolver := NewDependencyResolver()
+	// act
+	config, err := resolver.ResolveOperatorConfig()
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, expected, *config)
+}
+
+func TestResolveConfigFromInvalidConfigFile(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown field":       "reconcileRequeSeconds: 60",
+		"invalid type":        "reconcileRequeueSeconds: often",
+		"invalid yaml":        "extClustersGeoTags: [za",
+		"failing validation":  "reconcileRequeueSeconds: 0",
+		"invalid log level":   "log: {level: loud}",
+		"duplicate namespace": "watchNamespaces: [team-a, team-a]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			defer cleanup()
+			configureEnvVar(predefinedConfig)
+			_ = os.Setenv(ConfigFileKey, writeConfigFile(t, content))
+			resolver := NewDependencyResolver()
+			// act
+			_, err := resolver.ResolveOperatorConfig()
+			// assert
+			assert.Error(t, err)
+			assert.Equal(t, 0, resolver.ConfigGeneration())
+		})
+	}
+}
+
+func TestResolveConfigWithMissingConfigFile(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	_ = os.Setenv(ConfigFileKey, "/nonexistent/k8gb.yaml")
+	resolver := NewDependencyResolver()
+	// act
+	_, err := resolver.ResolveOperatorConfig()
+	// assert
+	assert.Error(t, err)
+}
+
+func TestReloadConfigAppliesHotReloadableFields(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	path := writeConfigFile(t, "extClustersGeoTags: [uk, eu]")
+	_ = os.Setenv(ConfigFileKey, path)
+	resolver := NewDependencyResolver()
+	config, err := resolver.ResolveOperatorConfig()
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+extClustersGeoTags: [uk, eu, za]
+reconcileRequeueSeconds: 30
+clusterDraining: true
+legacyHeartbeat: true
+dnsZone: cloud.example.org
+log: {level: trace}
+`), 0600))
+	expected := predefinedConfig
+	expected.ConfigFile = path
+	expected.ExtClustersGeoTags = []string{"uk", "eu", "za"}
+	expected.ReconcileRequeueSeconds = 30
+	expected.ClusterDraining = true
+	expected.LegacyHeartbeat = true
+	expected.Log.Level = zerolog.TraceLevel
+	// act
+	live, restart, err := resolver.ReloadOperatorConfig()
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, expected, *live)
+	assert.Equal(t, live, resolver.LiveConfig())
+	assert.Equal(t, []string{"DNSZone"}, restart)
+	assert.Equal(t, 2, resolver.ConfigGeneration())
+	assert.Equal(t, 300, config.ReconcileRequeueSeconds, "configuration the operator was started with must not change")
+}
+
+func TestReloadConfigWithoutChanges(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	_ = os.Setenv(ConfigFileKey, writeConfigFile(t, "reconcileRequeueSeconds: 300"))
+	resolver := NewDependencyResolver()
+	config, err := resolver.ResolveOperatorConfig()
+	require.NoError(t, err)
+	// act
+	live, restart, err := resolver.ReloadOperatorConfig()
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, config, live)
+	assert.Empty(t, restart)
+	assert.Equal(t, 1, resolver.ConfigGeneration())
+}
+
+func TestReloadConfigRejectsInvalidConfig(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	path := writeConfigFile(t, "reconcileRequeueSeconds: 300")
+	_ = os.Setenv(ConfigFileKey, path)
+	resolver := NewDependencyResolver()
+	config, err := resolver.ResolveOperatorConfig()
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, []byte("extClustersGeoTags: [uk, us]\nreconcileRequeueSeconds: -1"), 0600))
+	// act
+	live, _, err := resolver.ReloadOperatorConfig()
+	// assert
+	assert.Error(t, err)
+	assert.Nil(t, live)
+	assert.Equal(t, config, resolver.LiveConfig())
+	assert.Equal(t, 1, resolver.ConfigGeneration())
+}
+
+func TestResolveCredentialFromFile(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	path := writeConfigFile(t, "rotated\n")
+	_ = os.Setenv(InfobloxPasswordKey+CredentialFileSuffix, path)
+	expected := predefinedConfig
+	expected.Infoblox.Password = "rotated"
+	expected.Credentials = map[string]CredentialRef{InfobloxPasswordKey: {File: path}}
+	resolver := NewDependencyResolver()
+	// act
+	config, err := resolver.ResolveOperatorConfig()
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, expected, *config)
+}
+
+func TestResolveCredentialsFromSecret(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	_ = os.Setenv(InfobloxPasswordKey+CredentialSecretSuffix, "infoblox/password")
+	_ = os.Setenv(HeartbeatHMACSecretKey+CredentialSecretSuffix, "k8gb-peer-auth/hmac")
+	resolver := NewDependencyResolver().WithSecretReader(fake.NewFakeClientWithScheme(scheme.Scheme,
+		credentialSecret("infoblox", "password", "from-secret"), credentialSecret("k8gb-peer-auth", "hmac", "s3cret")))
+	// act
+	config, err := resolver.ResolveOperatorConfig()
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, "from-secret", config.Infoblox.Password)
+	assert.Equal(t, predefinedConfig.Infoblox.Username, config.Infoblox.Username)
+	assert.Equal(t, "s3cret", config.PeerAuth.HeartbeatSecret)
+	assert.Equal(t, map[string]CredentialRef{
+		InfobloxPasswordKey:    {SecretName: "infoblox", SecretKey: "password"},
+		HeartbeatHMACSecretKey: {SecretName: "k8gb-peer-auth", SecretKey: "hmac"},
+	}, config.Credentials)
+}
+
+func TestResolveCredentialsWithInvalidReferences(t *testing.T) {
+	reader := fake.NewFakeClientWithScheme(scheme.Scheme, credentialSecret("infoblox", "password", "from-secret"))
+	for name, env := range map[string]map[string]string{
+		"missing key":          {InfobloxPasswordKey + CredentialSecretSuffix: "infoblox"},
+		"invalid name":         {InfobloxPasswordKey + CredentialSecretSuffix: "Infoblox/password"},
+		"invalid key":          {InfobloxPasswordKey + CredentialSecretSuffix: "infoblox/pass word"},
+		"missing secret":       {InfobloxPasswordKey + CredentialSecretSuffix: "cloudflare/password"},
+		"missing key in data":  {InfobloxPasswordKey + CredentialSecretSuffix: "infoblox/username"},
+		"missing file":         {InfobloxPasswordKey + CredentialFileSuffix: "/nonexistent/password"},
+		"both file and secret": {InfobloxPasswordKey + CredentialSecretSuffix: "infoblox/password", InfobloxPasswordKey + CredentialFileSuffix: "/etc/password"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			defer cleanup()
+			configureEnvVar(predefinedConfig)
+			for k, v := range env {
+				_ = os.Setenv(k, v)
+			}
+			resolver := NewDependencyResolver().WithSecretReader(reader)
+			// act
+			_, err := resolver.ResolveOperatorConfig()
+			// assert
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestResolveOptionalCredentialFromMissingSecret(t *testing.T) {
+	for name, env := range map[string]map[string]string{
+		"missing secret":      {HeartbeatHMACSecretKey + CredentialSecretSuffix: "k8gb-peer-auth/HEARTBEAT_HMAC_SECRET"},
+		"missing key in data": {HeartbeatHMACSecretKey + CredentialSecretSuffix: "infoblox/HEARTBEAT_HMAC_SECRET"},
+		"missing file":        {HeartbeatHMACSecretKey + CredentialFileSuffix: "/nonexistent/hmac"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			defer cleanup()
+			configureEnvVar(predefinedConfig)
+			for k, v := range env {
+				_ = os.Setenv(k, v)
+			}
+			resolver := NewDependencyResolver().WithSecretReader(fake.NewFakeClientWithScheme(scheme.Scheme,
+				credentialSecret("infoblox", "password", "from-secret")))
+			// act
+			config, err := resolver.ResolveOperatorConfig()
+			// assert
+			assert.NoError(t, err)
+			assert.Empty(t, config.PeerAuth.HeartbeatSecret)
+		})
+	}
+}
+
+func TestReloadConfigAppliesOptionalCredentialCreatedLater(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	_ = os.Setenv(HeartbeatHMACSecretKey+CredentialSecretSuffix, "k8gb-peer-auth/HEARTBEAT_HMAC_SECRET")
+	reader := fake.NewFakeClientWithScheme(scheme.Scheme)
+	resolver := NewDependencyResolver().WithSecretReader(reader)
+	_, err := resolver.ResolveOperatorConfig()
+	require.NoError(t, err)
+	require.NoError(t, reader.Create(context.TODO(), credentialSecret("k8gb-peer-auth", "HEARTBEAT_HMAC_SECRET", "s3cret")))
+	// act
+	live, _, err := resolver.ReloadOperatorConfig()
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", live.PeerAuth.HeartbeatSecret)
+}
+
+func TestResolveCredentialFromSecretWithoutSecretReader(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	_ = os.Setenv(InfobloxPasswordKey+CredentialSecretSuffix, "infoblox/password")
+	resolver := NewDependencyResolver()
+	// act
+	_, err := resolver.ResolveOperatorConfig()
+	// assert
+	assert.Error(t, err)
+}
+
+func TestReloadConfigRotatesCredentials(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	_ = os.Setenv(InfobloxPasswordKey+CredentialSecretSuffix, "infoblox/password")
+	secret := credentialSecret("infoblox", "password", "from-secret")
+	reader := fake.NewFakeClientWithScheme(scheme.Scheme, secret)
+	resolver := NewDependencyResolver().WithSecretReader(reader)
+	config, err := resolver.ResolveOperatorConfig()
+	require.NoError(t, err)
+	secret.Data["password"] = []byte("rotated")
+	require.NoError(t, reader.Update(context.TODO(), secret))
+	// act
+	live, restart, err := resolver.ReloadOperatorConfig()
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, "rotated", live.Infoblox.Password)
+	assert.Empty(t, restart)
+	assert.Equal(t, 2, resolver.ConfigGeneration())
+	assert.Equal(t, "from-secret", config.Infoblox.Password)
+}
+
 // arrangeVariablesAndAssert sets string environment variables and asserts `expected` argument with
 // ResolveOperatorConfig() output. The last parameter unsets the values
 func arrangeVariablesAndAssert(t *testing.T, expected Config,
@@ -1265,24 +2231,45 @@ func cleanup() {
 	for _, s := range []string{ReconcileRequeueSecondsKey, ClusterGeoTagKey, ExtClustersGeoTagsKey, EdgeDNSZoneKey, DNSZoneKey, EdgeDNSServerKey,
 		Route53EnabledKey, NS1EnabledKey, InfobloxGridHostKey, InfobloxVersionKey, InfobloxPortKey, InfobloxUsernameKey, InfobloxPasswordKey,
 		OverrideWithFakeDNSKey, OverrideFakeInfobloxKey, K8gbNamespaceKey, CoreDNSExposedKey, InfobloxHTTPRequestTimeoutKey,
-		InfobloxHTTPPoolConnectionsKey, LogLevelKey, LogFormatKey, LogNoColorKey} {
+		InfobloxHTTPPoolConnectionsKey, InfobloxViewKey, InfobloxTenantIDKey, InfobloxExtensibleAttrsKey, LogLevelKey, LogFormatKey,
+		LogNoColorKey, DryRunKey, CloudflareEnabledKey, CloudflareZoneIDKey, CloudflareAPITokenKey, CloudflareAPITokenFileKey, CloudflareDelegationTTLKey,
+		DNSPluginEndpointKey, DNSPluginTimeoutKey, DoTEnabledKey, DoTCAFileKey, DoTServerNameKey,
+		PeerTSIGKeyNameKey, PeerTSIGSecretKey, PeerTSIGAlgorithmKey, HeartbeatHMACSecretKey, K8gbVersionKey, ClusterDrainingKey,
+		ClusterCapacityKey, LegacyHeartbeatKey, PeerStatusAddressKey, PeerStatusCertFileKey, PeerStatusKeyFileKey, PeerStatusCAFileKey, PeerStatusTokenKey,
+		PeerStatusTimeoutKey, WebhookEnabledKey, PeerWatchIntervalSecondsKey, WatchNamespacesKey, GslbLabelSelectorKey, AdditionalDNSZonesKey,
+		ConfigFileKey} {
 		if os.Unsetenv(s) != nil {
 			panic(fmt.Errorf("cleanup %s", s))
 		}
 	}
+	for key := range credentials(&Config{}) {
+		_ = os.Unsetenv(key + CredentialFileSuffix)
+		_ = os.Unsetenv(key + CredentialSecretSuffix)
+	}
 }
 
 func configureEnvVar(config Config) {
 	_ = os.Setenv(ReconcileRequeueSecondsKey, strconv.Itoa(config.ReconcileRequeueSeconds))
+	_ = os.Setenv(PeerWatchIntervalSecondsKey, strconv.Itoa(config.PeerWatchIntervalSeconds))
 	_ = os.Setenv(ClusterGeoTagKey, config.ClusterGeoTag)
 	_ = os.Setenv(ExtClustersGeoTagsKey, strings.Join(config.ExtClustersGeoTags, ","))
 	_ = os.Setenv(EdgeDNSServerKey, config.EdgeDNSServer)
 	_ = os.Setenv(EdgeDNSZoneKey, config.EdgeDNSZone)
 	_ = os.Setenv(DNSZoneKey, config.DNSZone)
+	var zones []string
+	for _, zone := range config.AdditionalDNSZones {
+		zones = append(zones, fmt.Sprintf("%s:%s", zone.DNSZone, zone.EdgeDNSZone))
+	}
+	_ = os.Setenv(AdditionalDNSZonesKey, strings.Join(zones, ","))
 	_ = os.Setenv(K8gbNamespaceKey, config.K8gbNamespace)
+	_ = os.Setenv(WatchNamespacesKey, strings.Join(config.WatchNamespaces, ","))
+	_ = os.Setenv(GslbLabelSelectorKey, config.GslbLabelSelector)
 	_ = os.Setenv(Route53EnabledKey, strconv.FormatBool(config.route53Enabled))
 	_ = os.Setenv(NS1EnabledKey, strconv.FormatBool(config.ns1Enabled))
+	_ = os.Setenv(CloudflareEnabledKey, strconv.FormatBool(config.cloudflareEnabled))
 	_ = os.Setenv(CoreDNSExposedKey, strconv.FormatBool(config.CoreDNSExposed))
+	_ = os.Setenv(DryRunKey, strconv.FormatBool(config.DryRun))
+	_ = os.Setenv(WebhookEnabledKey, strconv.FormatBool(config.WebhookEnabled))
 	_ = os.Setenv(InfobloxGridHostKey, config.Infoblox.Host)
 	_ = os.Setenv(InfobloxVersionKey, config.Infoblox.Version)
 	_ = os.Setenv(InfobloxPortKey, strconv.Itoa(config.Infoblox.Port))
@@ -1290,6 +2277,35 @@ func configureEnvVar(config Config) {
 	_ = os.Setenv(InfobloxPasswordKey, config.Infoblox.Password)
 	_ = os.Setenv(InfobloxHTTPRequestTimeoutKey, strconv.Itoa(config.Infoblox.HTTPRequestTimeout))
 	_ = os.Setenv(InfobloxHTTPPoolConnectionsKey, strconv.Itoa(config.Infoblox.HTTPPoolConnections))
+	_ = os.Setenv(InfobloxViewKey, config.Infoblox.View)
+	_ = os.Setenv(InfobloxTenantIDKey, config.Infoblox.TenantID)
+	var attributes []string
+	for name, value := range config.Infoblox.ExtensibleAttributes {
+		attributes = append(attributes, fmt.Sprintf("%s=%s", name, value))
+	}
+	_ = os.Setenv(InfobloxExtensibleAttrsKey, strings.Join(attributes, ","))
+	_ = os.Setenv(CloudflareZoneIDKey, config.Cloudflare.ZoneID)
+	_ = os.Setenv(CloudflareAPITokenKey, config.Cloudflare.APIToken)
+	_ = os.Setenv(CloudflareAPITokenFileKey, config.Cloudflare.APITokenFile)
+	_ = os.Setenv(DNSPluginEndpointKey, config.Plugin.Endpoint)
+	_ = os.Setenv(DNSPluginTimeoutKey, strconv.Itoa(config.Plugin.Timeout))
+	_ = os.Setenv(DoTEnabledKey, strconv.FormatBool(config.DoT.Enabled))
+	_ = os.Setenv(DoTCAFileKey, config.DoT.CAFile)
+	_ = os.Setenv(DoTServerNameKey, config.DoT.ServerName)
+	_ = os.Setenv(PeerTSIGKeyNameKey, config.PeerAuth.TSIGKeyName)
+	_ = os.Setenv(PeerTSIGSecretKey, config.PeerAuth.TSIGSecret)
+	_ = os.Setenv(PeerTSIGAlgorithmKey, config.PeerAuth.TSIGAlgorithm)
+	_ = os.Setenv(HeartbeatHMACSecretKey, config.PeerAuth.HeartbeatSecret)
+	_ = os.Setenv(PeerStatusAddressKey, config.PeerStatus.Address)
+	_ = os.Setenv(PeerStatusCertFileKey, config.PeerStatus.CertFile)
+	_ = os.Setenv(PeerStatusKeyFileKey, config.PeerStatus.KeyFile)
+	_ = os.Setenv(PeerStatusCAFileKey, config.PeerStatus.CAFile)
+	_ = os.Setenv(PeerStatusTokenKey, config.PeerStatus.Token)
+	_ = os.Setenv(PeerStatusTimeoutKey, strconv.Itoa(config.PeerStatus.Timeout))
+	_ = os.Setenv(K8gbVersionKey, config.K8gbVersion)
+	_ = os.Setenv(ClusterDrainingKey, strconv.FormatBool(config.ClusterDraining))
+	_ = os.Setenv(ClusterCapacityKey, strconv.Itoa(config.ClusterCapacity))
+	_ = os.Setenv(LegacyHeartbeatKey, strconv.FormatBool(config.LegacyHeartbeat))
 	_ = os.Setenv(OverrideWithFakeDNSKey, strconv.FormatBool(config.Override.FakeDNSEnabled))
 	_ = os.Setenv(OverrideFakeInfobloxKey, strconv.FormatBool(config.Override.FakeInfobloxEnabled))
 	_ = os.Setenv(LogLevelKey, config.Log.Level.String())
@@ -1298,6 +2314,25 @@ func configureEnvVar(config Config) {
 
 }
 
+// credentialSecret returns Secret in k8gb namespace of predefinedConfig holding single credential
+func credentialSecret(name, key, value string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: predefinedConfig.K8gbNamespace, Name: name},
+		Data:       map[string][]byte{key: []byte(value)},
+	}
+}
+
+// writeConfigFile writes content into temporary config file and returns its path
+func writeConfigFile(t *testing.T, content string) string {
+	file, err := ioutil.TempFile("", "k8gb-config-*.yaml")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Remove(file.Name()) })
+	_, err = file.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	return file.Name()
+}
+
 func getTestContext(testData string) (client.Client, *k8gbv1beta1.Gslb) {
 	// Create a fake client to mock API calls.
 	var gslbYaml, err = ioutil.ReadFile(testData)
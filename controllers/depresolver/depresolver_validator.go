@@ -33,6 +33,18 @@ const (
 	versionNumberRegex = "^(v){0,1}(0|(?:[1-9]\\d*))(?:\\.(0|(?:[1-9]\\d*))(?:\\.(0|(?:[1-9]\\d*)))?(?:\\-([\\w][\\w\\.\\-_]*))?)?$"
 	// k8sNamespaceRegex matches valid kubernetes namespace
 	k8sNamespaceRegex = "^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	// k8sNameRegex matches valid name of kubernetes object; e.g. Secret name
+	k8sNameRegex = "^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
+	// secretKeyRegex matches valid key of Secret data
+	secretKeyRegex = "^[-._a-zA-Z0-9]+$"
+	// pluginEndpointRegex matches http(s) URL of the plugin; e.g. http://localhost:8090
+	pluginEndpointRegex = "^https?://[^\\s/?#]+(/[^\\s?#]*)?$"
+	// tsigAlgorithmRegex matches HMAC algorithms supported by TSIG; e.g. hmac-sha256
+	tsigAlgorithmRegex = "^hmac-sha(1|224|256|384|512)\\.?$"
+	// base64Regex matches standard base64 encoded value
+	base64Regex = "^[A-Za-z0-9+/]+={0,2}$"
+	// listenAddressRegex matches optional host followed by port; e.g. :8443 or 0.0.0.0:8443
+	listenAddressRegex = "^[a-zA-Z0-9.\\-]*:[0-9]{1,5}$"
 )
 
 // validator wrapper against field to be verified
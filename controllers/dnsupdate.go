@@ -19,9 +19,9 @@ package controllers
 import (
 	"fmt"
 	"sort"
-	"strings"
 
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	externaldns "sigs.k8s.io/external-dns/endpoint"
@@ -39,19 +39,20 @@ func (r *GslbReconciler) gslbDNSEndpoint(gslb *k8gbv1beta1.Gslb) (*externaldns.D
 
 	serviceHealth, err := r.getServiceHealthStatus(gslb)
 	if err != nil {
-		return nil, err
+		return nil, withReason(k8gbv1beta1.ReasonServiceHealthFailed, err)
 	}
 
 	localTargets, err := r.DNSProvider.GslbIngressExposedIPs(gslb)
 	if err != nil {
-		return nil, err
+		return nil, withReason(k8gbv1beta1.ReasonIngressIPUnresolved, err)
 	}
 
 	for host, health := range serviceHealth {
 		var finalTargets []string
 
-		if !strings.Contains(host, r.Config.EdgeDNSZone) {
-			return nil, fmt.Errorf("ingress host %s does not match delegated zone %s", host, r.Config.EdgeDNSZone)
+		if _, found := r.Config.ZoneOf(host); !found {
+			return nil, withReason(k8gbv1beta1.ReasonZoneMismatch,
+				fmt.Errorf("ingress host %s does not match delegated zone %s", host, r.Config.EdgeDNSZones()))
 		}
 
 		if health == "Healthy" {
@@ -71,19 +72,25 @@ func (r *GslbReconciler) gslbDNSEndpoint(gslb *k8gbv1beta1.Gslb) (*externaldns.D
 
 		sortTargets(externalTargets)
 
+		primary := gslb.Spec.Strategy.PrimaryGeoTag
+		eventType, reason := corev1.EventTypeNormal, eventReasonPrimaryActive
+		message := fmt.Sprintf("Host %s is served by primary cluster %s", host, primary)
 		if len(externalTargets) > 0 {
 			switch gslb.Spec.Strategy.Type {
 			case roundRobinStrategy:
 				finalTargets = append(finalTargets, externalTargets...)
 			case failoverStrategy:
 				// If cluster is Primary
-				if gslb.Spec.Strategy.PrimaryGeoTag == r.Config.ClusterGeoTag {
+				if primary == r.Config.ClusterGeoTag {
 					// If cluster is Primary and Healthy return only own targets
 					// If cluster is Primary and Unhealthy return Secondary external targets
 					if health != "Healthy" {
 						finalTargets = externalTargets
 						log.Info(fmt.Sprintf("Executing failover strategy for %s Gslb on Primary. Workload on primary %s cluster is unhealthy, targets are %v",
-							gslb.Name, gslb.Spec.Strategy.PrimaryGeoTag, finalTargets))
+							gslb.Name, primary, finalTargets))
+						eventType, reason = corev1.EventTypeWarning, eventReasonFailoverActive
+						message = fmt.Sprintf("Workload on primary cluster %s is unhealthy, host %s is served by targets %v of secondary clusters",
+							primary, host, finalTargets)
 					}
 				} else {
 					// If cluster is Secondary and Primary external cluster is Healthy
@@ -91,12 +98,26 @@ func (r *GslbReconciler) gslbDNSEndpoint(gslb *k8gbv1beta1.Gslb) (*externaldns.D
 					// Return own targets by default.
 					finalTargets = externalTargets
 					log.Info(fmt.Sprintf("Executing failover strategy for %s Gslb on Secondary. Workload on primary %s cluster is healthy, targets are %v",
-						gslb.Name, gslb.Spec.Strategy.PrimaryGeoTag, finalTargets))
+						gslb.Name, primary, finalTargets))
 				}
 			}
 		} else {
 			log.Info(fmt.Sprintf("No external targets have been found for host %s", host))
+			if gslb.Spec.Strategy.Type == failoverStrategy && primary != r.Config.ClusterGeoTag {
+				eventType, reason = corev1.EventTypeWarning, eventReasonFailoverActive
+				message = fmt.Sprintf("Primary cluster %s has no targets for host %s, host is served by local targets %v",
+					primary, host, finalTargets)
+			}
+		}
+		if gslb.Spec.Strategy.Type == roundRobinStrategy {
+			eventType, reason = corev1.EventTypeNormal, eventReasonRoundRobin
+			message = fmt.Sprintf("Host %s is served by targets %v", host, finalTargets)
+		}
+		if len(finalTargets) == 0 {
+			eventType, reason = corev1.EventTypeWarning, eventReasonNoTargets
+			message = fmt.Sprintf("Host %s has no healthy targets in any cluster", host)
 		}
+		r.event(gslb, host, eventType, reason, message)
 
 		log.Info(fmt.Sprintf("Final target list for %s Gslb: %v", gslb.Name, finalTargets))
 
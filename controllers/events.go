@@ -0,0 +1,84 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Reasons of events recorded on Gslb
+const (
+	eventReasonPrimaryActive   = "PrimaryActive"
+	eventReasonFailoverActive  = "FailoverActive"
+	eventReasonRoundRobin      = "RoundRobin"
+	eventReasonNoTargets       = "NoTargets"
+	eventReasonIngressCreated  = "IngressCreated"
+	eventReasonIngressUpdated  = "IngressUpdated"
+	eventReasonIngressConflict = "IngressConflict"
+	eventReasonIngressFailed   = "IngressFailed"
+	eventReasonFinalizerAdded  = "FinalizerAdded"
+	eventReasonFinalized       = "Finalized"
+	eventReasonFinalizeFailed  = "FinalizeFailed"
+)
+
+// Reasons of events recorded on Ingress with k8gb annotations
+const (
+	eventReasonInvalidAnnotations = "InvalidAnnotations"
+)
+
+// ingressEventSubject is subject of events about Ingress of Gslb
+const ingressEventSubject = "ingress"
+
+// annotationsEventSubject is subject of events about k8gb annotations of Ingress
+const annotationsEventSubject = "annotations"
+
+// EventRecorder returns the recorder deduplicating events of Gslbs. The DNS provider records events through the same
+// recorder, so the events of both are forgotten when Gslb is finalized. Nil is returned, recording nothing, until
+// Recorder is set
+func (r *GslbReconciler) EventRecorder() *utils.EventRecorder {
+	if r.Recorder == nil {
+		return nil
+	}
+	r.eventsOnce.Do(func() {
+		r.events = utils.NewEventRecorder(r.Recorder)
+	})
+	return r.events
+}
+
+// event records event of gslb unless it repeats the last event about the same subject
+func (r *GslbReconciler) event(gslb *k8gbv1beta1.Gslb, subject, eventType, reason, message string) {
+	r.EventRecorder().Event(gslb, subject, eventType, reason, message)
+}
+
+// forgetEvents drops the last events of deleted Gslb
+func (r *GslbReconciler) forgetEvents(name types.NamespacedName) {
+	r.EventRecorder().Forget(&k8gbv1beta1.Gslb{ObjectMeta: metav1.ObjectMeta{Namespace: name.Namespace, Name: name.Name}})
+}
+
+// ingressEvents returns the recorder deduplicating events of Ingresses
+func (r *IngressReconciler) ingressEvents() *utils.EventRecorder {
+	if r.Recorder == nil {
+		return nil
+	}
+	r.eventsOnce.Do(func() {
+		r.events = utils.NewEventRecorder(r.Recorder)
+	})
+	return r.events
+}
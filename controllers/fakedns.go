@@ -23,6 +23,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
 	"github.com/miekg/dns"
 )
 
@@ -38,6 +39,8 @@ var records = map[string][]string{
 	"localtargets-roundrobin.cloud.example.com.": {"10.1.0.3", "10.1.0.2", "10.1.0.1"},
 	"test-gslb-heartbeat-eu.example.com.":        {oldEdgeTimestamp("10m")},
 	"test-gslb-heartbeat-za.example.com.":        {oldEdgeTimestamp("3m")},
+	// heartbeat of eu cluster replayed under the name of us cluster
+	"test-gslb-heartbeat-us.example.com.": {strconv.Quote(utils.Heartbeat{Timestamp: time.Now().UTC(), GeoTag: "eu"}.String())},
 }
 
 func parseQuery(m *dns.Msg) {
@@ -18,36 +18,121 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
 )
 
+// finalizeGslb removes DNS artifacts of gslb. DNSEndpoint and heartbeat of gslb are always removed, while zone
+// delegation shared by Gslbs of the zone is removed only when the last of them is finalized
 func (r *GslbReconciler) finalizeGslb(gslb *k8gbv1beta1.Gslb) (err error) {
-	// needs to do before the CR can be deleted. Examples
-	// of finalizers include performing backups and deleting
-	// resources that are not owned by this CR, like a PVC.
-	err = r.DNSProvider.Finalize(gslb)
+	others, err := r.remainingGslbs(gslb)
 	if err != nil {
-		log.Error(err, "Can't finalize GSLB (%s)")
 		return
 	}
-	log.Info("Successfully finalized Gslb")
+	err = r.removeDNSEndpoint(gslb)
+	if err != nil {
+		log.Error(err, "Can't remove DNSEndpoint of Gslb")
+		return
+	}
+	err = r.DNSProvider.Finalize(gslb, others)
+	if err != nil {
+		log.Error(err, fmt.Sprintf("Can't finalize GSLB (%s)", gslb.Name))
+		r.event(gslb, gslbFinalizer, corev1.EventTypeWarning, eventReasonFinalizeFailed,
+			fmt.Sprintf("Can't remove Gslb from %s edge DNS: %s", r.DNSProvider, err))
+		return
+	}
+	log.Info(fmt.Sprintf("Successfully finalized Gslb, %d other Gslbs remain", len(others)))
+	r.event(gslb, gslbFinalizer, corev1.EventTypeNormal, eventReasonFinalized,
+		fmt.Sprintf("Removed Gslb from %s edge DNS", r.DNSProvider))
 	return
 }
 
+// remainingGslbs lists Gslbs other than gslb which keep zone delegation of this instance: Gslbs in scope of the
+// instance and Gslbs out of it, e.g. managed by another k8gb instance, with a host in a delegation zone of the
+// instance. Gslbs out of the scope are read by APIReader, the cache of the manager holds only watched namespaces.
+// Gslbs being deleted don't keep zone delegation, so that concurrently deleted Gslbs don't leave it behind
+func (r *GslbReconciler) remainingGslbs(gslb *k8gbv1beta1.Gslb) (others []k8gbv1beta1.Gslb, err error) {
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	gslbList := &k8gbv1beta1.GslbList{}
+	if err = reader.List(context.TODO(), gslbList); err != nil {
+		return nil, err
+	}
+	for i := range gslbList.Items {
+		g := &gslbList.Items[i]
+		if g.GetDeletionTimestamp() != nil || (g.Namespace == gslb.Namespace && g.Name == gslb.Name) {
+			continue
+		}
+		if r.Config.IsManaged(g.Namespace, g.Labels) || inDelegationZones(r.Config, g) {
+			others = append(others, *g)
+		}
+	}
+	return others, nil
+}
+
+// inDelegationZones returns true when a host of gslb belongs to DNSZone or to one of AdditionalDNSZones of config
+func inDelegationZones(config *depresolver.Config, gslb *k8gbv1beta1.Gslb) bool {
+	for _, rule := range gslb.Spec.Ingress.Rules {
+		for _, zone := range config.DelegationZones() {
+			if depresolver.HostInZone(rule.Host, zone.DNSZone) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// removeDNSEndpoint removes DNSEndpoint of gslb right away, so that its hosts are not served until it is garbage collected
+func (r *GslbReconciler) removeDNSEndpoint(gslb *k8gbv1beta1.Gslb) error {
+	dnsEndpoint := &externaldns.DNSEndpoint{ObjectMeta: metav1.ObjectMeta{Namespace: gslb.Namespace, Name: gslb.Name}}
+	err := r.Delete(context.TODO(), dnsEndpoint)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
 func (r *GslbReconciler) addFinalizer(gslb *k8gbv1beta1.Gslb) error {
 	log.Info("Adding Finalizer for the Gslb")
+	patch := finalizerPatch(gslb)
 	gslb.SetFinalizers(append(gslb.GetFinalizers(), gslbFinalizer))
 
-	// Update CR
-	err := r.Update(context.TODO(), gslb)
+	// Patch CR, so that spec defaults resolved in memory are not written
+	err := r.patchGslb(gslb, patch)
 	if err != nil {
 		log.Error(err, "Failed to update Gslb with finalizer")
 		return err
 	}
+	r.event(gslb, gslbFinalizer, corev1.EventTypeNormal, eventReasonFinalizerAdded,
+		fmt.Sprintf("Added finalizer %s", gslbFinalizer))
+	return nil
+}
+
+// patchGslb writes patch of gslb. Gslb is read back from the server without the spec defaults resolved in memory
+// (unless the defaulting webhook persisted them), so the defaults are resolved again
+func (r *GslbReconciler) patchGslb(gslb *k8gbv1beta1.Gslb, patch client.Patch) error {
+	err := r.Patch(context.TODO(), gslb, patch)
+	if err != nil {
+		return err
+	}
+	depresolver.DefaultGslbSpec(&gslb.Spec)
 	return nil
 }
 
+// finalizerPatch patches finalizers of gslb changed after the call
+func finalizerPatch(gslb *k8gbv1beta1.Gslb) client.Patch {
+	return client.MergeFrom(gslb.DeepCopy())
+}
+
 func contains(list []string, s string) bool {
 	for _, v := range list {
 		if v == s {
@@ -0,0 +1,243 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/providers/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+const route53EndpointName = "k8gb-ns-route53"
+
+func TestZoneDelegationIsRemovedWithLastGslb(t *testing.T) {
+	var tests = []struct {
+		name  string
+		order []string
+	}{
+		{name: "sample Gslb first", order: []string{"test-gslb", "test-gslb-2"}},
+		{name: "sample Gslb last", order: []string{"test-gslb-2", "test-gslb"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// arrange
+			defer cleanup()
+			settings := provideRoute53Settings(t, "test-gslb-2")
+			first, last := test.order[0], test.order[1]
+			// act
+			finalizeTestGslb(t, settings, first)
+			// assert
+			assertDNSEndpointExists(t, settings, predefinedConfig.K8gbNamespace, route53EndpointName, true)
+			assertDNSEndpointExists(t, settings, settings.gslb.Namespace, first, false)
+			assertDNSEndpointExists(t, settings, settings.gslb.Namespace, last, true)
+			// act
+			finalizeTestGslb(t, settings, last)
+			// assert
+			assertDNSEndpointExists(t, settings, predefinedConfig.K8gbNamespace, route53EndpointName, false)
+			assertDNSEndpointExists(t, settings, settings.gslb.Namespace, last, false)
+		})
+	}
+}
+
+func TestZoneDelegationIsRemovedWithConcurrentlyDeletedGslbs(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideRoute53Settings(t, "test-gslb-2")
+	markTestGslbDeleted(t, settings, "test-gslb-2")
+	// act
+	finalizeTestGslb(t, settings, "test-gslb")
+	// assert
+	assertDNSEndpointExists(t, settings, predefinedConfig.K8gbNamespace, route53EndpointName, false)
+	assertDNSEndpointExists(t, settings, settings.gslb.Namespace, "test-gslb", false)
+}
+
+func TestZoneDelegationIsKeptForGslbOfAnotherInstanceInZone(t *testing.T) {
+	var tests = []struct {
+		name     string
+		host     string
+		expected bool
+	}{
+		{name: "host in zone", host: "app.cloud.example.com", expected: true},
+		{name: "host out of zone", host: "app.internal.example.com", expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// arrange
+			defer cleanup()
+			settings := provideRoute53Settings(t)
+			settings.reconciler.Config.GslbLabelSelector = "k8gb.io/instance!=internal"
+			gslb := settings.gslb.DeepCopy()
+			gslb.ObjectMeta = metav1.ObjectMeta{Namespace: "team-a", Name: "internal-gslb",
+				Labels: map[string]string{"k8gb.io/instance": "internal"}}
+			gslb.Spec.Ingress.Rules = gslb.Spec.Ingress.Rules[:1]
+			gslb.Spec.Ingress.Rules[0].Host = test.host
+			require.NoError(t, settings.client.Create(context.TODO(), gslb))
+			// act
+			finalizeTestGslb(t, settings, settings.gslb.Name)
+			// assert
+			assertDNSEndpointExists(t, settings, predefinedConfig.K8gbNamespace, route53EndpointName, test.expected)
+		})
+	}
+}
+
+func TestGslbWithSpecInvalidByStricterValidationIsFinalized(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	gslb := &k8gbv1beta1.Gslb{}
+	require.NoError(t, settings.client.Get(context.TODO(), settings.request.NamespacedName, gslb))
+	gslb.Spec.Strategy.DNSTtlSeconds = 100000
+	require.NoError(t, settings.client.Update(context.TODO(), gslb))
+	// act
+	finalizeTestGslb(t, settings, settings.gslb.Name)
+	// assert
+	assertDNSEndpointExists(t, settings, settings.gslb.Namespace, settings.gslb.Name, false)
+}
+
+func TestDNSEndpointOfGslbOfSameNameInK8gbNamespaceIsKept(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	config := *settings.reconciler.Config
+	config.EdgeDNSType = depresolver.DNSTypeNoEdgeDNS
+	f, err := dns.NewDNSProviderFactory(settings.client, config, settings.reconciler.Log, nil)
+	require.NoError(t, err)
+	settings.reconciler.DNSProvider = f.Provider()
+	require.Equal(t, "EMPTY", fmt.Sprint(settings.reconciler.DNSProvider))
+	other := &externaldns.DNSEndpoint{ObjectMeta: metav1.ObjectMeta{Namespace: predefinedConfig.K8gbNamespace, Name: settings.gslb.Name}}
+	require.NoError(t, settings.client.Create(context.TODO(), other))
+	// act
+	finalizeTestGslb(t, settings, settings.gslb.Name)
+	// assert
+	assertDNSEndpointExists(t, settings, settings.gslb.Namespace, settings.gslb.Name, false)
+	assertDNSEndpointExists(t, settings, predefinedConfig.K8gbNamespace, settings.gslb.Name, true)
+}
+
+func TestGslbRelabeledOutOfScopeIsFinalized(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	assertDNSEndpointExists(t, settings, settings.gslb.Namespace, settings.gslb.Name, true)
+	settings.reconciler.Config.GslbLabelSelector = "k8gb.io/instance=internal"
+	// act
+	reconcileTestGslb(t, settings, settings.gslb.Name)
+	// assert
+	gslb := &k8gbv1beta1.Gslb{}
+	require.NoError(t, settings.client.Get(context.TODO(), settings.request.NamespacedName, gslb))
+	assert.NotContains(t, gslb.Finalizers, gslbFinalizer)
+	assertDNSEndpointExists(t, settings, settings.gslb.Namespace, settings.gslb.Name, false)
+}
+
+func TestManagedPredicatePassesUpdateOutOfScope(t *testing.T) {
+	// arrange
+	internal := &metav1.ObjectMeta{Namespace: "team-a", Labels: map[string]string{"k8gb.io/instance": "internal"}}
+	public := &metav1.ObjectMeta{Namespace: "team-a"}
+	predicate := managedPredicate(&depresolver.Config{GslbLabelSelector: "k8gb.io/instance=internal"})
+	// act
+	// assert
+	assert.True(t, predicate.Update(event.UpdateEvent{MetaOld: internal, MetaNew: public}), "relabeled out of scope")
+	assert.True(t, predicate.Update(event.UpdateEvent{MetaOld: public, MetaNew: internal}), "relabeled into scope")
+	assert.False(t, predicate.Update(event.UpdateEvent{MetaOld: public, MetaNew: public}))
+	assert.True(t, predicate.Create(event.CreateEvent{Meta: internal}))
+	assert.False(t, predicate.Create(event.CreateEvent{Meta: public}))
+	assert.False(t, predicate.Delete(event.DeleteEvent{Meta: public}))
+}
+
+func TestStatusUpdatePredicateFiltersOutStatusOnlyUpdates(t *testing.T) {
+	// arrange
+	old := &metav1.ObjectMeta{Namespace: "team-a", Generation: 1, ResourceVersion: "1"}
+	status := old.DeepCopy()
+	status.ResourceVersion = "2"
+	spec := status.DeepCopy()
+	spec.Generation = 2
+	relabeled := status.DeepCopy()
+	relabeled.Labels = map[string]string{"k8gb.io/instance": "internal"}
+	deleted := status.DeepCopy()
+	deletionTimestamp := metav1.Now()
+	deleted.DeletionTimestamp = &deletionTimestamp
+	predicate := statusUpdatePredicate()
+	// act
+	// assert
+	assert.False(t, predicate.Update(event.UpdateEvent{MetaOld: old, MetaNew: status}), "status only")
+	assert.True(t, predicate.Update(event.UpdateEvent{MetaOld: old, MetaNew: spec}), "spec")
+	assert.True(t, predicate.Update(event.UpdateEvent{MetaOld: old, MetaNew: relabeled}), "labels")
+	assert.True(t, predicate.Update(event.UpdateEvent{MetaOld: old, MetaNew: deleted}), "deletion")
+}
+
+// provideRoute53Settings provides settings of Route53 provider delegating zone to sample Gslb and to Gslbs of names
+func provideRoute53Settings(t *testing.T, names ...string) testSettings {
+	settings := provideSettings(t, predefinedConfig)
+	config := *settings.reconciler.Config
+	config.EdgeDNSType = depresolver.DNSTypeRoute53
+	settings.reconciler.Config = &config
+	f, err := dns.NewDNSProviderFactory(settings.client, config, settings.reconciler.Log, nil)
+	require.NoError(t, err)
+	settings.reconciler.DNSProvider = f.Provider()
+	reconcileTestGslb(t, settings, settings.gslb.Name)
+	for _, name := range names {
+		gslb := settings.gslb.DeepCopy()
+		gslb.ObjectMeta = metav1.ObjectMeta{Namespace: settings.gslb.Namespace, Name: name}
+		require.NoError(t, settings.client.Create(context.TODO(), gslb))
+		reconcileTestGslb(t, settings, name)
+	}
+	assertDNSEndpointExists(t, settings, predefinedConfig.K8gbNamespace, route53EndpointName, true)
+	return settings
+}
+
+// finalizeTestGslb marks Gslb deleted and reconciles it
+func finalizeTestGslb(t *testing.T, settings testSettings, name string) {
+	markTestGslbDeleted(t, settings, name)
+	reconcileTestGslb(t, settings, name)
+	gslb := &k8gbv1beta1.Gslb{}
+	require.NoError(t, settings.client.Get(context.TODO(), client.ObjectKey{Namespace: settings.gslb.Namespace, Name: name}, gslb))
+	require.NotContains(t, gslb.Finalizers, gslbFinalizer)
+}
+
+func markTestGslbDeleted(t *testing.T, settings testSettings, name string) {
+	gslb := &k8gbv1beta1.Gslb{}
+	require.NoError(t, settings.client.Get(context.TODO(), client.ObjectKey{Namespace: settings.gslb.Namespace, Name: name}, gslb))
+	deletionTimestamp := metav1.Now()
+	gslb.SetDeletionTimestamp(&deletionTimestamp)
+	require.NoError(t, settings.client.Update(context.TODO(), gslb))
+}
+
+func reconcileTestGslb(t *testing.T, settings testSettings, name string) {
+	_, err := settings.reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: settings.gslb.Namespace, Name: name}})
+	require.NoError(t, err)
+}
+
+func assertDNSEndpointExists(t *testing.T, settings testSettings, namespace, name string, exists bool) {
+	err := settings.client.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: name}, &externaldns.DNSEndpoint{})
+	if exists {
+		assert.NoError(t, err, "DNSEndpoint %s/%s must exist", namespace, name)
+		return
+	}
+	assert.True(t, errors.IsNotFound(err), "DNSEndpoint %s/%s must be removed", namespace, name)
+}
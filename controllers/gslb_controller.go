@@ -19,8 +19,12 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sync"
+	"time"
 
 	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
+	"github.com/AbsaOSS/k8gb/controllers/providers/assistant"
 	"github.com/AbsaOSS/k8gb/controllers/providers/dns"
 
 	"github.com/AbsaOSS/k8gb/controllers/providers/metrics"
@@ -33,10 +37,14 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	types "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 	externaldns "sigs.k8s.io/external-dns/endpoint"
@@ -49,30 +57,45 @@ var log = logf.Log.WithName("controller_gslb")
 // GslbReconciler reconciles a Gslb object
 type GslbReconciler struct {
 	client.Client
+	// APIReader reads Gslbs of all instances, see remainingGslbs. Client is used when APIReader is nil
+	APIReader   client.Reader
 	Log         logr.Logger
 	Scheme      *runtime.Scheme
 	Config      *depresolver.Config
 	DepResolver *depresolver.DependencyResolver
 	Metrics     *metrics.PrometheusMetrics
 	DNSProvider dns.IDnsProvider
+	// Recorder records events of Gslb decisions. Events are not recorded when Recorder is nil
+	Recorder   record.EventRecorder
+	events     *utils.EventRecorder
+	eventsOnce sync.Once
+	// peerState is shared by DNS providers recreated on configuration reload
+	peerState     *assistant.PeerState
+	peerStateOnce sync.Once
+	// reloadedConfig is configuration of the DNS provider recreated by the last reload
+	reloadedConfig *depresolver.Config
 }
 
 const (
 	gslbFinalizer           = "finalizer.k8gb.absa.oss"
-	roundRobinStrategy      = "roundRobin"
-	failoverStrategy        = "failover"
-	primaryGeoTagAnnotation = "k8gb.io/primary-geotag"
-	strategyAnnotation      = "k8gb.io/strategy"
+	roundRobinStrategy      = k8gbv1beta1.RoundRobinStrategy
+	failoverStrategy        = k8gbv1beta1.FailoverStrategy
+	primaryGeoTagAnnotation = k8gbv1beta1.PrimaryGeoTagAnnotation
+	strategyAnnotation      = k8gbv1beta1.StrategyAnnotation
+	// heartbeatRefreshesPerThreshold is how many times heartbeat is refreshed within split brain threshold
+	heartbeatRefreshesPerThreshold = 3
 )
 
 // +kubebuilder:rbac:groups=k8gb.absa.oss,resources=gslbs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=k8gb.absa.oss,resources=gslbs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=k8gb.absa.oss,resources=clusterpeers,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile runs main reconiliation loop
 func (r *GslbReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
 	log := r.Log.WithValues("gslb", req.NamespacedName)
-	result := utils.NewReconcileResultHandler(r.Config.ReconcileRequeueSeconds, log)
+	result := utils.NewReconcileResultHandler(liveConfig(r.DepResolver, r.Config).ReconcileRequeueSeconds, log)
 	// Fetch the Gslb instance
 	gslb := &k8gbv1beta1.Gslb{}
 	err := r.Get(ctx, req.NamespacedName, gslb)
@@ -81,21 +104,27 @@ func (r *GslbReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			r.forgetEvents(req.NamespacedName)
 			return result.Stop()
 		}
 		return result.RequeueError(fmt.Errorf("error reading the object (%s)", err))
 	}
-
-	err = r.DepResolver.ResolveGslbSpec(ctx, gslb, r.Client)
-	if err != nil {
-		return result.RequeueError(fmt.Errorf("resolving spec (%s)", err))
+	managed := r.Config.IsManaged(gslb.Namespace, gslb.Labels)
+	if !managed && !contains(gslb.GetFinalizers(), gslbFinalizer) {
+		// Gslb is managed by another k8gb instance
+		return result.Stop()
 	}
+
 	// == Finalizer business ==
 
 	// Check if the Gslb instance is marked to be deleted, which is
 	// indicated by the deletion timestamp being set.
+	// Gslb relabeled out of the scope of the operator is finalized like deleted one, so that its DNS records and
+	// the finalizer don't outlive the scope. Spec is only defaulted, not validated, so that Gslb which became
+	// invalid by stricter validation of newer version is still finalized
 	isGslbMarkedToBeDeleted := gslb.GetDeletionTimestamp() != nil
-	if isGslbMarkedToBeDeleted {
+	if isGslbMarkedToBeDeleted || !managed {
+		depresolver.DefaultGslbSpec(&gslb.Spec)
 		if contains(gslb.GetFinalizers(), gslbFinalizer) {
 			// Run finalization logic for gslbFinalizer. If the
 			// finalization logic fails, don't remove the finalizer so
@@ -106,15 +135,22 @@ func (r *GslbReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 			// Remove gslbFinalizer. Once all finalizers have been
 			// removed, the object will be deleted.
+			patch := finalizerPatch(gslb)
 			gslb.SetFinalizers(remove(gslb.GetFinalizers(), gslbFinalizer))
-			err := r.Update(ctx, gslb)
+			err := r.patchGslb(gslb, patch)
 			if err != nil {
 				return result.RequeueError(err)
 			}
+			r.forgetEvents(req.NamespacedName)
 		}
 		return result.Stop()
 	}
 
+	err = r.DepResolver.ResolveGslbSpec(gslb)
+	if err != nil {
+		return result.RequeueError(r.failed(gslb, "", k8gbv1beta1.ReasonInvalidSpec, fmt.Errorf("resolving spec (%s)", err)))
+	}
+
 	// Add finalizer for this CR
 	if !contains(gslb.GetFinalizers(), gslbFinalizer) {
 		if err := r.addFinalizer(gslb); err != nil {
@@ -123,44 +159,56 @@ func (r *GslbReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	}
 
 	// == Ingress ==========
-	ingress, err := r.gslbIngress(gslb)
-	if err != nil {
-		return result.RequeueError(err)
-	}
+	// Ingress of Gslb created out of Ingress annotations is the source of the Gslb, so it is never written
+	if !isIngressDriven(gslb) {
+		ingress, err := r.gslbIngress(gslb)
+		if err != nil {
+			return result.RequeueError(r.failed(gslb, "", k8gbv1beta1.ReasonIngressFailed, err))
+		}
 
-	err = r.saveIngress(gslb, ingress)
-	if err != nil {
-		return result.RequeueError(err)
+		err = r.saveIngress(gslb, ingress)
+		if err != nil {
+			return result.RequeueError(r.failed(gslb, "", k8gbv1beta1.ReasonIngressFailed, err))
+		}
 	}
 
 	// == external-dns dnsendpoints CRs ==
 	dnsEndpoint, err := r.gslbDNSEndpoint(gslb)
 	if err != nil {
-		return result.RequeueError(err)
+		return result.RequeueError(r.failed(gslb, k8gbv1beta1.ConditionDNSEndpointSynced, k8gbv1beta1.ReasonDNSEndpointFailed, err))
 	}
 
 	err = r.DNSProvider.SaveDNSEndpoint(gslb, dnsEndpoint)
 	if err != nil {
-		return result.RequeueError(err)
+		return result.RequeueError(r.failed(gslb, k8gbv1beta1.ConditionDNSEndpointSynced, k8gbv1beta1.ReasonDNSEndpointFailed, err))
 	}
+	setCondition(gslb, k8gbv1beta1.ConditionDNSEndpointSynced, metav1.ConditionTrue, k8gbv1beta1.ReasonReconciled, "DNSEndpoint is saved")
 
 	// == handle delegated zone in Edge DNS
 	err = r.DNSProvider.CreateZoneDelegationForExternalDNS(gslb)
 	if err != nil {
-		return result.RequeueError(err)
+		return result.RequeueError(r.failed(gslb, k8gbv1beta1.ConditionDelegationSynced, k8gbv1beta1.ReasonDelegationFailed, err))
 	}
+	setCondition(gslb, k8gbv1beta1.ConditionDelegationSynced, metav1.ConditionTrue, k8gbv1beta1.ReasonReconciled,
+		fmt.Sprintf("Zone delegation is up to date in %s edge DNS", r.DNSProvider))
 
 	// == Status =
 	err = r.updateGslbStatus(gslb)
 	if err != nil {
-		return result.RequeueError(err)
+		return result.RequeueError(r.failed(gslb, "", k8gbv1beta1.ReasonStatusFailed, err))
 	}
 
 	// == Finish ==========
-	// Everything went fine, requeue after some time to catch up
-	// with external Gslb status
-	// TODO: potentially enhance with smarter reaction to external Event
-	return result.Requeue()
+	// Everything went fine, requeue after some time as a safety net; changes of external clusters are picked up by
+	// the peer watcher. Heartbeat of the cluster is refreshed only by reconciliation, so it comes well within
+	// the split brain threshold, otherwise other clusters would consider the cluster dead between reconciliations
+	return result.RequeueWithin(heartbeatRefreshInterval(gslb))
+}
+
+// heartbeatRefreshInterval is the longest interval between reconciliations of gslb keeping its heartbeat alive for
+// other clusters
+func heartbeatRefreshInterval(gslb *k8gbv1beta1.Gslb) time.Duration {
+	return time.Duration(gslb.Spec.Strategy.SplitBrainThresholdSeconds) * time.Second / heartbeatRefreshesPerThreshold
 }
 
 // SetupWithManager configures controller manager
@@ -169,116 +217,135 @@ func (r *GslbReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	endpointMapFn := handler.ToRequestsFunc(
 		func(a handler.MapObject) []reconcile.Request {
-			gslbList := &k8gbv1beta1.GslbList{}
-			opts := []client.ListOption{
-				client.InNamespace(a.Meta.GetNamespace()),
-			}
-			c := mgr.GetClient()
-			err := c.List(context.TODO(), gslbList, opts...)
-			if err != nil {
-				log.Info("Can't fetch gslb objects")
-				return nil
-			}
-			gslbName := ""
-			for _, gslb := range gslbList.Items {
-				for _, rule := range gslb.Spec.Ingress.Rules {
-					for _, path := range rule.HTTP.Paths {
-						if path.Backend.ServiceName == a.Meta.GetName() {
-							gslbName = gslb.Name
-						}
-					}
-				}
-			}
-			if len(gslbName) > 0 {
-				return []reconcile.Request{
-					{NamespacedName: types.NamespacedName{
-						Name:      gslbName,
-						Namespace: a.Meta.GetNamespace(),
-					}},
-				}
-			}
-			return nil
+			return r.endpointGslbRequests(mgr.GetClient(), a.Meta.GetNamespace(), a.Meta.GetName())
 		})
 
-	createGslbFromIngress := func(annotationKey string, annotationValue string, a handler.MapObject, strategy string) {
-		log.Info(fmt.Sprintf("Detected strategy annotation(%s:%s) on Ingress(%s)",
-			annotationKey, annotationValue, a.Meta.GetName()))
-		c := mgr.GetClient()
-		ingressToReuse := &v1beta1.Ingress{}
-		err := c.Get(context.Background(), client.ObjectKey{
-			Namespace: a.Meta.GetNamespace(),
-			Name:      a.Meta.GetName(),
-		}, ingressToReuse)
-		if err != nil {
-			log.Info(fmt.Sprintf("Ingress(%s) does not exist anymore. Skipping Glsb creation...", a.Meta.GetName()))
-			return
-		}
-		gslbExist := &k8gbv1beta1.Gslb{}
-		err = c.Get(context.Background(), client.ObjectKey{
-			Namespace: a.Meta.GetNamespace(),
-			Name:      a.Meta.GetName(),
-		}, gslbExist)
-		if err == nil {
-			log.Info(fmt.Sprintf("Gslb(%s) already exists. Skipping Gslb creation...", gslbExist.Name))
-			return
-		}
-		gslb := &k8gbv1beta1.Gslb{
-			ObjectMeta: metav1.ObjectMeta{
-				Namespace:   a.Meta.GetNamespace(),
-				Name:        a.Meta.GetName(),
-				Annotations: a.Meta.GetAnnotations(),
-			},
-			Spec: k8gbv1beta1.GslbSpec{
-				Ingress: ingressToReuse.Spec,
-				Strategy: k8gbv1beta1.Strategy{
-					Type: strategy,
-				},
-			},
-		}
-
-		if strategy == failoverStrategy {
-			for annotationKey, annotationValue := range a.Meta.GetAnnotations() {
-				if annotationKey == primaryGeoTagAnnotation {
-					gslb.Spec.Strategy.PrimaryGeoTag = annotationValue
-				}
-			}
-			if gslb.Spec.Strategy.PrimaryGeoTag == "" {
-				log.Info(fmt.Sprintf("%s annotation is missing, skipping Gslb creation...", primaryGeoTagAnnotation))
-				return
-			}
-		}
-
-		log.Info(fmt.Sprintf("Creating new Gslb(%s) out of Ingress annotation", gslb.Name))
-		err = c.Create(context.Background(), gslb)
-		if err != nil {
-			log.Error(err, "Glsb creation failed")
-		}
-	}
-	ingressMapFn := handler.ToRequestsFunc(
+	// Any change of cluster membership affects zone delegation and targets of all Gslbs
+	clusterPeerMapFn := handler.ToRequestsFunc(
 		func(a handler.MapObject) []reconcile.Request {
-			for annotationKey, annotationValue := range a.Meta.GetAnnotations() {
-				if annotationKey == strategyAnnotation {
-					switch annotationValue {
-					case roundRobinStrategy:
-						createGslbFromIngress(annotationKey, annotationKey, a, roundRobinStrategy)
-					case failoverStrategy:
-						createGslbFromIngress(annotationKey, annotationKey, a, failoverStrategy)
-					}
-				}
-			}
-			return nil
+			return r.allGslbRequests(mgr.GetClient())
 		})
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&k8gbv1beta1.Gslb{}).
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
+		For(&k8gbv1beta1.Gslb{}, builder.WithPredicates(managedPredicate(r.Config), statusUpdatePredicate())).
 		Owns(&v1beta1.Ingress{}).
 		Owns(&externaldns.DNSEndpoint{}).
 		Watches(&source.Kind{Type: &corev1.Endpoints{}},
 			&handler.EnqueueRequestsFromMapFunc{
 				ToRequests: endpointMapFn}).
-		Watches(&source.Kind{Type: &v1beta1.Ingress{}},
+		Watches(&source.Kind{Type: &k8gbv1beta1.ClusterPeer{}},
 			&handler.EnqueueRequestsFromMapFunc{
-				ToRequests: ingressMapFn}).
-		Complete(r)
+				ToRequests: clusterPeerMapFn})
 
+	// Gslbs are reconciled as soon as external clusters change, the periodic requeue is only a safety net
+	if r.Config.PeerWatchIntervalSeconds > 0 {
+		watcher := newPeerWatcher(mgr.GetClient(), r.Config, r.DNSProvider, time.Duration(r.Config.PeerWatchIntervalSeconds)*time.Second)
+		if err := mgr.Add(watcher); err != nil {
+			return err
+		}
+		controllerBuilder = controllerBuilder.Watches(&source.Channel{Source: watcher.events}, &handler.EnqueueRequestForObject{})
+	}
+	return controllerBuilder.Complete(r)
+
+}
+
+// managedPredicate passes events of Gslbs in the scope of the operator. Update is passed when either the old or
+// the new Gslb is in the scope, so Gslb relabeled out of the scope is still finalized
+func managedPredicate(config *depresolver.Config) predicate.Funcs {
+	isManaged := func(meta metav1.Object) bool {
+		return config.IsManaged(meta.GetNamespace(), meta.GetLabels())
+	}
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return isManaged(e.Meta) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return isManaged(e.Meta) },
+		GenericFunc: func(e event.GenericEvent) bool { return isManaged(e.Meta) },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return isManaged(e.MetaOld) || isManaged(e.MetaNew)
+		},
+	}
+}
+
+// statusUpdatePredicate filters out updates changing only status of Gslb, e.g. written by the reconciler itself.
+// Changes of spec bump generation; changes of labels, annotations, finalizers and deletion don't, so they are compared
+func statusUpdatePredicate() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration() ||
+				!reflect.DeepEqual(e.MetaOld.GetLabels(), e.MetaNew.GetLabels()) ||
+				!reflect.DeepEqual(e.MetaOld.GetAnnotations(), e.MetaNew.GetAnnotations()) ||
+				!reflect.DeepEqual(e.MetaOld.GetFinalizers(), e.MetaNew.GetFinalizers()) ||
+				(e.MetaOld.GetDeletionTimestamp() == nil) != (e.MetaNew.GetDeletionTimestamp() == nil)
+		},
+	}
+}
+
+// allGslbRequests returns reconcile requests of all Gslbs managed by the operator
+func (r *GslbReconciler) allGslbRequests(c client.Client) []reconcile.Request {
+	gslbs, err := listManagedGslbs(c, r.Config)
+	if err != nil {
+		log.Info("Can't fetch gslb objects")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, gslb := range gslbs {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Name:      gslb.Name,
+			Namespace: gslb.Namespace,
+		}})
+	}
+	return requests
+}
+
+// endpointGslbRequests returns reconcile requests of managed Gslbs in namespace with backend of given service name.
+// Endpoints change often, so namespaces out of the scope of the operator are skipped without listing Gslbs
+func (r *GslbReconciler) endpointGslbRequests(c client.Client, namespace, service string) []reconcile.Request {
+	if !r.Config.IsWatchedNamespace(namespace) {
+		return nil
+	}
+	gslbs, err := listManagedGslbs(c, r.Config, client.InNamespace(namespace))
+	if err != nil {
+		log.Info("Can't fetch gslb objects")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, gslb := range gslbs {
+		if hasBackend(&gslb, service) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Name:      gslb.Name,
+				Namespace: gslb.Namespace,
+			}})
+		}
+	}
+	return requests
+}
+
+func hasBackend(gslb *k8gbv1beta1.Gslb, service string) bool {
+	for _, rule := range gslb.Spec.Ingress.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.ServiceName == service {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// listManagedGslbs lists Gslbs matching GslbLabelSelector in watched namespaces
+func listManagedGslbs(c client.Client, config *depresolver.Config, opts ...client.ListOption) ([]k8gbv1beta1.Gslb, error) {
+	gslbList := &k8gbv1beta1.GslbList{}
+	opts = append(opts, client.MatchingLabelsSelector{Selector: config.GslbSelector()})
+	err := c.List(context.TODO(), gslbList, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var gslbs []k8gbv1beta1.Gslb
+	for _, gslb := range gslbList.Items {
+		if config.IsWatchedNamespace(gslb.Namespace) {
+			gslbs = append(gslbs, gslb)
+		}
+	}
+	return gslbs, nil
 }
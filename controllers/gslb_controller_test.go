@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	coreerrors "errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -40,11 +41,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -346,6 +349,54 @@ func TestGslbCreatesDNSEndpointCRForHealthyIngressHosts(t *testing.T) {
 	assert.Equal(t, want, got, "got:\n %s DNSEndpoint,\n\n want:\n %s", prettyGot, prettyWant)
 }
 
+func TestGslbWithoutSpecDefaultsCreatesDNSEndpointWithDefaultTTL(t *testing.T) {
+	// arrange
+	defer cleanup()
+	serviceName := "frontend-podinfo"
+	dnsEndpoint := &externaldns.DNSEndpoint{}
+	settings := provideSettings(t, predefinedConfig)
+	err := settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.ingress)
+	require.NoError(t, err, "Failed to get expected ingress")
+	settings.ingress.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+	err = settings.client.Status().Update(context.TODO(), settings.ingress)
+	require.NoError(t, err, "Failed to update gslb Ingress Address")
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	// Gslb written without the defaulting webhook, so the finalizer is added by the next reconciliation
+	settings.gslb.Spec.Strategy.DNSTtlSeconds = 0
+	settings.gslb.Spec.Strategy.SplitBrainThresholdSeconds = 0
+	settings.gslb.SetFinalizers(nil)
+	require.NoError(t, settings.client.Update(context.TODO(), settings.gslb))
+	settings.reconciler.Client = readBackClient{settings.client}
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	// assert
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, dnsEndpoint)
+	require.NoError(t, err, "Failed to load DNS endpoint")
+	require.NotEmpty(t, dnsEndpoint.Spec.Endpoints)
+	for _, endpoint := range dnsEndpoint.Spec.Endpoints {
+		assert.Equal(t, externaldns.TTL(30), endpoint.RecordTTL, endpoint.DNSName)
+	}
+}
+
+// readBackClient replaces patched Gslb by the stored one like responses of API server do. Fake client keeps the
+// fields of patched object which are missing in the stored one
+type readBackClient struct {
+	client.Client
+}
+
+func (c readBackClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	err := c.Client.Patch(ctx, obj, patch, opts...)
+	gslb, ok := obj.(*k8gbv1beta1.Gslb)
+	if err != nil || !ok {
+		return err
+	}
+	stored := &k8gbv1beta1.Gslb{}
+	err = c.Client.Get(ctx, client.ObjectKey{Namespace: gslb.Namespace, Name: gslb.Name}, stored)
+	*gslb = *stored
+	return err
+}
+
 func TestDNSRecordReflectionInStatus(t *testing.T) {
 	// arrange
 	defer cleanup()
@@ -456,6 +507,129 @@ func TestCanGetExternalTargetsFromK8gbInAnotherLocation(t *testing.T) {
 	assert.Equal(t, hrGot, hrWant, "got:\n %s Gslb Records status,\n\n want:\n %s", hrGot, hrWant)
 }
 
+func TestReportsExternalClustersInGslbStatus(t *testing.T) {
+	// arrange
+	defer cleanup()
+	serviceName := "frontend-podinfo"
+	want := []k8gbv1beta1.PeerStatus{{GeoTag: "us-east-1", Reachable: true,
+		Targets: map[string][]string{"roundrobin.cloud.example.com": {"10.1.0.1", "10.1.0.2", "10.1.0.3"}}}}
+	customConfig := predefinedConfig
+	customConfig.Override.FakeDNSEnabled = true
+	settings := provideSettings(t, customConfig)
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	// assert
+	assert.Equal(t, want, settings.gslb.Status.Peers)
+}
+
+func TestSetsConditionsOfSuccessfulReconciliation(t *testing.T) {
+	// arrange
+	defer cleanup()
+	serviceName := "frontend-podinfo"
+	customConfig := predefinedConfig
+	customConfig.Override.FakeDNSEnabled = true
+	settings := provideSettings(t, customConfig)
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	// assert
+	assert.True(t, meta.IsStatusConditionTrue(settings.gslb.Status.Conditions, k8gbv1beta1.ConditionReady))
+	assert.True(t, meta.IsStatusConditionTrue(settings.gslb.Status.Conditions, k8gbv1beta1.ConditionDNSEndpointSynced))
+	assert.True(t, meta.IsStatusConditionTrue(settings.gslb.Status.Conditions, k8gbv1beta1.ConditionDelegationSynced))
+	assert.True(t, meta.IsStatusConditionFalse(settings.gslb.Status.Conditions, k8gbv1beta1.ConditionDegraded))
+	assert.Equal(t, settings.gslb.Generation, settings.gslb.Status.ObservedGeneration)
+}
+
+func TestSetsConditionsOfIngressHostnameMismatch(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	customConfig := predefinedConfig
+	customConfig.EdgeDNSZone = "otherdnszone.com"
+	settings.reconciler.Config = &customConfig
+	// act
+	_, err := settings.reconciler.Reconcile(settings.request)
+	require.Error(t, err)
+	require.NoError(t, settings.client.Get(context.TODO(), settings.request.NamespacedName, settings.gslb))
+	// assert
+	synced := meta.FindStatusCondition(settings.gslb.Status.Conditions, k8gbv1beta1.ConditionDNSEndpointSynced)
+	require.NotNil(t, synced)
+	assert.Equal(t, metav1.ConditionFalse, synced.Status)
+	assert.Equal(t, k8gbv1beta1.ReasonZoneMismatch, synced.Reason)
+	assert.Equal(t, err.Error(), synced.Message)
+	assert.True(t, meta.IsStatusConditionFalse(settings.gslb.Status.Conditions, k8gbv1beta1.ConditionReady))
+	assert.True(t, meta.IsStatusConditionTrue(settings.gslb.Status.Conditions, k8gbv1beta1.ConditionDegraded))
+}
+
+func TestRecordsEventsOfStrategyDecisionsOnce(t *testing.T) {
+	// arrange
+	defer cleanup()
+	serviceName := "frontend-podinfo"
+	customConfig := predefinedConfig
+	customConfig.Override.FakeDNSEnabled = true
+	settings := provideSettings(t, customConfig)
+	recorder := record.NewFakeRecorder(20)
+	settings.reconciler.Recorder = recorder
+	createHealthyService(t, &settings, serviceName)
+	defer deleteHealthyService(t, &settings, serviceName)
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	first := drainEvents(recorder)
+	reconcileAndUpdateGslb(t, settings)
+	second := drainEvents(recorder)
+	// assert
+	assert.Contains(t, first, "Normal RoundRobin Host roundrobin.cloud.example.com is served by targets [10.1.0.1 10.1.0.2 10.1.0.3]")
+	assert.Contains(t, first, "Warning NoTargets Host notfound.cloud.example.com has no healthy targets in any cluster")
+	assert.Empty(t, second)
+}
+
+func TestRecordsEventOfFailoverOnPrimary(t *testing.T) {
+	// arrange
+	defer cleanup()
+	customConfig := predefinedConfig
+	customConfig.ClusterGeoTag = "eu"
+	customConfig.Override.FakeDNSEnabled = true
+	settings := provideSettings(t, customConfig)
+	recorder := record.NewFakeRecorder(20)
+	settings.reconciler.Recorder = recorder
+	settings.gslb.Spec.Strategy.Type = "failover"
+	settings.gslb.Spec.Strategy.PrimaryGeoTag = "eu"
+	require.NoError(t, settings.client.Update(context.TODO(), settings.gslb))
+	// act
+	reconcileAndUpdateGslb(t, settings)
+	// assert
+	assert.Contains(t, drainEvents(recorder), "Warning FailoverActive Workload on primary cluster eu is unhealthy, "+
+		"host roundrobin.cloud.example.com is served by targets [10.1.0.1 10.1.0.2 10.1.0.3] of secondary clusters")
+}
+
+func TestForgetsProviderEventsOfFinalizedGslb(t *testing.T) {
+	// arrange
+	defer cleanup()
+	customConfig := predefinedConfig
+	customConfig.Override.FakeDNSEnabled = true
+	settings := provideSettings(t, customConfig)
+	recorder := record.NewFakeRecorder(20)
+	settings.reconciler.Recorder = recorder
+	a := assistant.NewGslbAssistant(settings.client, settings.reconciler.Log, customConfig.K8gbNamespace,
+		customConfig.EdgeDNSServer).WithEventRecorder(settings.reconciler.EventRecorder())
+	gslb := settings.gslb.DeepCopy()
+	a.Event(gslb, "za", corev1.EventTypeWarning, "PeerFilteredOut", "Cluster za is filtered out")
+	a.Event(gslb, "za", corev1.EventTypeWarning, "PeerFilteredOut", "Cluster za is filtered out")
+	deletionTimestamp := metav1.Now()
+	settings.gslb.SetDeletionTimestamp(&deletionTimestamp)
+	require.NoError(t, settings.client.Update(context.TODO(), settings.gslb))
+	settings.finalCall = true
+	reconcileAndUpdateGslb(t, settings)
+	drainEvents(recorder)
+	// act
+	a.Event(gslb, "za", corev1.EventTypeWarning, "PeerFilteredOut", "Cluster za is filtered out")
+	// assert
+	assert.Equal(t, []string{"Warning PeerFilteredOut Cluster za is filtered out"}, drainEvents(recorder))
+}
+
 func TestCanCheckExternalGslbTXTRecordForValidityAndFailIfItIsExpired(t *testing.T) {
 	// arrange
 	defer cleanup()
@@ -464,7 +638,7 @@ func TestCanCheckExternalGslbTXTRecordForValidityAndFailIfItIsExpired(t *testing
 	customConfig.EdgeDNSServer = "fake"
 	settings := provideSettings(t, customConfig)
 	// act
-	got := settings.assistant.InspectTXTThreshold("test-gslb-heartbeat-eu.example.com",
+	got := settings.assistant.InspectTXTThreshold("test-gslb-heartbeat-eu.example.com", "eu",
 		customConfig.Override.FakeDNSEnabled, time.Minute*5)
 	want := errors.NewResourceExpired("Split brain TXT record expired the time threshold: (5m0s)")
 	// assert
@@ -478,12 +652,25 @@ func TestCanCheckExternalGslbTXTRecordForValidityAndPAssIfItISNotExpired(t *test
 	customConfig.EdgeDNSServer = "fake"
 	settings := provideSettings(t, customConfig)
 	// act
-	err2 := settings.assistant.InspectTXTThreshold("test-gslb-heartbeat-za.example.com",
+	err2 := settings.assistant.InspectTXTThreshold("test-gslb-heartbeat-za.example.com", "za",
 		customConfig.Override.FakeDNSEnabled, time.Minute*5)
 	// assert
 	assert.NoError(t, err2, "got:\n %s from TXT split brain check,\n\n want error:\n %v", err2, nil)
 }
 
+func TestRejectsExternalGslbTXTRecordWrittenByOtherCluster(t *testing.T) {
+	// arrange
+	customConfig := predefinedConfig
+	customConfig.Override.FakeDNSEnabled = true
+	customConfig.EdgeDNSServer = "fake"
+	settings := provideSettings(t, customConfig)
+	// act
+	err := settings.assistant.InspectTXTThreshold("test-gslb-heartbeat-us.example.com", "us",
+		customConfig.Override.FakeDNSEnabled, time.Minute*5)
+	// assert
+	assert.True(t, coreerrors.Is(err, utils.ErrUnauthenticated), "got:\n %v from TXT split brain check", err)
+}
+
 func TestReturnsOwnRecordsUsingFailoverStrategyWhenPrimary(t *testing.T) {
 	defer cleanup()
 	serviceName := "frontend-podinfo"
@@ -660,6 +847,21 @@ func TestDetectsIngressHostnameMismatch(t *testing.T) {
 	assert.True(t, strings.HasSuffix(err.Error(), "cloud.example.com does not match delegated zone otherdnszone.com"))
 }
 
+func TestAcceptsIngressHostnameOfAdditionalDNSZone(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	customConfig := predefinedConfig
+	customConfig.DNSZone = "cloud.otherdnszone.com"
+	customConfig.EdgeDNSZone = "otherdnszone.com"
+	customConfig.AdditionalDNSZones = []depresolver.DelegationZone{{DNSZone: "cloud.example.com", EdgeDNSZone: "example.com"}}
+	settings.reconciler.Config = &customConfig
+	// act
+	_, err := settings.reconciler.Reconcile(settings.request)
+	// assert
+	assert.NoError(t, err, "host within additional zone must be accepted")
+}
+
 func TestCreatesNSDNSRecordsForRoute53(t *testing.T) {
 	// arrange
 	defer cleanup()
@@ -715,7 +917,7 @@ func TestCreatesNSDNSRecordsForRoute53(t *testing.T) {
 	settings.reconciler.Config = &customConfig
 	// If config is changed, new Route53 provider needs to be re-created. There is no way and reason to change provider
 	// configuration at another time than startup
-	f, _ := dns.NewDNSProviderFactory(settings.reconciler.Client, customConfig, settings.reconciler.Log)
+	f, _ := dns.NewDNSProviderFactory(settings.reconciler.Client, customConfig, settings.reconciler.Log, settings.reconciler.Metrics)
 	settings.reconciler.DNSProvider = f.Provider()
 
 	reconcileAndUpdateGslb(t, settings)
@@ -786,7 +988,7 @@ func TestCreatesNSDNSRecordsForNS1(t *testing.T) {
 	settings.reconciler.Config = &customConfig
 	// If config is changed, new Route53 provider needs to be re-created. There is no way and reason to change provider
 	// configuration at another time than startup
-	f, _ := dns.NewDNSProviderFactory(settings.reconciler.Client, customConfig, settings.reconciler.Log)
+	f, _ := dns.NewDNSProviderFactory(settings.reconciler.Client, customConfig, settings.reconciler.Log, settings.reconciler.Metrics)
 	settings.reconciler.DNSProvider = f.Provider()
 
 	reconcileAndUpdateGslb(t, settings)
@@ -883,6 +1085,54 @@ func TestRoute53ZoneDelegationGarbageCollection(t *testing.T) {
 	require.Error(t, err, "k8gb-ns-route53 DNSEndpoint should be garbage collected")
 }
 
+func TestCreatesNSDNSRecordsFromClusterPeers(t *testing.T) {
+	// arrange
+	defer cleanup()
+	wantTargets := externaldns.Targets{
+		"gslb-ns-cloud-example-com-af.example.com",
+		"gslb-ns-cloud-example-com-eu.example.com",
+		"gslb-ns-cloud-example-com-za.example.com",
+	}
+	customConfig := predefinedConfig
+	customConfig.CoreDNSExposed = true
+	customConfig.ClusterGeoTag = "eu"
+	customConfig.ExtClustersGeoTags = []string{"za", "us"}
+	settings := provideSettings(t, customConfig)
+	coreDNSService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      coreDNSExtServiceName,
+			Namespace: predefinedConfig.K8gbNamespace,
+		},
+	}
+	err := settings.client.Create(context.TODO(), coreDNSService)
+	require.NoError(t, err, "Failed to create testing %s service", coreDNSExtServiceName)
+	coreDNSService.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+	err = settings.client.Status().Update(context.TODO(), coreDNSService)
+	require.NoError(t, err, "Failed to update coredns service lb ip")
+	for _, peer := range []k8gbv1beta1.ClusterPeer{
+		{ObjectMeta: metav1.ObjectMeta{Name: "af"}, Spec: k8gbv1beta1.ClusterPeerSpec{GeoTag: "af", Enabled: true}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "us"}, Spec: k8gbv1beta1.ClusterPeerSpec{GeoTag: "us", Enabled: false}},
+	} {
+		peer := peer
+		require.NoError(t, settings.client.Create(context.TODO(), &peer))
+	}
+
+	// act
+	customConfig.EdgeDNSType = depresolver.DNSTypeRoute53
+	settings.reconciler.Config = &customConfig
+	f, _ := dns.NewDNSProviderFactory(settings.reconciler.Client, customConfig, settings.reconciler.Log, settings.reconciler.Metrics)
+	settings.reconciler.DNSProvider = f.Provider()
+	reconcileAndUpdateGslb(t, settings)
+	dnsEndpointRoute53 := &externaldns.DNSEndpoint{}
+	err = settings.client.Get(context.TODO(), client.ObjectKey{Namespace: predefinedConfig.K8gbNamespace, Name: "k8gb-ns-route53"}, dnsEndpointRoute53)
+	requests := settings.reconciler.allGslbRequests(settings.client)
+
+	// assert
+	require.NoError(t, err, "Failed to get expected DNSEndpoint")
+	assert.Equal(t, wantTargets, dnsEndpointRoute53.Spec.Endpoints[0].Targets)
+	assert.Equal(t, []reconcile.Request{settings.request}, requests)
+}
+
 func TestGslbSetsAnnotationsOnTheIngress(t *testing.T) {
 	// arrange
 	defer cleanup()
@@ -900,6 +1150,60 @@ func TestGslbSetsAnnotationsOnTheIngress(t *testing.T) {
 	assert.Equal(t, map[string]string{strategyAnnotation: "roundRobin"}, ingress.Annotations)
 }
 
+func TestSkipsGslbNotMatchingLabelSelector(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	settings.reconciler.Config.GslbLabelSelector = "k8gb.io/instance=internal"
+	err := settings.client.Delete(context.TODO(), settings.ingress)
+	require.NoError(t, err)
+	// act
+	result, err := settings.reconciler.Reconcile(settings.request)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, result)
+	err = settings.client.Get(context.TODO(), settings.request.NamespacedName, &v1beta1.Ingress{})
+	assert.True(t, errors.IsNotFound(err), "Ingress of Gslb managed by another instance must not be created")
+}
+
+func TestEndpointGslbRequestsRespectScope(t *testing.T) {
+	// arrange
+	newGslb := func(namespace, name, service string, labels map[string]string) runtime.Object {
+		return &k8gbv1beta1.Gslb{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+			Spec: k8gbv1beta1.GslbSpec{Ingress: v1beta1.IngressSpec{Rules: []v1beta1.IngressRule{
+				{Host: name + ".cloud.example.com", IngressRuleValue: v1beta1.IngressRuleValue{HTTP: &v1beta1.HTTPIngressRuleValue{
+					Paths: []v1beta1.HTTPIngressPath{{Backend: v1beta1.IngressBackend{ServiceName: service}}},
+				}}},
+				{Host: "nohttp.cloud.example.com"},
+			}}},
+		}
+	}
+	internal := map[string]string{"k8gb.io/instance": "internal"}
+	s := runtime.NewScheme()
+	require.NoError(t, k8gbv1beta1.AddToScheme(s))
+	cl := fake.NewFakeClientWithScheme(s,
+		newGslb("team-a", "first", "frontend", internal),
+		newGslb("team-a", "second", "frontend", internal),
+		newGslb("team-a", "public", "frontend", nil),
+		newGslb("team-a", "backend", "backend", internal),
+		newGslb("team-b", "other", "frontend", internal),
+	)
+	r := &GslbReconciler{Config: &depresolver.Config{
+		WatchNamespaces:   []string{"team-a"},
+		GslbLabelSelector: "k8gb.io/instance=internal",
+	}}
+	// act
+	requests := r.endpointGslbRequests(cl, "team-a", "frontend")
+	unwatched := r.endpointGslbRequests(cl, "team-b", "frontend")
+	// assert
+	assert.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "first"}},
+		{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "second"}},
+	}, requests)
+	assert.Empty(t, unwatched)
+}
+
 func TestMain(m *testing.M) {
 	// setup tests
 	fakeDNS()
@@ -1028,6 +1332,30 @@ func deleteUnhealthyService(t *testing.T, s *testSettings, serviceName string) {
 
 }
 
+func drainEvents(recorder *record.FakeRecorder) (events []string) {
+	for {
+		select {
+		case e := <-recorder.Events:
+			events = append(events, e)
+		default:
+			return
+		}
+	}
+}
+
+func TestReconcileRequeuesWithinSplitBrainThreshold(t *testing.T) {
+	// arrange
+	defer cleanup()
+	customConfig := predefinedConfig
+	customConfig.ReconcileRequeueSeconds = 300
+	settings := provideSettings(t, customConfig)
+	// act
+	result, err := settings.reconciler.Reconcile(settings.request)
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, 100*time.Second, result.RequeueAfter, "heartbeat must be refreshed 3 times within 300s threshold")
+}
+
 func reconcileAndUpdateGslb(t *testing.T, s testSettings) {
 	t.Helper()
 	// Reconcile again so Reconcile() checks services and updates the Gslb
@@ -1038,7 +1366,13 @@ func reconcileAndUpdateGslb(t *testing.T, s testSettings) {
 	}
 
 	if !s.finalCall {
-		if res != (reconcile.Result{RequeueAfter: time.Second * 30}) {
+		requeueAfter := time.Second * time.Duration(s.reconciler.Config.ReconcileRequeueSeconds)
+		gslb := s.gslb.DeepCopy()
+		depresolver.DefaultGslbSpec(&gslb.Spec)
+		if refresh := heartbeatRefreshInterval(gslb); refresh < requeueAfter {
+			requeueAfter = refresh
+		}
+		if res != (reconcile.Result{RequeueAfter: requeueAfter}) {
 			t.Error("reconcile did not return Result with Requeue")
 		}
 	}
@@ -1070,7 +1404,7 @@ func provideSettings(t *testing.T, expected depresolver.Config) (settings testSe
 	}
 	// Register operator types with the runtime scheme.
 	s := scheme.Scheme
-	s.AddKnownTypes(k8gbv1beta1.GroupVersion, gslb)
+	s.AddKnownTypes(k8gbv1beta1.GroupVersion, gslb, &k8gbv1beta1.GslbList{}, &k8gbv1beta1.ClusterPeer{}, &k8gbv1beta1.ClusterPeerList{})
 	// Register external-dns DNSEndpoint CRD
 	s.AddKnownTypes(schema.GroupVersion{Group: "externaldns.k8s.io", Version: "v1alpha1"}, &externaldns.DNSEndpoint{})
 	// Create a fake client to mock API calls.
@@ -1099,7 +1433,7 @@ func provideSettings(t *testing.T, expected depresolver.Config) (settings testSe
 	}
 
 	var f *dns.ProviderFactory
-	f, err = dns.NewDNSProviderFactory(r.Client, *r.Config, r.Log)
+	f, err = dns.NewDNSProviderFactory(r.Client, *r.Config, r.Log, r.Metrics)
 	if err != nil {
 		t.Fatalf("reconcile: (%v)", err)
 	}
@@ -1141,7 +1475,9 @@ func cleanup() {
 		depresolver.Route53EnabledKey, depresolver.InfobloxGridHostKey, depresolver.InfobloxVersionKey, depresolver.InfobloxPortKey,
 		depresolver.InfobloxUsernameKey, depresolver.InfobloxPasswordKey, depresolver.InfobloxHTTPRequestTimeoutKey,
 		depresolver.InfobloxHTTPPoolConnectionsKey, depresolver.OverrideWithFakeDNSKey, depresolver.OverrideFakeInfobloxKey,
-		depresolver.LogLevelKey, depresolver.LogFormatKey, depresolver.LogNoColorKey} {
+		depresolver.LogLevelKey, depresolver.LogFormatKey, depresolver.LogNoColorKey, depresolver.ConfigFileKey,
+		depresolver.InfobloxPasswordKey + depresolver.CredentialFileSuffix,
+		depresolver.InfobloxPasswordKey + depresolver.CredentialSecretSuffix} {
 		if os.Unsetenv(s) != nil {
 			panic(fmt.Errorf("cleanup %s", s))
 		}
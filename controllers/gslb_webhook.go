@@ -0,0 +1,126 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"k8s.io/api/admission/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// GslbValidatingWebhookPath is the path validating webhook of Gslb is served on
+	GslbValidatingWebhookPath = "/validate-k8gb-absa-oss-v1beta1-gslb"
+	// GslbMutatingWebhookPath is the path defaulting webhook of Gslb is served on
+	GslbMutatingWebhookPath = "/mutate-k8gb-absa-oss-v1beta1-gslb"
+)
+
+// +kubebuilder:webhook:path=/validate-k8gb-absa-oss-v1beta1-gslb,mutating=false,failurePolicy=fail,groups=k8gb.absa.oss,resources=gslbs,verbs=create;update,versions=v1beta1,name=vgslb.k8gb.absa.oss
+
+// GslbValidator rejects Gslbs which the operator can't reconcile, before any Ingress or DNSEndpoint is created
+type GslbValidator struct {
+	Client client.Client
+	Config *depresolver.Config
+	// DepResolver provides hot-reloaded geo tags of external clusters; Config is used when nil
+	DepResolver *depresolver.DependencyResolver
+	decoder     *admission.Decoder
+}
+
+// Handle validates created and updated Gslbs. Updates which don't change the spec are allowed, so that finalizers
+// of Gslbs created before the webhook was enabled can be removed. Gslbs out of the scope of the operator are left to
+// the k8gb instance managing them
+func (v *GslbValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	gslb := &k8gbv1beta1.Gslb{}
+	if err := v.decoder.Decode(req, gslb); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if !v.Config.IsManaged(gslb.Namespace, gslb.Labels) {
+		return admission.Allowed("Gslb is managed by another k8gb instance")
+	}
+	if req.Operation == v1beta1.Update {
+		old := &k8gbv1beta1.Gslb{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if gslb.DeletionTimestamp != nil || reflect.DeepEqual(gslb.Spec, old.Spec) {
+			return admission.Allowed("spec is not changed")
+		}
+	}
+	geoTags, err := v.geoTags(ctx)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if err = depresolver.ValidateGslbSpec(gslb.Spec, v.Config, geoTags); err != nil {
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("")
+}
+
+// InjectDecoder injects the decoder
+func (v *GslbValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// geoTags returns geo tags of local cluster, of configured external clusters and of ClusterPeers
+func (v *GslbValidator) geoTags(ctx context.Context) ([]string, error) {
+	config := liveConfig(v.DepResolver, v.Config)
+	geoTags := append([]string{config.ClusterGeoTag}, config.ExtClustersGeoTags...)
+	peers := &k8gbv1beta1.ClusterPeerList{}
+	if err := v.Client.List(ctx, peers); err != nil {
+		return nil, err
+	}
+	for _, peer := range peers.Items {
+		geoTags = append(geoTags, peer.Spec.GeoTag)
+	}
+	return geoTags, nil
+}
+
+// +kubebuilder:webhook:path=/mutate-k8gb-absa-oss-v1beta1-gslb,mutating=true,failurePolicy=fail,groups=k8gb.absa.oss,resources=gslbs,verbs=create;update,versions=v1beta1,name=mgslb.k8gb.absa.oss
+
+// GslbDefaulter writes predefined values of spec fields missing in the yaml, so that the reconciler never
+// changes spec of Gslbs
+type GslbDefaulter struct {
+	decoder *admission.Decoder
+}
+
+// Handle patches created and updated Gslbs by spec defaults
+func (d *GslbDefaulter) Handle(_ context.Context, req admission.Request) admission.Response {
+	gslb := &k8gbv1beta1.Gslb{}
+	if err := d.decoder.Decode(req, gslb); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	depresolver.DefaultGslbSpec(&gslb.Spec)
+	raw, err := json.Marshal(gslb)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, raw)
+}
+
+// InjectDecoder injects the decoder
+func (d *GslbDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/admission/v1beta1"
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestWebhookAllowsValidGslb(t *testing.T) {
+	// arrange
+	validator := newTestGslbValidator(t)
+	gslb := newWebhookTestGslb(k8gbv1beta1.FailoverStrategy, "eu", "failover.cloud.example.com")
+	// act
+	response := validator.Handle(context.TODO(), admissionRequest(t, v1beta1.Create, gslb, nil))
+	// assert
+	assert.True(t, response.Allowed)
+}
+
+func TestWebhookAllowsPrimaryGeoTagOfClusterPeer(t *testing.T) {
+	// arrange
+	validator := newTestGslbValidator(t)
+	gslb := newWebhookTestGslb(k8gbv1beta1.FailoverStrategy, "za", "failover.cloud.example.com")
+	// act
+	response := validator.Handle(context.TODO(), admissionRequest(t, v1beta1.Create, gslb, nil))
+	// assert
+	assert.True(t, response.Allowed)
+}
+
+func TestWebhookAllowsPrimaryGeoTagOfReloadedConfig(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	path := writeConfigFile(t, "extClustersGeoTags: [us-east-1]")
+	_ = os.Setenv(depresolver.ConfigFileKey, path)
+	resolver := depresolver.NewDependencyResolver()
+	_, err := resolver.ResolveOperatorConfig()
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, []byte("extClustersGeoTags: [us-east-1, uk]"), 0600))
+	_, _, err = resolver.ReloadOperatorConfig()
+	require.NoError(t, err)
+	validator := newTestGslbValidator(t)
+	validator.DepResolver = resolver
+	gslb := newWebhookTestGslb(k8gbv1beta1.FailoverStrategy, "uk", "failover.cloud.example.com")
+	// act
+	response := validator.Handle(context.TODO(), admissionRequest(t, v1beta1.Create, gslb, nil))
+	// assert
+	assert.True(t, response.Allowed)
+}
+
+func TestWebhookDeniesInvalidGslb(t *testing.T) {
+	// arrange
+	validator := newTestGslbValidator(t)
+	for name, gslb := range map[string]*k8gbv1beta1.Gslb{
+		"unknown strategy":         newWebhookTestGslb("weighted", "", "roundrobin.cloud.example.com"),
+		"failover without primary": newWebhookTestGslb(k8gbv1beta1.FailoverStrategy, "", "failover.cloud.example.com"),
+		"unknown primary geo tag":  newWebhookTestGslb(k8gbv1beta1.FailoverStrategy, "uk", "failover.cloud.example.com"),
+		"host outside of zone":     newWebhookTestGslb(k8gbv1beta1.RoundRobinStrategy, "", "roundrobin.cloud.example.org"),
+	} {
+		// act
+		response := validator.Handle(context.TODO(), admissionRequest(t, v1beta1.Create, gslb, nil))
+		// assert
+		assert.False(t, response.Allowed, name)
+		assert.NotEmpty(t, response.Result.Reason, name)
+	}
+}
+
+func TestWebhookAllowsGslbOutOfScope(t *testing.T) {
+	// arrange
+	validator := newTestGslbValidator(t)
+	validator.Config.GslbLabelSelector = "k8gb.io/instance=internal"
+	gslb := newWebhookTestGslb("weighted", "", "roundrobin.cloud.example.com")
+	// act
+	response := validator.Handle(context.TODO(), admissionRequest(t, v1beta1.Create, gslb, nil))
+	// assert
+	assert.True(t, response.Allowed)
+}
+
+func TestWebhookDeniesNegativeTTL(t *testing.T) {
+	// arrange
+	validator := newTestGslbValidator(t)
+	gslb := newWebhookTestGslb(k8gbv1beta1.RoundRobinStrategy, "", "roundrobin.cloud.example.com")
+	gslb.Spec.Strategy.DNSTtlSeconds = -1
+	// act
+	response := validator.Handle(context.TODO(), admissionRequest(t, v1beta1.Create, gslb, nil))
+	// assert
+	assert.False(t, response.Allowed)
+	assert.Contains(t, string(response.Result.Reason), "spec.strategy.dnsTtlSeconds")
+}
+
+func TestWebhookAllowsUpdateWithoutSpecChange(t *testing.T) {
+	// arrange
+	validator := newTestGslbValidator(t)
+	old := newWebhookTestGslb(k8gbv1beta1.RoundRobinStrategy, "", "roundrobin.cloud.example.org")
+	gslb := old.DeepCopy()
+	gslb.Finalizers = nil
+	// act
+	response := validator.Handle(context.TODO(), admissionRequest(t, v1beta1.Update, gslb, old))
+	// assert
+	assert.True(t, response.Allowed)
+}
+
+func TestWebhookDeniesUpdateToInvalidSpec(t *testing.T) {
+	// arrange
+	validator := newTestGslbValidator(t)
+	old := newWebhookTestGslb(k8gbv1beta1.RoundRobinStrategy, "", "roundrobin.cloud.example.com")
+	gslb := old.DeepCopy()
+	gslb.Spec.Strategy.Type = k8gbv1beta1.FailoverStrategy
+	// act
+	response := validator.Handle(context.TODO(), admissionRequest(t, v1beta1.Update, gslb, old))
+	// assert
+	assert.False(t, response.Allowed)
+}
+
+func TestDefaulterPatchesMissingValues(t *testing.T) {
+	// arrange
+	defaulter := newTestGslbDefaulter(t)
+	gslb := newWebhookTestGslb(k8gbv1beta1.RoundRobinStrategy, "", "roundrobin.cloud.example.com")
+	// act
+	response := defaulter.Handle(context.TODO(), admissionRequest(t, v1beta1.Create, gslb, nil))
+	// assert
+	require.True(t, response.Allowed)
+	patches := map[string]interface{}{}
+	for _, p := range response.Patches {
+		patches[p.Path] = p.Value
+	}
+	assert.Equal(t, map[string]interface{}{
+		"/spec/strategy/dnsTtlSeconds":              float64(30),
+		"/spec/strategy/splitBrainThresholdSeconds": float64(300),
+	}, patches)
+}
+
+func TestDefaulterKeepsSetValues(t *testing.T) {
+	// arrange
+	defaulter := newTestGslbDefaulter(t)
+	gslb := newWebhookTestGslb(k8gbv1beta1.RoundRobinStrategy, "", "roundrobin.cloud.example.com")
+	gslb.Spec.Strategy.DNSTtlSeconds = 5
+	gslb.Spec.Strategy.SplitBrainThresholdSeconds = 60
+	// act
+	response := defaulter.Handle(context.TODO(), admissionRequest(t, v1beta1.Create, gslb, nil))
+	// assert
+	assert.True(t, response.Allowed)
+	assert.Empty(t, response.Patches)
+}
+
+func newTestGslbDefaulter(t *testing.T) *GslbDefaulter {
+	t.Helper()
+	s := runtime.NewScheme()
+	require.NoError(t, k8gbv1beta1.AddToScheme(s))
+	decoder, err := admission.NewDecoder(s)
+	require.NoError(t, err)
+	defaulter := &GslbDefaulter{}
+	require.NoError(t, defaulter.InjectDecoder(decoder))
+	return defaulter
+}
+
+func newTestGslbValidator(t *testing.T) *GslbValidator {
+	t.Helper()
+	s := runtime.NewScheme()
+	require.NoError(t, k8gbv1beta1.AddToScheme(s))
+	decoder, err := admission.NewDecoder(s)
+	require.NoError(t, err)
+	peer := &k8gbv1beta1.ClusterPeer{ObjectMeta: metav1.ObjectMeta{Name: "za"},
+		Spec: k8gbv1beta1.ClusterPeerSpec{GeoTag: "za", Enabled: true}}
+	validator := &GslbValidator{
+		Client: fake.NewFakeClientWithScheme(s, peer),
+		Config: &depresolver.Config{ClusterGeoTag: "eu", ExtClustersGeoTags: []string{"us"}, EdgeDNSZone: "example.com"},
+	}
+	require.NoError(t, validator.InjectDecoder(decoder))
+	return validator
+}
+
+func newWebhookTestGslb(strategy, primaryGeoTag, host string) *k8gbv1beta1.Gslb {
+	return &k8gbv1beta1.Gslb{
+		TypeMeta:   metav1.TypeMeta{APIVersion: k8gbv1beta1.GroupVersion.String(), Kind: "Gslb"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gslb", Namespace: "test-gslb", Finalizers: []string{gslbFinalizer}},
+		Spec: k8gbv1beta1.GslbSpec{
+			Ingress:  extv1beta1.IngressSpec{Rules: []extv1beta1.IngressRule{{Host: host}}},
+			Strategy: k8gbv1beta1.Strategy{Type: strategy, PrimaryGeoTag: primaryGeoTag},
+		},
+	}
+}
+
+func admissionRequest(t *testing.T, operation v1beta1.Operation, gslb, old *k8gbv1beta1.Gslb) admission.Request {
+	t.Helper()
+	req := admission.Request{AdmissionRequest: v1beta1.AdmissionRequest{Operation: operation}}
+	raw, err := json.Marshal(gslb)
+	require.NoError(t, err)
+	req.Object = runtime.RawExtension{Raw: raw}
+	if old != nil {
+		raw, err = json.Marshal(old)
+		require.NoError(t, err)
+		req.OldObject = runtime.RawExtension{Raw: raw}
+	}
+	return req
+}
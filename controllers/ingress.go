@@ -18,11 +18,13 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 
 	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
 
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	v1beta1 "k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -66,9 +68,13 @@ func (r *GslbReconciler) saveIngress(instance *k8gbv1beta1.Gslb, i *v1beta1.Ingr
 		if err != nil {
 			// Creation failed
 			log.Error(err, "Failed to create new Ingress", "Ingress.Namespace", i.Namespace, "Ingress.Name", i.Name)
+			r.event(instance, ingressEventSubject, corev1.EventTypeWarning, eventReasonIngressFailed,
+				fmt.Sprintf("Failed to create Ingress %s: %s", i.Name, err))
 			return err
 		}
 		// Creation was successful
+		r.event(instance, ingressEventSubject, corev1.EventTypeNormal, eventReasonIngressCreated,
+			fmt.Sprintf("Created Ingress %s", i.Name))
 		return nil
 	} else if err != nil {
 		// Error that isn't due to the service not existing
@@ -84,13 +90,19 @@ func (r *GslbReconciler) saveIngress(instance *k8gbv1beta1.Gslb, i *v1beta1.Ingr
 		if errors.IsConflict(err) {
 			r.Log.Info("Ingress has been modified outside of controller, retrying reconciliation",
 				"Ingress.Namespace", found.Namespace, "Ingress.Name", found.Name)
+			r.event(instance, ingressEventSubject, corev1.EventTypeWarning, eventReasonIngressConflict,
+				fmt.Sprintf("Ingress %s has been modified outside of controller, retrying reconciliation", found.Name))
 			return nil
 		}
 		if err != nil {
 			// Update failed
 			log.Error(err, "Failed to update Ingress", "Ingress.Namespace", found.Namespace, "Ingress.Name", found.Name)
+			r.event(instance, ingressEventSubject, corev1.EventTypeWarning, eventReasonIngressFailed,
+				fmt.Sprintf("Failed to update Ingress %s: %s", found.Name, err))
 			return err
 		}
+		r.event(instance, ingressEventSubject, corev1.EventTypeNormal, eventReasonIngressUpdated,
+			fmt.Sprintf("Updated Ingress %s to match Gslb", found.Name))
 	}
 
 	return nil
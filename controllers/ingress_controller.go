@@ -0,0 +1,186 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	v1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// IngressReconciler keeps Gslbs defined by k8gb annotations of Ingresses in sync with the Ingresses. Gslb created
+// out of annotations is controlled by its Ingress, so Gslbs written by hand are never changed.
+type IngressReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	Config *depresolver.Config
+	// Recorder records events of invalid annotations on Ingress. Events are not recorded when Recorder is nil
+	Recorder   record.EventRecorder
+	events     *utils.EventRecorder
+	eventsOnce sync.Once
+}
+
+// +kubebuilder:rbac:groups=extensions,resources=ingresses,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile creates, updates or deletes Gslb of annotated Ingress
+func (r *IngressReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("ingress", req.NamespacedName)
+	ingress := &v1beta1.Ingress{}
+	err := r.Get(ctx, req.NamespacedName, ingress)
+	if errors.IsNotFound(err) {
+		// Gslb of deleted Ingress is removed by garbage collector
+		r.ingressEvents().Forget(&v1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: req.Namespace, Name: req.Name}})
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if isGslbIngress(ingress) {
+		// annotations of Ingress created by Gslb are output of the Gslb
+		return ctrl.Result{}, nil
+	}
+	if !r.Config.IsManaged(ingress.Namespace, ingress.Labels) {
+		// Ingress is watched by another k8gb instance
+		return ctrl.Result{}, nil
+	}
+	desired, err := gslbFromIngress(ingress)
+	if err != nil {
+		// keep the Gslb as it is until the annotations are fixed
+		log.Info(fmt.Sprintf("Invalid k8gb annotations, skipping Gslb synchronization (%s)", err))
+		r.ingressEvents().Event(ingress, annotationsEventSubject, corev1.EventTypeWarning, eventReasonInvalidAnnotations, err.Error())
+		return ctrl.Result{}, nil
+	}
+	// annotations are valid again, so the next invalid annotations are reported even when they repeat
+	r.ingressEvents().Forget(ingress)
+
+	gslb := &k8gbv1beta1.Gslb{}
+	err = r.Get(ctx, req.NamespacedName, gslb)
+	if err == nil && desired != nil && isLegacyIngressDriven(gslb) {
+		log.Info(fmt.Sprintf("Adopting Gslb(%s) created out of Ingress annotation by older k8gb version", gslb.Name))
+		if err = controllerutil.SetControllerReference(ingress, gslb, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err = r.Update(ctx, gslb); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	switch {
+	case errors.IsNotFound(err):
+		if desired == nil {
+			return ctrl.Result{}, nil
+		}
+		if err = controllerutil.SetControllerReference(ingress, desired, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		log.Info(fmt.Sprintf("Creating new Gslb(%s) out of Ingress annotation", desired.Name))
+		return ctrl.Result{}, r.Create(ctx, desired)
+	case err != nil:
+		return ctrl.Result{}, err
+	case !metav1.IsControlledBy(gslb, ingress):
+		log.Info(fmt.Sprintf("Gslb(%s) is not created out of Ingress annotation. Skipping Gslb synchronization...", gslb.Name))
+		return ctrl.Result{}, nil
+	case desired == nil:
+		log.Info(fmt.Sprintf("%s annotation is removed, deleting Gslb(%s)", strategyAnnotation, gslb.Name))
+		return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, gslb))
+	case reflect.DeepEqual(gslb.Spec, desired.Spec) && reflect.DeepEqual(gslb.Annotations, desired.Annotations) &&
+		reflect.DeepEqual(gslb.Labels, desired.Labels):
+		return ctrl.Result{}, nil
+	}
+	log.Info(fmt.Sprintf("Updating Gslb(%s) out of Ingress annotation", gslb.Name))
+	gslb.Spec = desired.Spec
+	gslb.Annotations = desired.Annotations
+	gslb.Labels = desired.Labels
+	return ctrl.Result{}, r.Update(ctx, gslb)
+}
+
+// SetupWithManager configures controller manager
+func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("ingress").
+		For(&v1beta1.Ingress{}, builder.WithPredicates(predicate.NewPredicateFuncs(
+			func(meta metav1.Object, _ runtime.Object) bool {
+				return r.Config.IsManaged(meta.GetNamespace(), meta.GetLabels())
+			}))).
+		Owns(&k8gbv1beta1.Gslb{}).
+		Complete(r)
+}
+
+// gslbFromIngress returns Gslb defined by k8gb annotations of the ingress or nil if the ingress has no strategy
+// annotation. Missing values of the spec are defaulted, so that Gslbs written by the defaulting webhook are equal.
+// Labels are copied, so that the Gslb stays in the scope of the k8gb instance watching the ingress.
+func gslbFromIngress(ingress *v1beta1.Ingress) (*k8gbv1beta1.Gslb, error) {
+	if _, found := ingress.Annotations[strategyAnnotation]; !found {
+		return nil, nil
+	}
+	strategy, err := depresolver.ResolveStrategyAnnotations(ingress.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	gslb := &k8gbv1beta1.Gslb{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   ingress.Namespace,
+			Name:        ingress.Name,
+			Labels:      ingress.Labels,
+			Annotations: ingress.Annotations,
+		},
+		Spec: k8gbv1beta1.GslbSpec{
+			Ingress:  ingress.Spec,
+			Strategy: strategy,
+		},
+	}
+	depresolver.DefaultGslbSpec(&gslb.Spec)
+	return gslb, nil
+}
+
+// isGslbIngress returns true if the ingress is created by Gslb
+func isGslbIngress(ingress *v1beta1.Ingress) bool {
+	owner := metav1.GetControllerOf(ingress)
+	return owner != nil && owner.Kind == "Gslb" && owner.APIVersion == k8gbv1beta1.GroupVersion.String()
+}
+
+// isLegacyIngressDriven returns true if the gslb is created out of annotations of Ingress by k8gb version which
+// didn't set the controller reference. Such Gslb has no controller and carries the annotations of the Ingress
+func isLegacyIngressDriven(gslb *k8gbv1beta1.Gslb) bool {
+	_, found := gslb.Annotations[strategyAnnotation]
+	return found && metav1.GetControllerOf(gslb) == nil
+}
+
+// isIngressDriven returns true if the gslb is created out of annotations of Ingress
+func isIngressDriven(gslb *k8gbv1beta1.Gslb) bool {
+	owner := metav1.GetControllerOf(gslb)
+	return owner != nil && owner.Kind == "Ingress"
+}
@@ -0,0 +1,296 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestCreatesGslbFromAnnotatedIngress(t *testing.T) {
+	// arrange
+	ingress := newAnnotatedIngress(map[string]string{strategyAnnotation: failoverStrategy, primaryGeoTagAnnotation: "eu"})
+	reconciler := newTestIngressReconciler(t, ingress)
+	// act
+	reconcileIngress(t, reconciler, ingress)
+	// assert
+	gslb := getIngressGslb(t, reconciler, ingress)
+	assert.True(t, metav1.IsControlledBy(gslb, ingress))
+	assert.Equal(t, failoverStrategy, gslb.Spec.Strategy.Type)
+	assert.Equal(t, "eu", gslb.Spec.Strategy.PrimaryGeoTag)
+	assert.Equal(t, 30, gslb.Spec.Strategy.DNSTtlSeconds)
+	assert.Equal(t, ingress.Spec, gslb.Spec.Ingress)
+}
+
+func TestUpdatesGslbWhenIngressChanges(t *testing.T) {
+	// arrange
+	ingress := newAnnotatedIngress(map[string]string{strategyAnnotation: roundRobinStrategy})
+	reconciler := newTestIngressReconciler(t, ingress)
+	reconcileIngress(t, reconciler, ingress)
+	ingress.Annotations = map[string]string{strategyAnnotation: failoverStrategy, primaryGeoTagAnnotation: "za"}
+	ingress.Spec.Rules[0].Host = "app2.cloud.example.com"
+	require.NoError(t, reconciler.Update(context.TODO(), ingress))
+	// act
+	reconcileIngress(t, reconciler, ingress)
+	// assert
+	gslb := getIngressGslb(t, reconciler, ingress)
+	assert.Equal(t, failoverStrategy, gslb.Spec.Strategy.Type)
+	assert.Equal(t, "za", gslb.Spec.Strategy.PrimaryGeoTag)
+	assert.Equal(t, "app2.cloud.example.com", gslb.Spec.Ingress.Rules[0].Host)
+	assert.Equal(t, ingress.Annotations, gslb.Annotations)
+}
+
+func TestDeletesGslbWhenStrategyAnnotationIsRemoved(t *testing.T) {
+	// arrange
+	ingress := newAnnotatedIngress(map[string]string{strategyAnnotation: roundRobinStrategy})
+	reconciler := newTestIngressReconciler(t, ingress)
+	reconcileIngress(t, reconciler, ingress)
+	ingress.Annotations = nil
+	require.NoError(t, reconciler.Update(context.TODO(), ingress))
+	// act
+	reconcileIngress(t, reconciler, ingress)
+	// assert
+	err := reconciler.Get(context.TODO(), client.ObjectKey{Namespace: ingress.Namespace, Name: ingress.Name}, &k8gbv1beta1.Gslb{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestKeepsGslbOfInvalidAnnotations(t *testing.T) {
+	// arrange
+	ingress := newAnnotatedIngress(map[string]string{strategyAnnotation: roundRobinStrategy})
+	reconciler := newTestIngressReconciler(t, ingress)
+	recorder := record.NewFakeRecorder(10)
+	reconciler.Recorder = recorder
+	reconcileIngress(t, reconciler, ingress)
+	ingress.Annotations = map[string]string{strategyAnnotation: roundRobinStrategy, k8gbv1beta1.DNSTtlSecondsAnnotation: "30s"}
+	require.NoError(t, reconciler.Update(context.TODO(), ingress))
+	// act
+	reconcileIngress(t, reconciler, ingress)
+	// assert
+	gslb := getIngressGslb(t, reconciler, ingress)
+	assert.Equal(t, 30, gslb.Spec.Strategy.DNSTtlSeconds)
+	assert.Equal(t, []string{`Warning InvalidAnnotations "k8gb.io/dns-ttl-seconds" must be integer, got "30s"`},
+		drainEvents(recorder))
+}
+
+func TestRecordsEventOfInvalidAnnotationsOnce(t *testing.T) {
+	// arrange
+	ingress := newAnnotatedIngress(map[string]string{strategyAnnotation: roundRobinStrategy, k8gbv1beta1.DNSTtlSecondsAnnotation: "30s"})
+	reconciler := newTestIngressReconciler(t, ingress)
+	recorder := record.NewFakeRecorder(10)
+	reconciler.Recorder = recorder
+	// act
+	reconcileIngress(t, reconciler, ingress)
+	first := drainEvents(recorder)
+	reconcileIngress(t, reconciler, ingress)
+	second := drainEvents(recorder)
+	// assert
+	assert.Len(t, first, 1)
+	assert.Empty(t, second)
+}
+
+func TestRecordsEventOfAnnotationsInvalidAgain(t *testing.T) {
+	// arrange
+	invalid := map[string]string{strategyAnnotation: roundRobinStrategy, k8gbv1beta1.DNSTtlSecondsAnnotation: "30s"}
+	ingress := newAnnotatedIngress(invalid)
+	reconciler := newTestIngressReconciler(t, ingress)
+	recorder := record.NewFakeRecorder(10)
+	reconciler.Recorder = recorder
+	reconcileIngress(t, reconciler, ingress)
+	ingress.Annotations = map[string]string{strategyAnnotation: roundRobinStrategy}
+	require.NoError(t, reconciler.Update(context.TODO(), ingress))
+	reconcileIngress(t, reconciler, ingress)
+	ingress.Annotations = invalid
+	require.NoError(t, reconciler.Update(context.TODO(), ingress))
+	drainEvents(recorder)
+	// act
+	reconcileIngress(t, reconciler, ingress)
+	// assert
+	assert.Len(t, drainEvents(recorder), 1)
+}
+
+func TestCreatesGslbWithStrategyOptionsFromAnnotations(t *testing.T) {
+	// arrange
+	ingress := newAnnotatedIngress(map[string]string{strategyAnnotation: roundRobinStrategy,
+		k8gbv1beta1.DNSTtlSecondsAnnotation: "5", k8gbv1beta1.SplitBrainThresholdSecondsAnnotation: "60"})
+	reconciler := newTestIngressReconciler(t, ingress)
+	// act
+	reconcileIngress(t, reconciler, ingress)
+	// assert
+	gslb := getIngressGslb(t, reconciler, ingress)
+	assert.Equal(t, 5, gslb.Spec.Strategy.DNSTtlSeconds)
+	assert.Equal(t, 60, gslb.Spec.Strategy.SplitBrainThresholdSeconds)
+}
+
+func TestDoesNotTouchHandWrittenGslb(t *testing.T) {
+	// arrange
+	ingress := newAnnotatedIngress(map[string]string{strategyAnnotation: roundRobinStrategy})
+	handWritten := &k8gbv1beta1.Gslb{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ingress.Namespace, Name: ingress.Name},
+		Spec:       k8gbv1beta1.GslbSpec{Strategy: k8gbv1beta1.Strategy{Type: failoverStrategy, PrimaryGeoTag: "eu"}},
+	}
+	reconciler := newTestIngressReconciler(t, ingress, handWritten)
+	// act
+	reconcileIngress(t, reconciler, ingress)
+	ingress.Annotations = nil
+	require.NoError(t, reconciler.Update(context.TODO(), ingress))
+	reconcileIngress(t, reconciler, ingress)
+	// assert
+	gslb := getIngressGslb(t, reconciler, ingress)
+	assert.Equal(t, handWritten.Spec, gslb.Spec)
+	assert.Nil(t, metav1.GetControllerOf(gslb))
+}
+
+func TestAdoptsGslbCreatedOutOfAnnotationsByOlderVersion(t *testing.T) {
+	// arrange
+	ingress := newAnnotatedIngress(map[string]string{strategyAnnotation: failoverStrategy, primaryGeoTagAnnotation: "za"})
+	legacy := &k8gbv1beta1.Gslb{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ingress.Namespace, Name: ingress.Name,
+			Annotations: map[string]string{strategyAnnotation: failoverStrategy, primaryGeoTagAnnotation: "eu"}},
+		Spec: k8gbv1beta1.GslbSpec{Strategy: k8gbv1beta1.Strategy{Type: failoverStrategy, PrimaryGeoTag: "eu"}},
+	}
+	reconciler := newTestIngressReconciler(t, ingress, legacy)
+	// act
+	reconcileIngress(t, reconciler, ingress)
+	// assert
+	gslb := getIngressGslb(t, reconciler, ingress)
+	assert.True(t, metav1.IsControlledBy(gslb, ingress))
+	assert.Equal(t, "za", gslb.Spec.Strategy.PrimaryGeoTag)
+	assert.Equal(t, ingress.Annotations, gslb.Annotations)
+}
+
+func TestKeepsGslbCreatedByOlderVersionWhenStrategyAnnotationIsRemoved(t *testing.T) {
+	// arrange
+	ingress := newAnnotatedIngress(nil)
+	legacy := &k8gbv1beta1.Gslb{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ingress.Namespace, Name: ingress.Name,
+			Annotations: map[string]string{strategyAnnotation: roundRobinStrategy}},
+		Spec: k8gbv1beta1.GslbSpec{Strategy: k8gbv1beta1.Strategy{Type: roundRobinStrategy}},
+	}
+	reconciler := newTestIngressReconciler(t, ingress, legacy)
+	// act
+	reconcileIngress(t, reconciler, ingress)
+	// assert
+	gslb := getIngressGslb(t, reconciler, ingress)
+	assert.Nil(t, metav1.GetControllerOf(gslb))
+}
+
+func TestIgnoresIngressOfGslb(t *testing.T) {
+	// arrange
+	gslb := &k8gbv1beta1.Gslb{ObjectMeta: metav1.ObjectMeta{Namespace: "test-gslb", Name: "app", UID: "gslb-uid"}}
+	ingress := newAnnotatedIngress(map[string]string{strategyAnnotation: roundRobinStrategy})
+	require.NoError(t, controllerutil.SetControllerReference(gslb, ingress, newIngressTestScheme(t)))
+	reconciler := newTestIngressReconciler(t, ingress)
+	// act
+	reconcileIngress(t, reconciler, ingress)
+	// assert
+	err := reconciler.Get(context.TODO(), client.ObjectKey{Namespace: ingress.Namespace, Name: ingress.Name}, &k8gbv1beta1.Gslb{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestCopiesIngressLabelsToGslb(t *testing.T) {
+	// arrange
+	ingress := newAnnotatedIngress(map[string]string{strategyAnnotation: roundRobinStrategy})
+	ingress.Labels = map[string]string{"k8gb.io/instance": "internal"}
+	reconciler := newTestIngressReconciler(t, ingress)
+	reconciler.Config.GslbLabelSelector = "k8gb.io/instance=internal"
+	// act
+	reconcileIngress(t, reconciler, ingress)
+	// assert
+	gslb := getIngressGslb(t, reconciler, ingress)
+	assert.Equal(t, ingress.Labels, gslb.Labels)
+	assert.True(t, reconciler.Config.IsManaged(gslb.Namespace, gslb.Labels))
+}
+
+func TestIgnoresIngressOutOfScope(t *testing.T) {
+	var tests = []struct {
+		name   string
+		config depresolver.Config
+	}{
+		{name: "not watched namespace", config: depresolver.Config{WatchNamespaces: []string{"team-a"}}},
+		{name: "not matching labels", config: depresolver.Config{GslbLabelSelector: "k8gb.io/instance=internal"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// arrange
+			ingress := newAnnotatedIngress(map[string]string{strategyAnnotation: roundRobinStrategy})
+			reconciler := newTestIngressReconciler(t, ingress)
+			reconciler.Config = &test.config
+			// act
+			reconcileIngress(t, reconciler, ingress)
+			// assert
+			err := reconciler.Get(context.TODO(), client.ObjectKey{Namespace: ingress.Namespace, Name: ingress.Name}, &k8gbv1beta1.Gslb{})
+			assert.True(t, errors.IsNotFound(err))
+		})
+	}
+}
+
+func newAnnotatedIngress(annotations map[string]string) *v1beta1.Ingress {
+	return &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test-gslb", Name: "app", UID: "ingress-uid", Annotations: annotations},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{{Host: "app.cloud.example.com"}},
+		},
+	}
+}
+
+func newIngressTestScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	require.NoError(t, k8gbv1beta1.AddToScheme(s))
+	return s
+}
+
+func newTestIngressReconciler(t *testing.T, objects ...runtime.Object) *IngressReconciler {
+	t.Helper()
+	s := newIngressTestScheme(t)
+	return &IngressReconciler{
+		Client: fake.NewFakeClientWithScheme(s, objects...),
+		Log:    ctrl.Log.WithName("test"),
+		Scheme: s,
+		Config: &depresolver.Config{},
+	}
+}
+
+func reconcileIngress(t *testing.T, r *IngressReconciler, ingress *v1beta1.Ingress) {
+	t.Helper()
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}})
+	require.NoError(t, err)
+}
+
+func getIngressGslb(t *testing.T, r *IngressReconciler, ingress *v1beta1.Ingress) *k8gbv1beta1.Gslb {
+	t.Helper()
+	gslb := &k8gbv1beta1.Gslb{}
+	require.NoError(t, r.Get(context.TODO(), client.ObjectKey{Namespace: ingress.Namespace, Name: ingress.Name}, gslb))
+	return gslb
+}
@@ -17,32 +17,140 @@ limitations under the License.
 package utils
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"sort"
+	"time"
 
-	"github.com/lixiangzhong/dnsutil"
+	"github.com/miekg/dns"
 )
 
-// Dig retrieves list of tuple <IP address, A record > from edge DNS server for specific FQDN
-func Dig(edgeDNSServer, fqdn string) ([]string, error) {
-	var dig dnsutil.Dig
+// EDNS0BufferSize is UDP payload size advertised in queries. Bigger answers come truncated and are retried over TCP
+const EDNS0BufferSize = 4096
+
+const digTimeout = 5 * time.Second
+
+// Dig retrieves list of tuple <IP address, A record > from edge DNS server for specific FQDN. When tlsConfig is set,
+// the query is sent over DNS over TLS
+func Dig(edgeDNSServer, fqdn string, tlsConfig *tls.Config) ([]string, error) {
 	if edgeDNSServer == "" {
 		return nil, fmt.Errorf("empty edgeDNSServer")
 	}
-	err := dig.SetDNS(edgeDNSServer)
-	if err != nil {
-		err = fmt.Errorf("dig error: can't set query dns (%s) with error(%s)", edgeDNSServer, err)
-		return nil, err
+	if fqdn == "" {
+		return nil, nil
 	}
-	a, err := dig.A(fqdn)
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(fqdn), dns.TypeA)
+	r, err := Exchange(m, DNSAddress(edgeDNSServer, tlsConfig), DNSTransport{Timeout: digTimeout, TLS: tlsConfig})
 	if err != nil {
 		err = fmt.Errorf("dig error: can't dig fqdn(%s) with error(%s)", fqdn, err)
 		return nil, err
 	}
 	var IPs []string
-	for _, ip := range a {
-		IPs = append(IPs, fmt.Sprint(ip.A))
+	for _, rr := range r.Answer {
+		if a, ok := rr.(*dns.A); ok {
+			IPs = append(IPs, a.A.String())
+		}
 	}
 	sort.Strings(IPs)
 	return IPs, nil
 }
+
+// DNSAddress returns address of DNS server; port 853 is used for DNS over TLS, 53 otherwise
+func DNSAddress(server string, tlsConfig *tls.Config) string {
+	if tlsConfig != nil {
+		return net.JoinHostPort(server, "853")
+	}
+	return net.JoinHostPort(server, "53")
+}
+
+// NewTLSClientConfig creates TLS configuration of DNS over TLS and HTTPS clients. Server certificates are verified by PEM bundle
+// caFile, or by system roots when caFile is empty. Empty serverName means the name of queried server is verified
+func NewTLSClientConfig(caFile, serverName string) (*tls.Config, error) {
+	c := &tls.Config{ServerName: serverName, MinVersion: tls.VersionTLS12}
+	if caFile == "" {
+		return c, nil
+	}
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("can't read CA file: %s", err)
+	}
+	c.RootCAs = x509.NewCertPool()
+	if !c.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in CA file %s", caFile)
+	}
+	return c, nil
+}
+
+// DNSTransport configures how Exchange sends queries
+type DNSTransport struct {
+	// Timeout of single query
+	Timeout time.Duration
+	// TLS enables DNS over TLS when set
+	TLS *tls.Config
+	// TSIG signs queries and requires signed answers when set
+	TSIG *TSIG
+}
+
+// TSIG is key signing DNS messages by RFC 8945
+type TSIG struct {
+	// KeyName as FQDN, e.g. k8gb-peers.
+	KeyName string
+	// Secret base64 encoded
+	Secret string
+	// Algorithm as FQDN, e.g. hmac-sha256.
+	Algorithm string
+}
+
+// ErrUnauthenticated is returned when the answer or data received from other cluster can't be authenticated
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// NewTSIG creates TSIG key; key name and algorithm are converted to FQDN
+func NewTSIG(keyName, secret, algorithm string) *TSIG {
+	return &TSIG{KeyName: dns.Fqdn(keyName), Secret: secret, Algorithm: dns.Fqdn(algorithm)}
+}
+
+// Exchange sends query m to server with EDNS0 buffer size set. Truncated UDP answer is retried over TCP, so
+// no records are lost; over DNS over TLS the retry stays on TLS. Answer with other than NOERROR rcode (e.g. SERVFAIL, NXDOMAIN) is returned as error,
+// so failing server can't be mistaken for empty answer. When transport has TSIG, answers which are unsigned
+// or don't pass the verification fail with ErrUnauthenticated
+func Exchange(m *dns.Msg, server string, transport DNSTransport) (*dns.Msg, error) {
+	if m.IsEdns0() == nil {
+		m.SetEdns0(EDNS0BufferSize, false)
+	}
+	c := &dns.Client{Net: "udp", Timeout: transport.Timeout}
+	if transport.TLS != nil {
+		c.Net = "tcp-tls"
+		c.TLSConfig = transport.TLS
+	}
+	if transport.TSIG != nil {
+		c.TsigSecret = map[string]string{transport.TSIG.KeyName: transport.TSIG.Secret}
+		if m.IsTsig() == nil {
+			m.SetTsig(transport.TSIG.KeyName, transport.TSIG.Algorithm, 300, time.Now().Unix())
+		}
+	}
+	r, _, err := c.Exchange(m, server)
+	if err == nil && r.Truncated {
+		if transport.TLS == nil {
+			c.Net = "tcp"
+		}
+		r, _, err = c.Exchange(m, server)
+	}
+	if err == dns.ErrSig || err == dns.ErrTime || err == dns.ErrSecret {
+		return nil, fmt.Errorf("%w: TSIG verification of answer from %s failed (%s)", ErrUnauthenticated, server, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if transport.TSIG != nil && r.IsTsig() == nil {
+		return nil, fmt.Errorf("%w: %s sent answer without TSIG", ErrUnauthenticated, server)
+	}
+	if r.Rcode != dns.RcodeSuccess {
+		return nil, fmt.Errorf("%s answered %s for %s", server, dns.RcodeToString[r.Rcode], m.Question[0].Name)
+	}
+	return r, nil
+}
@@ -17,10 +17,27 @@ limitations under the License.
 package utils
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
 	"net/http"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/miekg/dns"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestValidDig(t *testing.T) {
@@ -31,7 +48,7 @@ func TestValidDig(t *testing.T) {
 	edgeDNSServer := "8.8.8.8"
 	fqdn := "google.com"
 	// act
-	result, err := Dig(edgeDNSServer, fqdn)
+	result, err := Dig(edgeDNSServer, fqdn, nil)
 	// assert
 	assert.NoError(t, err)
 	assert.NotEmpty(t, result)
@@ -46,7 +63,7 @@ func TestEmptyFQDNButValidEdgeDNS(t *testing.T) {
 	edgeDNSServer := "8.8.8.8"
 	fqdn := ""
 	// act
-	result, err := Dig(edgeDNSServer, fqdn)
+	result, err := Dig(edgeDNSServer, fqdn, nil)
 	// assert
 	assert.NoError(t, err)
 	assert.Nil(t, result)
@@ -57,7 +74,7 @@ func TestEmptyEdgeDNS(t *testing.T) {
 	edgeDNSServer := ""
 	fqdn := "whatever"
 	// act
-	result, err := Dig(edgeDNSServer, fqdn)
+	result, err := Dig(edgeDNSServer, fqdn, nil)
 	// assert
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -68,12 +85,317 @@ func TestValidEdgeDNSButNonExistingFQDN(t *testing.T) {
 	edgeDNSServer := "localhost"
 	fqdn := "some-valid-ip-fqdn-123"
 	// act
-	result, err := Dig(edgeDNSServer, fqdn)
+	result, err := Dig(edgeDNSServer, fqdn, nil)
 	// assert
 	assert.Error(t, err)
 	assert.Nil(t, result)
 }
 
+func TestExchangeAdvertisesEDNS0BufferSize(t *testing.T) {
+	// arrange
+	server := startDNSServer(t, dns.RcodeSuccess, 100)
+	m := new(dns.Msg)
+	m.SetQuestion("localtargets-roundrobin.cloud.example.com.", dns.TypeA)
+	// act
+	r, err := Exchange(m, server.addr, DNSTransport{Timeout: time.Second})
+	// assert
+	require.NoError(t, err)
+	assert.Len(t, r.Answer, 100)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&server.tcpQueries))
+}
+
+func TestExchangeRetriesTruncatedAnswerOverTCP(t *testing.T) {
+	// arrange
+	server := startDNSServer(t, dns.RcodeSuccess, 400)
+	m := new(dns.Msg)
+	m.SetQuestion("localtargets-roundrobin.cloud.example.com.", dns.TypeA)
+	// act
+	r, err := Exchange(m, server.addr, DNSTransport{Timeout: time.Second})
+	// assert
+	require.NoError(t, err)
+	assert.False(t, r.Truncated)
+	assert.Len(t, r.Answer, 400)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.udpQueries))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.tcpQueries))
+}
+
+func TestExchangeFailsOnErrorRcode(t *testing.T) {
+	for _, rcode := range []int{dns.RcodeServerFailure, dns.RcodeNameError, dns.RcodeRefused} {
+		t.Run(dns.RcodeToString[rcode], func(t *testing.T) {
+			// arrange
+			server := startDNSServer(t, rcode, 0)
+			m := new(dns.Msg)
+			m.SetQuestion("localtargets-roundrobin.cloud.example.com.", dns.TypeA)
+			// act
+			r, err := Exchange(m, server.addr, DNSTransport{Timeout: time.Second})
+			// assert
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), dns.RcodeToString[rcode])
+			assert.Nil(t, r)
+		})
+	}
+}
+
+func TestExchangeOverTLS(t *testing.T) {
+	// arrange
+	cert, caFile := selfSignedCertificate(t, "dns.example.com")
+	addr := startDoTServer(t, cert)
+	tlsConfig, err := NewTLSClientConfig(caFile, "dns.example.com")
+	require.NoError(t, err)
+	m := new(dns.Msg)
+	m.SetQuestion("localtargets-roundrobin.cloud.example.com.", dns.TypeA)
+	// act
+	r, err := Exchange(m, addr, DNSTransport{Timeout: time.Second, TLS: tlsConfig})
+	// assert
+	require.NoError(t, err)
+	require.Len(t, r.Answer, 1)
+	assert.Equal(t, "10.0.0.1", r.Answer[0].(*dns.A).A.String())
+}
+
+func TestExchangeRetriesTruncatedAnswerOverTLS(t *testing.T) {
+	// arrange
+	cert, caFile := selfSignedCertificate(t, "dns.example.com")
+	var queries int32
+	addr := startDoTServerWithHandler(t, cert, dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		for i := 1; i <= 2; i++ {
+			rr, _ := dns.NewRR(fmt.Sprintf("%s 30 IN A 10.0.0.%d", req.Question[0].Name, i))
+			m.Answer = append(m.Answer, rr)
+		}
+		if atomic.AddInt32(&queries, 1) == 1 {
+			m.Answer = m.Answer[:1]
+			m.Truncated = true
+		}
+		_ = w.WriteMsg(m)
+	}))
+	tlsConfig, err := NewTLSClientConfig(caFile, "dns.example.com")
+	require.NoError(t, err)
+	m := new(dns.Msg)
+	m.SetQuestion("localtargets-roundrobin.cloud.example.com.", dns.TypeA)
+	// act
+	r, err := Exchange(m, addr, DNSTransport{Timeout: time.Second, TLS: tlsConfig})
+	// assert
+	require.NoError(t, err, "retry must not downgrade to plain TCP")
+	assert.False(t, r.Truncated)
+	assert.Len(t, r.Answer, 2)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&queries))
+}
+
+func TestExchangeOverTLSRejectsUntrustedServer(t *testing.T) {
+	// arrange
+	cert, _ := selfSignedCertificate(t, "dns.example.com")
+	_, otherCAFile := selfSignedCertificate(t, "dns.example.com")
+	addr := startDoTServer(t, cert)
+	tlsConfig, err := NewTLSClientConfig(otherCAFile, "dns.example.com")
+	require.NoError(t, err)
+	m := new(dns.Msg)
+	m.SetQuestion("localtargets-roundrobin.cloud.example.com.", dns.TypeA)
+	// act
+	r, err := Exchange(m, addr, DNSTransport{Timeout: time.Second, TLS: tlsConfig})
+	// assert
+	assert.Error(t, err)
+	assert.Nil(t, r)
+}
+
+func TestExchangeOverTLSRejectsUnexpectedServerName(t *testing.T) {
+	// arrange
+	cert, caFile := selfSignedCertificate(t, "dns.example.com")
+	addr := startDoTServer(t, cert)
+	tlsConfig, err := NewTLSClientConfig(caFile, "other.example.com")
+	require.NoError(t, err)
+	m := new(dns.Msg)
+	m.SetQuestion("localtargets-roundrobin.cloud.example.com.", dns.TypeA)
+	// act
+	_, err = Exchange(m, addr, DNSTransport{Timeout: time.Second, TLS: tlsConfig})
+	// assert
+	assert.Error(t, err)
+}
+
+func TestExchangeWithTSIG(t *testing.T) {
+	// arrange
+	const secret = "c2VjcmV0LXNoYXJlZC1ieS1hbGwtY2x1c3RlcnM="
+	tsig := NewTSIG("k8gb-peers", secret, "hmac-sha256")
+	m := new(dns.Msg)
+	m.SetQuestion("localtargets-roundrobin.cloud.example.com.", dns.TypeA)
+	// act
+	r, err := Exchange(m, startTSIGServer(t, tsig.KeyName, secret, true), DNSTransport{Timeout: time.Second, TSIG: tsig})
+	// assert
+	require.NoError(t, err)
+	require.Len(t, r.Answer, 1)
+	assert.NotNil(t, r.IsTsig())
+}
+
+func TestExchangeWithTSIGRejectsUnauthenticatedAnswer(t *testing.T) {
+	const secret = "c2VjcmV0LXNoYXJlZC1ieS1hbGwtY2x1c3RlcnM="
+	tsig := NewTSIG("k8gb-peers", secret, "hmac-sha256")
+	tests := []struct {
+		name   string
+		server string
+	}{
+		{"unsigned answer", startTSIGServer(t, tsig.KeyName, secret, false)},
+		{"different secret", startTSIGServer(t, tsig.KeyName, "b3RoZXItc2VjcmV0", true)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// arrange
+			m := new(dns.Msg)
+			m.SetQuestion("localtargets-roundrobin.cloud.example.com.", dns.TypeA)
+			// act
+			r, err := Exchange(m, test.server, DNSTransport{Timeout: time.Second, TSIG: tsig})
+			// assert
+			assert.True(t, errors.Is(err, ErrUnauthenticated), "%v", err)
+			assert.Nil(t, r)
+		})
+	}
+}
+
+func TestNewTLSClientConfigWithInvalidCAFile(t *testing.T) {
+	// arrange
+	invalid := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, ioutil.WriteFile(invalid, []byte("not a certificate"), 0600))
+	for _, caFile := range []string{invalid, filepath.Join(t.TempDir(), "missing.crt")} {
+		// act
+		c, err := NewTLSClientConfig(caFile, "")
+		// assert
+		assert.Error(t, err)
+		assert.Nil(t, c)
+	}
+}
+
+func TestDNSAddress(t *testing.T) {
+	// arrange
+	tlsConfig, err := NewTLSClientConfig("", "")
+	require.NoError(t, err)
+	// act
+	plainAddress := DNSAddress("dns.example.com", nil)
+	tlsAddress := DNSAddress("10.0.0.1", tlsConfig)
+	// assert
+	assert.Equal(t, "dns.example.com:53", plainAddress)
+	assert.Equal(t, "10.0.0.1:853", tlsAddress)
+}
+
+// selfSignedCertificate creates certificate of DNS server and stores it as CA bundle into temporary file
+func selfSignedCertificate(t *testing.T, name string) (tls.Certificate, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, caFile
+}
+
+// startDoTServer runs DNS over TLS server answering every A question with 10.0.0.1
+func startDoTServer(t *testing.T, cert tls.Certificate) string {
+	return startDoTServerWithHandler(t, cert, dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		rr, _ := dns.NewRR(fmt.Sprintf("%s 30 IN A 10.0.0.1", req.Question[0].Name))
+		m.Answer = append(m.Answer, rr)
+		_ = w.WriteMsg(m)
+	}))
+}
+
+// startDoTServerWithHandler runs DNS over TLS server answering by handler
+func startDoTServerWithHandler(t *testing.T, cert tls.Certificate, handler dns.Handler) string {
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+	require.NoError(t, err)
+	started := make(chan struct{})
+	server := &dns.Server{
+		Listener:          l,
+		Net:               "tcp-tls",
+		NotifyStartedFunc: func() { close(started) },
+		Handler:           handler,
+	}
+	go func() { _ = server.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return l.Addr().String()
+}
+
+// startTSIGServer runs DNS server with TSIG key answering every A question with 10.0.0.1. Answers are signed
+// when sign is true and the query passed TSIG verification
+func startTSIGServer(t *testing.T, keyName, secret string, sign bool) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn:        pc,
+		TsigSecret:        map[string]string{keyName: secret},
+		NotifyStartedFunc: func() { close(started) },
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+			m := new(dns.Msg)
+			m.SetReply(req)
+			rr, _ := dns.NewRR(fmt.Sprintf("%s 30 IN A 10.0.0.1", req.Question[0].Name))
+			m.Answer = append(m.Answer, rr)
+			if tsig := req.IsTsig(); sign && tsig != nil {
+				m.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+			}
+			_ = w.WriteMsg(m)
+		}),
+	}
+	go func() { _ = server.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return pc.LocalAddr().String()
+}
+
+// testDNSServer answers every question with given rcode and number of A records. Over UDP, the answer is
+// truncated to the buffer size advertised by client, as real servers do
+type testDNSServer struct {
+	addr       string
+	udpQueries int32
+	tcpQueries int32
+}
+
+func startDNSServer(t *testing.T, rcode, records int) *testDNSServer {
+	s := &testDNSServer{}
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetRcode(req, rcode)
+		for i := 0; i < records; i++ {
+			rr, _ := dns.NewRR(fmt.Sprintf("%s 30 IN A 10.0.%d.%d", req.Question[0].Name, i/256, i%256))
+			m.Answer = append(m.Answer, rr)
+		}
+		if _, ok := w.LocalAddr().(*net.UDPAddr); ok {
+			atomic.AddInt32(&s.udpQueries, 1)
+			size := dns.MinMsgSize
+			if opt := req.IsEdns0(); opt != nil {
+				size = int(opt.UDPSize())
+			}
+			m.Truncate(size)
+		} else {
+			atomic.AddInt32(&s.tcpQueries, 1)
+		}
+		_ = w.WriteMsg(m)
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	pc, err := net.ListenPacket("udp", l.Addr().String())
+	require.NoError(t, err)
+	s.addr = l.Addr().String()
+	for _, server := range []*dns.Server{{Listener: l, Handler: handler}, {PacketConn: pc, Handler: handler}} {
+		started := make(chan struct{})
+		server.NotifyStartedFunc = func() { close(started) }
+		go func(server *dns.Server) { _ = server.ActivateAndServe() }(server)
+		<-started
+		t.Cleanup(func(server *dns.Server) func() { return func() { _ = server.Shutdown() } }(server))
+	}
+	return s
+}
+
 func connected() (ok bool) {
 	res, err := http.Get("http://google.com")
 	if err != nil {
@@ -0,0 +1,78 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// EventRecorder records Kubernetes events of objects. Reconciliations repeat the same decisions, so the event
+// is recorded only when it differs from the last event recorded for the same object and subject
+type EventRecorder struct {
+	sync.Mutex
+	recorder record.EventRecorder
+	// last holds the last event per object and subject
+	last map[string]map[string]string
+}
+
+// NewEventRecorder wraps recorder. Returned EventRecorder records nothing when recorder is nil
+func NewEventRecorder(recorder record.EventRecorder) *EventRecorder {
+	return &EventRecorder{recorder: recorder, last: make(map[string]map[string]string)}
+}
+
+// Event records event of object. Subject tells what the event is about, e.g. host or external cluster, so the
+// events of different subjects don't replace each other
+func (r *EventRecorder) Event(object runtime.Object, subject, eventType, reason, message string) {
+	if r == nil || r.recorder == nil {
+		return
+	}
+	key := objectKey(object)
+	event := eventType + "/" + reason + "/" + message
+	r.Lock()
+	if r.last[key] == nil {
+		r.last[key] = make(map[string]string)
+	}
+	if r.last[key][subject] == event {
+		r.Unlock()
+		return
+	}
+	r.last[key][subject] = event
+	r.Unlock()
+	r.recorder.Event(object, eventType, reason, message)
+}
+
+// Forget drops the last events of object, typically when the object is deleted
+func (r *EventRecorder) Forget(object runtime.Object) {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	delete(r.last, objectKey(object))
+}
+
+func objectKey(object runtime.Object) string {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetNamespace() + "/" + accessor.GetName()
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRepeatedEventIsRecordedOnce(t *testing.T) {
+	// arrange
+	fake := record.NewFakeRecorder(10)
+	recorder := NewEventRecorder(fake)
+	object := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "test-gslb", Name: "frontend"}}
+	// act
+	recorder.Event(object, "host", corev1.EventTypeNormal, "Reason", "message")
+	recorder.Event(object, "host", corev1.EventTypeNormal, "Reason", "message")
+	// assert
+	assert.Equal(t, []string{"Normal Reason message"}, drain(fake))
+}
+
+func TestChangedEventIsRecorded(t *testing.T) {
+	// arrange
+	fake := record.NewFakeRecorder(10)
+	recorder := NewEventRecorder(fake)
+	object := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "test-gslb", Name: "frontend"}}
+	// act
+	recorder.Event(object, "host", corev1.EventTypeNormal, "Primary", "served by primary")
+	recorder.Event(object, "host", corev1.EventTypeWarning, "Failover", "served by secondary")
+	recorder.Event(object, "host", corev1.EventTypeNormal, "Primary", "served by primary")
+	// assert
+	assert.Equal(t, []string{"Normal Primary served by primary", "Warning Failover served by secondary",
+		"Normal Primary served by primary"}, drain(fake))
+}
+
+func TestEventsOfSubjectsAndObjectsAreIndependent(t *testing.T) {
+	// arrange
+	fake := record.NewFakeRecorder(10)
+	recorder := NewEventRecorder(fake)
+	object := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "test-gslb", Name: "frontend"}}
+	other := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "test-gslb", Name: "backend"}}
+	// act
+	recorder.Event(object, "a", corev1.EventTypeNormal, "Reason", "message")
+	recorder.Event(object, "b", corev1.EventTypeNormal, "Reason", "message")
+	recorder.Event(other, "a", corev1.EventTypeNormal, "Reason", "message")
+	// assert
+	assert.Len(t, drain(fake), 3)
+}
+
+func TestForgottenEventIsRecordedAgain(t *testing.T) {
+	// arrange
+	fake := record.NewFakeRecorder(10)
+	recorder := NewEventRecorder(fake)
+	object := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "test-gslb", Name: "frontend"}}
+	recorder.Event(object, "host", corev1.EventTypeNormal, "Reason", "message")
+	// act
+	recorder.Forget(object)
+	recorder.Event(object, "host", corev1.EventTypeNormal, "Reason", "message")
+	// assert
+	assert.Len(t, drain(fake), 2)
+}
+
+func TestNilRecorderRecordsNothing(t *testing.T) {
+	// arrange
+	recorder := NewEventRecorder(nil)
+	object := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "test-gslb", Name: "frontend"}}
+	// act
+	recorder.Event(object, "host", corev1.EventTypeNormal, "Reason", "message")
+	recorder.Forget(object)
+}
+
+func drain(fake *record.FakeRecorder) (events []string) {
+	for {
+		select {
+		case e := <-fake.Events:
+			events = append(events, e)
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// heartbeatSignatureSeparator separates heartbeat payload from its HMAC signature
+const heartbeatSignatureSeparator = ";hmac-sha256="
+
+// SignHeartbeat appends HMAC-SHA256 signature to the heartbeat written into TXT record fqdn by cluster geoTag.
+// The signature covers fqdn and geoTag besides the payload, so a heartbeat can't be replayed under the name of other
+// Gslb or cluster. Payload is returned unchanged when the secret is empty
+func SignHeartbeat(payload, fqdn, geoTag, secret string) string {
+	if secret == "" {
+		return payload
+	}
+	return payload + heartbeatSignatureSeparator + heartbeatMAC(payload, fqdn, geoTag, secret)
+}
+
+// VerifyHeartbeat returns payload of heartbeat read from TXT record fqdn of cluster geoTag. When the secret is set,
+// heartbeat without valid signature fails with ErrUnauthenticated. When the secret is empty, the signature isn't
+// required and is stripped if present
+func VerifyHeartbeat(heartbeat, fqdn, geoTag, secret string) (string, error) {
+	i := strings.LastIndex(heartbeat, heartbeatSignatureSeparator)
+	if secret == "" {
+		if i >= 0 {
+			return heartbeat[:i], nil
+		}
+		return heartbeat, nil
+	}
+	if i < 0 {
+		return "", fmt.Errorf("%w: heartbeat is not signed", ErrUnauthenticated)
+	}
+	payload, signature := heartbeat[:i], heartbeat[i+len(heartbeatSignatureSeparator):]
+	if !hmac.Equal([]byte(signature), []byte(heartbeatMAC(payload, fqdn, geoTag, secret))) {
+		return "", fmt.Errorf("%w: invalid heartbeat signature", ErrUnauthenticated)
+	}
+	return payload, nil
+}
+
+// heartbeatMAC signs fqdn, geoTag and payload separated by new lines; fqdn is compared case insensitive and
+// without the trailing dot
+func heartbeatMAC(payload, fqdn, geoTag, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "%s\n%s\n%s", strings.TrimSuffix(strings.ToLower(fqdn), "."), geoTag, payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HeartbeatVersion is version of structured heartbeat payload written by Heartbeat.String()
+const HeartbeatVersion = 1
+
+const heartbeatTimeFormat = "2006-01-02T15:04:05"
+
+// Heartbeat is payload of split brain TXT record. Besides the time of the last update, it carries metadata
+// of the cluster which wrote it
+type Heartbeat struct {
+	// Version of parsed payload; 0 for legacy payload containing bare timestamp only
+	Version int
+	// Timestamp of the last update in UTC
+	Timestamp time.Time
+	// OperatorVersion of k8gb
+	OperatorVersion string
+	// GeoTag of the cluster
+	GeoTag string
+	// Draining if true, the cluster shouldn't receive traffic
+	Draining bool
+	// HealthyGslbs number of Gslbs having at least one healthy service
+	HealthyGslbs int
+	// Capacity hint of relative capacity of the cluster; 0 means unknown
+	Capacity int
+}
+
+// String encodes heartbeat as semicolon separated key=value pairs, e.g.
+// v=1;ts=2021-03-12T10:20:30;k8gb=v0.7.1;geo=eu;drain=false;healthy=3;capacity=100
+func (h Heartbeat) String() string {
+	return fmt.Sprintf("v=%d;ts=%s;k8gb=%s;geo=%s;drain=%t;healthy=%d;capacity=%d", HeartbeatVersion,
+		h.Timestamp.UTC().Format(heartbeatTimeFormat), h.OperatorVersion, h.GeoTag, h.Draining, h.HealthyGslbs, h.Capacity)
+}
+
+// Legacy encodes heartbeat as bare timestamp, which is the only payload clusters of older versions can read
+func (h Heartbeat) Legacy() string {
+	return h.Timestamp.UTC().Format(heartbeatTimeFormat)
+}
+
+// ParseHeartbeat parses heartbeat payload. Legacy payload containing bare timestamp is parsed as Version 0.
+// Unknown keys are ignored, so payloads of newer versions remain readable
+func ParseHeartbeat(payload string) (h Heartbeat, err error) {
+	if !strings.HasPrefix(payload, "v=") {
+		h.Timestamp, err = time.Parse(heartbeatTimeFormat, payload)
+		return h, err
+	}
+	var hasTimestamp bool
+	for _, pair := range strings.Split(payload, ";") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return Heartbeat{}, fmt.Errorf("invalid heartbeat field %q", pair)
+		}
+		switch kv[0] {
+		case "v":
+			h.Version, err = strconv.Atoi(kv[1])
+		case "ts":
+			h.Timestamp, err = time.Parse(heartbeatTimeFormat, kv[1])
+			hasTimestamp = true
+		case "k8gb":
+			h.OperatorVersion = kv[1]
+		case "geo":
+			h.GeoTag = kv[1]
+		case "drain":
+			h.Draining, err = strconv.ParseBool(kv[1])
+		case "healthy":
+			h.HealthyGslbs, err = strconv.Atoi(kv[1])
+		case "capacity":
+			h.Capacity, err = strconv.Atoi(kv[1])
+		}
+		if err != nil {
+			return Heartbeat{}, fmt.Errorf("invalid heartbeat field %q: %s", pair, err)
+		}
+	}
+	if !hasTimestamp {
+		return Heartbeat{}, fmt.Errorf("heartbeat without timestamp")
+	}
+	return h, nil
+}
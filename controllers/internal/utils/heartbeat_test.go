@@ -0,0 +1,156 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	heartbeatTimestamp = "2021-03-12T10:20:30"
+	heartbeatFQDN      = "test-gslb-heartbeat-eu.example.com"
+)
+
+func TestSignedHeartbeatIsVerified(t *testing.T) {
+	// arrange
+	heartbeat := SignHeartbeat(heartbeatTimestamp, heartbeatFQDN, "eu", "secret")
+	// act
+	payload, err := VerifyHeartbeat(heartbeat, heartbeatFQDN, "eu", "secret")
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, heartbeatTimestamp, payload)
+	assert.NotEqual(t, heartbeatTimestamp, heartbeat)
+}
+
+func TestHeartbeatSignatureIgnoresTrailingDotAndCaseOfFQDN(t *testing.T) {
+	// arrange
+	heartbeat := SignHeartbeat(heartbeatTimestamp, heartbeatFQDN, "eu", "secret")
+	// act
+	payload, err := VerifyHeartbeat(heartbeat, "Test-Gslb-Heartbeat-EU.example.com.", "eu", "secret")
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, heartbeatTimestamp, payload)
+}
+
+func TestHeartbeatIsNotSignedWithoutSecret(t *testing.T) {
+	// act
+	heartbeat := SignHeartbeat(heartbeatTimestamp, heartbeatFQDN, "eu", "")
+	// assert
+	assert.Equal(t, heartbeatTimestamp, heartbeat)
+}
+
+func TestUnauthenticatedHeartbeatIsRejected(t *testing.T) {
+	signed := SignHeartbeat(heartbeatTimestamp, heartbeatFQDN, "eu", "secret")
+	for name, heartbeat := range map[string]string{
+		"unsigned":         heartbeatTimestamp,
+		"different secret": SignHeartbeat(heartbeatTimestamp, heartbeatFQDN, "eu", "other"),
+		"tampered payload": "2099-01-01T00:00:00" + signed[len(heartbeatTimestamp):],
+		"empty signature":  heartbeatTimestamp + heartbeatSignatureSeparator,
+		"other record":     SignHeartbeat(heartbeatTimestamp, "other-gslb-heartbeat-eu.example.com", "eu", "secret"),
+		"other cluster":    SignHeartbeat(heartbeatTimestamp, heartbeatFQDN, "za", "secret"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			// act
+			payload, err := VerifyHeartbeat(heartbeat, heartbeatFQDN, "eu", "secret")
+			// assert
+			assert.True(t, errors.Is(err, ErrUnauthenticated))
+			assert.Empty(t, payload)
+		})
+	}
+}
+
+func TestHeartbeatSignatureIsIgnoredWithoutSecret(t *testing.T) {
+	for _, heartbeat := range []string{heartbeatTimestamp, SignHeartbeat(heartbeatTimestamp, heartbeatFQDN, "eu", "secret")} {
+		// act
+		payload, err := VerifyHeartbeat(heartbeat, heartbeatFQDN, "eu", "")
+		// assert
+		require.NoError(t, err)
+		assert.Equal(t, heartbeatTimestamp, payload)
+	}
+}
+
+func TestHeartbeatRoundTrip(t *testing.T) {
+	// arrange
+	heartbeat := Heartbeat{
+		Timestamp:       time.Date(2021, 3, 12, 10, 20, 30, 0, time.UTC),
+		OperatorVersion: "v0.7.1",
+		GeoTag:          "eu",
+		Draining:        true,
+		HealthyGslbs:    3,
+		Capacity:        100,
+	}
+	// act
+	payload := heartbeat.String()
+	parsed, err := ParseHeartbeat(payload)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, "v=1;ts=2021-03-12T10:20:30;k8gb=v0.7.1;geo=eu;drain=true;healthy=3;capacity=100", payload)
+	heartbeat.Version = HeartbeatVersion
+	assert.Equal(t, heartbeat, parsed)
+}
+
+func TestLegacyHeartbeatContainsTimestampOnly(t *testing.T) {
+	// arrange
+	heartbeat := Heartbeat{
+		Timestamp: time.Date(2021, 3, 12, 10, 20, 30, 0, time.UTC),
+		GeoTag:    "eu",
+		Draining:  true,
+	}
+	// act
+	payload := heartbeat.Legacy()
+	// assert
+	assert.Equal(t, heartbeatTimestamp, payload)
+}
+
+func TestParseLegacyHeartbeat(t *testing.T) {
+	// act
+	heartbeat, err := ParseHeartbeat(heartbeatTimestamp)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, Heartbeat{Version: 0, Timestamp: time.Date(2021, 3, 12, 10, 20, 30, 0, time.UTC)}, heartbeat)
+}
+
+func TestParseHeartbeatIgnoresUnknownFields(t *testing.T) {
+	// act
+	heartbeat, err := ParseHeartbeat("v=2;ts=2021-03-12T10:20:30;geo=za;region=af-south-1")
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, 2, heartbeat.Version)
+	assert.Equal(t, "za", heartbeat.GeoTag)
+}
+
+func TestParseInvalidHeartbeat(t *testing.T) {
+	for _, payload := range []string{
+		"",
+		"12.03.2021 10:20:30",
+		"v=1;geo=eu",
+		"v=1;ts=2021-03-12T10:20:30;drain",
+		"v=1;ts=2021-03-12T10:20:30;drain=maybe",
+		"v=1;ts=2021-03-12T10:20:30;healthy=many",
+		"v=one;ts=2021-03-12T10:20:30",
+	} {
+		// act
+		_, err := ParseHeartbeat(payload)
+		// assert
+		assert.Error(t, err, payload)
+	}
+}
@@ -56,6 +56,14 @@ func (r *ReconcileResultHandler) Requeue() (ctrl.Result, error) {
 	return r.delayedResult, nil
 }
 
+// RequeueWithin requeue loop after config.ReconcileRequeueSeconds or after limit, whichever comes first
+func (r *ReconcileResultHandler) RequeueWithin(limit time.Duration) (ctrl.Result, error) {
+	if limit > 0 && limit < r.delayedResult.RequeueAfter {
+		return ctrl.Result{RequeueAfter: limit}, nil
+	}
+	return r.delayedResult, nil
+}
+
 func (r *ReconcileResultHandler) RequeueNow() (ctrl.Result, error) {
 	return ctrl.Result{Requeue: true}, nil
 }
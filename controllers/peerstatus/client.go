@@ -0,0 +1,86 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package peerstatus
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client calls APIs of other clusters
+type Client struct {
+	token string
+	http  *http.Client
+}
+
+// NewClient creates client authenticating by token. Server certificates are verified by tlsConfig
+func NewClient(token string, tlsConfig *tls.Config, timeout time.Duration) *Client {
+	return &Client{
+		token: token,
+		http:  &http.Client{Timeout: timeout, Transport: &http.Transport{TLSClientConfig: tlsConfig}},
+	}
+}
+
+// Status returns status of all Gslbs in cluster serving API on endpoint, e.g. https://k8gb.eu.example.com:8443
+func (c *Client) Status(endpoint string) (response StatusResponse, err error) {
+	err = c.get(endpoint, PathStatus, &response)
+	return
+}
+
+// Targets returns local targets of host in cluster serving API on endpoint
+func (c *Client) Targets(endpoint, host string) (response TargetsResponse, err error) {
+	err = c.get(endpoint, PathTargets+"?host="+url.QueryEscape(host), &response)
+	return
+}
+
+func (c *Client) get(endpoint, path string, response interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(endpoint, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(headerAuthorization, bearerPrefix+c.token)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err = json.Unmarshal(raw, response); err != nil {
+			return fmt.Errorf("peer status %s: malformed response: %s", endpoint, err)
+		}
+		return nil
+	case http.StatusUnauthorized:
+		return fmt.Errorf("peer status %s: %w", endpoint, ErrUnauthorized)
+	default:
+		errResponse := ErrorResponse{}
+		if json.Unmarshal(raw, &errResponse) != nil || errResponse.Error == "" {
+			errResponse.Error = strings.TrimSpace(string(raw))
+		}
+		return fmt.Errorf("peer status %s failed (%s): %s", endpoint, resp.Status, errResponse.Error)
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package peerstatus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+const token = "shared-token"
+
+func TestOperatorsReadStatusOfEachOther(t *testing.T) {
+	// arrange
+	eu := startOperator(t, "eu", map[string]string{"app.cloud.example.com": "Healthy", "web.cloud.example.com": "Unhealthy"},
+		map[string][]string{"app.cloud.example.com": {"10.0.0.1", "10.0.0.2"}})
+	za := startOperator(t, "za", map[string]string{"app.cloud.example.com": "Unhealthy"}, nil)
+	c := newTestClient(eu, token)
+	// act
+	euStatus, euErr := c.Status(eu.URL)
+	zaStatus, zaErr := c.Status(za.URL)
+	// assert
+	require.NoError(t, euErr)
+	require.NoError(t, zaErr)
+	assert.Equal(t, StatusResponse{GeoTag: "eu", Gslbs: []GslbStatus{{Namespace: "test-gslb", Name: "test-gslb",
+		ServiceHealth: map[string]string{"app.cloud.example.com": "Healthy", "web.cloud.example.com": "Unhealthy"},
+		LocalTargets:  map[string][]string{"app.cloud.example.com": {"10.0.0.1", "10.0.0.2"}}}}}, euStatus)
+	assert.Equal(t, StatusResponse{GeoTag: "za", Gslbs: []GslbStatus{{Namespace: "test-gslb", Name: "test-gslb",
+		ServiceHealth: map[string]string{"app.cloud.example.com": "Unhealthy"}, LocalTargets: map[string][]string{}}}}, zaStatus)
+}
+
+func TestOperatorsReadTargetsOfEachOther(t *testing.T) {
+	// arrange
+	eu := startOperator(t, "eu", map[string]string{"app.cloud.example.com": "Healthy"},
+		map[string][]string{"app.cloud.example.com": {"10.0.0.1", "10.0.0.2"}})
+	za := startOperator(t, "za", map[string]string{"app.cloud.example.com": "Unhealthy"}, nil)
+	c := newTestClient(eu, token)
+	// act
+	euTargets, euErr := c.Targets(eu.URL, "app.cloud.example.com")
+	zaTargets, zaErr := c.Targets(za.URL, "app.cloud.example.com")
+	// assert
+	require.NoError(t, euErr)
+	require.NoError(t, zaErr)
+	assert.Equal(t, TargetsResponse{Host: "app.cloud.example.com", Targets: []string{"10.0.0.1", "10.0.0.2"}, TTL: 30}, euTargets)
+	assert.Equal(t, TargetsResponse{Host: "app.cloud.example.com", Targets: []string{}}, zaTargets)
+}
+
+func TestRequestWithInvalidTokenIsRejected(t *testing.T) {
+	// arrange
+	eu := startOperator(t, "eu", map[string]string{"app.cloud.example.com": "Healthy"}, nil)
+	// act
+	_, invalidErr := newTestClient(eu, "invalid").Targets(eu.URL, "app.cloud.example.com")
+	_, emptyErr := newTestClient(eu, "").Status(eu.URL)
+	// assert
+	assert.True(t, errors.Is(invalidErr, ErrUnauthorized))
+	assert.True(t, errors.Is(emptyErr, ErrUnauthorized))
+}
+
+func TestTargetsRequireHost(t *testing.T) {
+	// arrange
+	eu := startOperator(t, "eu", map[string]string{"app.cloud.example.com": "Healthy"}, nil)
+	// act
+	_, err := newTestClient(eu, token).Targets(eu.URL, "")
+	// assert
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrUnauthorized))
+}
+
+func TestUnreachableOperator(t *testing.T) {
+	// arrange
+	eu := startOperator(t, "eu", map[string]string{"app.cloud.example.com": "Healthy"}, nil)
+	c := newTestClient(eu, token)
+	eu.Close()
+	// act
+	_, err := c.Status(eu.URL)
+	// assert
+	assert.Error(t, err)
+}
+
+func TestRotatedTokenIsAccepted(t *testing.T) {
+	// arrange
+	current := token
+	server := httptest.NewTLSServer(NewRotatingHandler(newOperatorClient(t, map[string]string{"app.cloud.example.com": "Healthy"}, nil),
+		"eu", func() string { return current }))
+	t.Cleanup(server.Close)
+	// act
+	current = "rotated-token"
+	_, previousErr := newTestClient(server, token).Status(server.URL)
+	_, rotatedErr := newTestClient(server, "rotated-token").Status(server.URL)
+	// assert
+	assert.True(t, errors.Is(previousErr, ErrUnauthorized))
+	assert.NoError(t, rotatedErr)
+}
+
+func TestDNSEndpointsAreIndexedByHostsOfLocalTargets(t *testing.T) {
+	// arrange
+	indexer := &recordingIndexer{}
+	dnsEndpoint := &externaldns.DNSEndpoint{Spec: externaldns.DNSEndpointSpec{Endpoints: []*externaldns.Endpoint{
+		{DNSName: "app.cloud.example.com", Targets: []string{"10.0.0.1"}},
+		{DNSName: localTargetsPrefix + "app.cloud.example.com", Targets: []string{"10.0.0.1"}},
+	}}}
+	// act
+	err := IndexLocalTargets(indexer)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, LocalTargetsHostIndex, indexer.field)
+	assert.Equal(t, []string{"app.cloud.example.com"}, indexer.extractValue(dnsEndpoint))
+	assert.Empty(t, indexer.extractValue(&externaldns.DNSEndpoint{}))
+}
+
+// recordingIndexer records the registered index
+type recordingIndexer struct {
+	field        string
+	extractValue client.IndexerFunc
+}
+
+func (i *recordingIndexer) IndexField(_ context.Context, _ runtime.Object, field string, extractValue client.IndexerFunc) error {
+	i.field, i.extractValue = field, extractValue
+	return nil
+}
+
+// startOperator serves API of operator having single Gslb with given health and local targets
+func startOperator(t *testing.T, geoTag string, health map[string]string, localTargets map[string][]string) *httptest.Server {
+	server := httptest.NewTLSServer(NewHandler(newOperatorClient(t, health, localTargets), geoTag, token))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newOperatorClient creates fake client reading single Gslb with given health and local targets
+func newOperatorClient(t *testing.T, health map[string]string, localTargets map[string][]string) client.Client {
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	require.NoError(t, k8gbv1beta1.AddToScheme(s))
+	s.AddKnownTypes(schema.GroupVersion{Group: "externaldns.k8s.io", Version: "v1alpha1"}, &externaldns.DNSEndpoint{}, &externaldns.DNSEndpointList{})
+	meta := metav1.ObjectMeta{Namespace: "test-gslb", Name: "test-gslb"}
+	dnsEndpoint := &externaldns.DNSEndpoint{ObjectMeta: meta}
+	for host, targets := range localTargets {
+		dnsEndpoint.Spec.Endpoints = append(dnsEndpoint.Spec.Endpoints,
+			&externaldns.Endpoint{DNSName: host, RecordTTL: 30, RecordType: "A", Targets: targets},
+			&externaldns.Endpoint{DNSName: localTargetsPrefix + host, RecordTTL: 30, RecordType: "A", Targets: targets})
+	}
+	gslb := &k8gbv1beta1.Gslb{ObjectMeta: meta, Status: k8gbv1beta1.GslbStatus{ServiceHealth: health}}
+	return fake.NewFakeClientWithScheme(s, gslb, dnsEndpoint)
+}
+
+// newTestClient creates client trusting certificate of test servers
+func newTestClient(server *httptest.Server, token string) *Client {
+	return NewClient(token, server.Client().Transport.(*http.Transport).TLSClientConfig, time.Second)
+}
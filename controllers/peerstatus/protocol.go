@@ -0,0 +1,71 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package peerstatus defines HTTPS/JSON API exposing health and local targets of Gslbs to k8gb operators in
+// other clusters. Unlike DNS records, the API describes every Gslb, including unhealthy hosts.
+//
+// Every request carries token shared by all clusters in the Authorization header as "Bearer <token>". API returns
+// 200 on success, 401 when the token is missing or wrong and any other status with ErrorResponse body on failure.
+package peerstatus
+
+import "errors"
+
+// Version of the API; it is the prefix of all paths
+const Version = "v1"
+
+// Paths of the API
+const (
+	PathStatus          = "/" + Version + "/status"
+	PathTargets         = "/" + Version + "/targets"
+	ContentTypeJSON     = "application/json"
+	headerContentType   = "Content-Type"
+	headerAuthorization = "Authorization"
+	bearerPrefix        = "Bearer "
+)
+
+// ErrUnauthorized is returned by client when other cluster rejects the token
+var ErrUnauthorized = errors.New("peer status API rejected the token")
+
+// StatusResponse describes all Gslbs of the cluster
+type StatusResponse struct {
+	// GeoTag of the cluster, e.g. eu-west-1
+	GeoTag string       `json:"geoTag"`
+	Gslbs  []GslbStatus `json:"gslbs"`
+}
+
+// GslbStatus is local health and local targets of single Gslb
+type GslbStatus struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// ServiceHealth of Gslb hosts; Healthy, Unhealthy or NotFound
+	ServiceHealth map[string]string `json:"serviceHealth"`
+	// LocalTargets of healthy hosts, i.e. addresses served by this cluster
+	LocalTargets map[string][]string `json:"localTargets"`
+}
+
+// TargetsResponse contains local targets of Host. Targets are empty when the host isn't healthy in the cluster,
+// the same way as localtargets-<host> DNS record doesn't exist
+type TargetsResponse struct {
+	Host    string   `json:"host"`
+	Targets []string `json:"targets"`
+	// TTL of the targets in seconds
+	TTL int `json:"ttl"`
+}
+
+// ErrorResponse is body of every failed request
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package peerstatus
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+// localTargetsPrefix prefixes DNS name of local targets of the host in Gslb DNSEndpoint
+const localTargetsPrefix = "localtargets-"
+
+// LocalTargetsHostIndex is field index of DNSEndpoints by hosts of their local targets
+const LocalTargetsHostIndex = "localTargetsHost"
+
+// shutdownTimeout bounds the time in-flight requests are served after the operator is stopped
+const shutdownTimeout = 5 * time.Second
+
+type handler struct {
+	client client.Reader
+	geoTag string
+	token  func() string
+}
+
+// NewHandler returns http.Handler serving status of Gslbs read by client. Requests not carrying token are rejected.
+// The client is expected to read from the informer cache having LocalTargetsHostIndex, see IndexLocalTargets
+func NewHandler(client client.Reader, geoTag, token string) http.Handler {
+	return NewRotatingHandler(client, geoTag, func() string { return token })
+}
+
+// NewRotatingHandler returns http.Handler like NewHandler. token is called on every request, so that rotated token
+// is accepted without restart
+func NewRotatingHandler(client client.Reader, geoTag string, token func() string) http.Handler {
+	h := &handler{client: client, geoTag: geoTag, token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathStatus, h.get(func(r *http.Request) (interface{}, int, error) {
+		statuses, err := h.gslbs()
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		return StatusResponse{GeoTag: h.geoTag, Gslbs: statuses}, http.StatusOK, nil
+	}))
+	mux.HandleFunc(PathTargets, h.get(func(r *http.Request) (interface{}, int, error) {
+		host := r.URL.Query().Get("host")
+		if host == "" {
+			return nil, http.StatusBadRequest, fmt.Errorf("host must be set")
+		}
+		ep, err := h.localTargets(host)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		response := TargetsResponse{Host: host, Targets: []string{}}
+		if ep != nil {
+			response.Targets = ep.Targets
+			response.TTL = int(ep.RecordTTL)
+		}
+		return response, http.StatusOK, nil
+	}))
+	return mux
+}
+
+// IndexLocalTargets registers LocalTargetsHostIndex, so that targets of single host are looked up without reading
+// DNSEndpoints of all Gslbs
+func IndexLocalTargets(indexer client.FieldIndexer) error {
+	return indexer.IndexField(context.TODO(), &externaldns.DNSEndpoint{}, LocalTargetsHostIndex, func(obj runtime.Object) (hosts []string) {
+		for host := range localTargetsOf(obj.(*externaldns.DNSEndpoint)) {
+			hosts = append(hosts, host)
+		}
+		return
+	})
+}
+
+// gslbs reads status of all Gslbs together with their local targets. DNSEndpoints are listed at once and paired
+// with Gslbs by name
+func (h *handler) gslbs() (statuses []GslbStatus, err error) {
+	gslbList := &k8gbv1beta1.GslbList{}
+	if err = h.client.List(context.TODO(), gslbList); err != nil {
+		return nil, err
+	}
+	endpointList := &externaldns.DNSEndpointList{}
+	if err = h.client.List(context.TODO(), endpointList); err != nil {
+		return nil, err
+	}
+	dnsEndpoints := make(map[types.NamespacedName]*externaldns.DNSEndpoint)
+	for i, dnsEndpoint := range endpointList.Items {
+		dnsEndpoints[types.NamespacedName{Namespace: dnsEndpoint.Namespace, Name: dnsEndpoint.Name}] = &endpointList.Items[i]
+	}
+	statuses = []GslbStatus{}
+	for _, gslb := range gslbList.Items {
+		status := GslbStatus{Namespace: gslb.Namespace, Name: gslb.Name, ServiceHealth: gslb.Status.ServiceHealth,
+			LocalTargets: map[string][]string{}}
+		if dnsEndpoint, found := dnsEndpoints[types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name}]; found {
+			for host, ep := range localTargetsOf(dnsEndpoint) {
+				status.LocalTargets[host] = ep.Targets
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// localTargets returns endpoint of local targets of host or nil if no Gslb serves the host. Only DNSEndpoints
+// indexed by the host are read
+func (h *handler) localTargets(host string) (*externaldns.Endpoint, error) {
+	endpointList := &externaldns.DNSEndpointList{}
+	err := h.client.List(context.TODO(), endpointList, client.MatchingFields{LocalTargetsHostIndex: host})
+	if err != nil {
+		return nil, err
+	}
+	for i := range endpointList.Items {
+		if ep, found := localTargetsOf(&endpointList.Items[i])[host]; found {
+			return ep, nil
+		}
+	}
+	return nil, nil
+}
+
+// localTargetsOf returns endpoints of local targets of dnsEndpoint indexed by host
+func localTargetsOf(dnsEndpoint *externaldns.DNSEndpoint) map[string]*externaldns.Endpoint {
+	endpoints := make(map[string]*externaldns.Endpoint)
+	for _, ep := range dnsEndpoint.Spec.Endpoints {
+		if strings.HasPrefix(ep.DNSName, localTargetsPrefix) {
+			endpoints[strings.TrimPrefix(ep.DNSName, localTargetsPrefix)] = ep
+		}
+	}
+	return endpoints
+}
+
+// get wraps fn into authenticated GET handler. fn returns the response body and status
+func (h *handler) get(fn func(r *http.Request) (interface{}, int, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+			return
+		}
+		if !h.authenticated(r) {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid token"))
+			return
+		}
+		result, status, err := fn(r)
+		if err != nil {
+			writeError(w, status, err)
+			return
+		}
+		writeJSON(w, status, result)
+	}
+}
+
+func (h *handler) authenticated(r *http.Request) bool {
+	auth := r.Header.Get(headerAuthorization)
+	token := h.token()
+	if token == "" || !strings.HasPrefix(auth, bearerPrefix) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, bearerPrefix)), []byte(token)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set(headerContentType, ContentTypeJSON)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+// Server serves handler over HTTPS. It implements manager.Runnable, so it runs as long as the operator
+type Server struct {
+	address  string
+	certFile string
+	keyFile  string
+	handler  http.Handler
+}
+
+// NewServer creates server listening on address, e.g. :8443, with certificate and key read from PEM files
+func NewServer(address, certFile, keyFile string, handler http.Handler) *Server {
+	return &Server{address: address, certFile: certFile, keyFile: keyFile, handler: handler}
+}
+
+// Start serves requests until stop is closed
+func (s *Server) Start(stop <-chan struct{}) error {
+	server := &http.Server{Addr: s.address, Handler: s.handler, TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12}}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServeTLS(s.certFile, s.keyFile)
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-stop:
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return server.Shutdown(ctx)
+	}
+}
+
+// NeedLeaderElection returns false; every replica serves status of the cluster
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/providers/dns"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// peerWatcherConcurrency bounds the number of hosts resolved and heartbeats inspected in external clusters at once
+const peerWatcherConcurrency = 16
+
+// peerWatcher polls targets of every Gslb host and heartbeats of every Gslb in external clusters and sends event
+// of each Gslb whose external view changed since the previous poll. Host or heartbeat shared by several Gslbs is
+// queried once
+type peerWatcher struct {
+	client   client.Client
+	config   *depresolver.Config
+	provider dns.IDnsProvider
+	interval time.Duration
+	events   chan event.GenericEvent
+	views    map[types.NamespacedName]string
+}
+
+func newPeerWatcher(client client.Client, config *depresolver.Config, provider dns.IDnsProvider, interval time.Duration) *peerWatcher {
+	return &peerWatcher{
+		client:   client,
+		config:   config,
+		provider: provider,
+		interval: interval,
+		events:   make(chan event.GenericEvent),
+		views:    make(map[types.NamespacedName]string),
+	}
+}
+
+// Start polls external clusters every interval until stop is closed. Runs only in the elected leader
+func (w *peerWatcher) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			for _, gslb := range w.poll() {
+				select {
+				case w.events <- event.GenericEvent{Meta: gslb, Object: gslb}:
+				case <-stop:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// poll returns Gslbs whose external view differs from the previous poll. Gslbs seen for the first time are not
+// returned, they are reconciled when they are created
+func (w *peerWatcher) poll() (changed []*k8gbv1beta1.Gslb) {
+	gslbs, err := listManagedGslbs(w.client, w.config)
+	if err != nil {
+		log.Info(fmt.Sprintf("Can't fetch gslb objects (%s)", err))
+		return nil
+	}
+	gslbs = liveGslbs(gslbs)
+	targets := w.resolveHosts(gslbs)
+	heartbeats, alive := w.inspectHeartbeats(gslbs)
+	views := make(map[types.NamespacedName]string)
+	for i := range gslbs {
+		gslb := &gslbs[i]
+		key := types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name}
+		views[key] = view(gslb, targets, heartbeats[i], alive)
+		if previous, found := w.views[key]; found && previous != views[key] {
+			log.Info(fmt.Sprintf("External view of Gslb(%s) changed, reconciling", key))
+			changed = append(changed, gslb)
+		}
+	}
+	w.views = views
+	return
+}
+
+// resolveHosts retrieves targets of all hosts of gslbs from external clusters
+func (w *peerWatcher) resolveHosts(gslbs []k8gbv1beta1.Gslb) map[string][]string {
+	unique := make(map[string]bool)
+	for _, gslb := range gslbs {
+		for _, rule := range gslb.Spec.Ingress.Rules {
+			unique[rule.Host] = true
+		}
+	}
+	targets := make(map[string][]string)
+	var mu sync.Mutex
+	var queries []func()
+	for host := range unique {
+		host := host
+		queries = append(queries, func() {
+			hostTargets := sortTargets(w.provider.GetExternalTargets(host))
+			mu.Lock()
+			targets[host] = hostTargets
+			mu.Unlock()
+		})
+	}
+	concurrently(queries)
+	return targets
+}
+
+// inspectHeartbeats inspects heartbeats of all gslbs in external clusters. Returns heartbeats of every Gslb and
+// liveness per heartbeat key; heartbeat shared by several Gslbs is inspected once
+func (w *peerWatcher) inspectHeartbeats(gslbs []k8gbv1beta1.Gslb) (heartbeats [][]dns.Heartbeat, alive map[string]bool) {
+	unique := make(map[string]dns.Heartbeat)
+	for _, gslb := range gslbs {
+		gslb := gslb.DeepCopy()
+		depresolver.DefaultGslbSpec(&gslb.Spec)
+		gslbHeartbeats := w.provider.ExternalHeartbeats(gslb)
+		for _, heartbeat := range gslbHeartbeats {
+			unique[heartbeatKey(heartbeat)] = heartbeat
+		}
+		heartbeats = append(heartbeats, gslbHeartbeats)
+	}
+	alive = make(map[string]bool)
+	var mu sync.Mutex
+	var queries []func()
+	for key, heartbeat := range unique {
+		key, heartbeat := key, heartbeat
+		queries = append(queries, func() {
+			heartbeatAlive := heartbeat.Alive()
+			mu.Lock()
+			alive[key] = heartbeatAlive
+			mu.Unlock()
+		})
+	}
+	concurrently(queries)
+	return
+}
+
+// concurrently runs queries, at most peerWatcherConcurrency of them at once, and waits until all of them are done
+func concurrently(queries []func()) {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, peerWatcherConcurrency)
+	for _, query := range queries {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(query func()) {
+			defer wg.Done()
+			query()
+			<-semaphore
+		}(query)
+	}
+	wg.Wait()
+}
+
+// heartbeatKey identifies inspection of heartbeat; Gslbs of the same name in different namespaces share the TXT
+// record, but may differ in the threshold
+func heartbeatKey(heartbeat dns.Heartbeat) string {
+	return fmt.Sprintf("%s/%s", heartbeat.FQDN, heartbeat.Threshold)
+}
+
+// liveGslbs returns gslbs which aren't being deleted
+func liveGslbs(gslbs []k8gbv1beta1.Gslb) (live []k8gbv1beta1.Gslb) {
+	for _, gslb := range gslbs {
+		if gslb.GetDeletionTimestamp() == nil {
+			live = append(live, gslb)
+		}
+	}
+	return
+}
+
+// view describes everything the reconciliation of gslb reads from external clusters; targets of its hosts and
+// liveness of its heartbeats
+func view(gslb *k8gbv1beta1.Gslb, targets map[string][]string, heartbeats []dns.Heartbeat, alive map[string]bool) string {
+	var lines []string
+	for _, rule := range gslb.Spec.Ingress.Rules {
+		lines = append(lines, fmt.Sprintf("%s=%s", rule.Host, strings.Join(targets[rule.Host], ",")))
+	}
+	for _, heartbeat := range heartbeats {
+		lines = append(lines, fmt.Sprintf("%s=%t", heartbeat.FQDN, alive[heartbeatKey(heartbeat)]))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
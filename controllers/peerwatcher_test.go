@@ -0,0 +1,186 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/providers/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+// watchedProvider answers targets and heartbeats of external clusters and counts the queries
+type watchedProvider struct {
+	sync.Mutex
+	targets    map[string][]string
+	heartbeats map[string]bool
+	queries    map[string]int
+}
+
+func (p *watchedProvider) CreateZoneDelegationForExternalDNS(*k8gbv1beta1.Gslb) error { return nil }
+
+func (p *watchedProvider) GslbIngressExposedIPs(*k8gbv1beta1.Gslb) ([]string, error) { return nil, nil }
+
+func (p *watchedProvider) GetExternalTargets(host string) []string {
+	p.Lock()
+	defer p.Unlock()
+	p.queries[host]++
+	return append([]string{}, p.targets[host]...)
+}
+
+func (p *watchedProvider) ExternalClustersStatus(*k8gbv1beta1.Gslb) []k8gbv1beta1.PeerStatus {
+	return nil
+}
+
+func (p *watchedProvider) ExternalHeartbeats(gslb *k8gbv1beta1.Gslb) []dns.Heartbeat {
+	fqdn := gslb.Name
+	return []dns.Heartbeat{{FQDN: fqdn, Alive: func() bool {
+		p.Lock()
+		defer p.Unlock()
+		p.queries[fqdn]++
+		return p.heartbeats[fqdn]
+	}}}
+}
+
+func (p *watchedProvider) SaveDNSEndpoint(*k8gbv1beta1.Gslb, *externaldns.DNSEndpoint) error {
+	return nil
+}
+
+func (p *watchedProvider) Finalize(*k8gbv1beta1.Gslb, []k8gbv1beta1.Gslb) error { return nil }
+
+func (p *watchedProvider) set(host string, targets ...string) {
+	p.Lock()
+	defer p.Unlock()
+	p.targets[host] = targets
+}
+
+func TestPeerWatcherReturnsOnlyGslbsWithChangedTargets(t *testing.T) {
+	// arrange
+	provider := newWatchedProvider()
+	provider.set("app.cloud.example.com", "10.0.0.2", "10.0.0.1")
+	watcher := newTestPeerWatcher(t, provider,
+		newWatchedGslb("app", "app.cloud.example.com", "shared.cloud.example.com"),
+		newWatchedGslb("web", "web.cloud.example.com", "shared.cloud.example.com"))
+	require.Empty(t, watcher.poll(), "Gslbs seen for the first time are not returned")
+	provider.set("app.cloud.example.com", "10.0.0.1", "10.0.0.2")
+	require.Empty(t, watcher.poll(), "order of targets doesn't matter")
+	// act
+	provider.set("app.cloud.example.com", "10.0.0.1")
+	changed := watcher.poll()
+	// assert
+	assert.Equal(t, []string{"app"}, gslbNames(changed))
+	assert.Equal(t, 3, provider.queries["shared.cloud.example.com"], "shared host is queried once per poll")
+}
+
+func TestPeerWatcherReturnsGslbsOfChangedSharedHost(t *testing.T) {
+	// arrange
+	provider := newWatchedProvider()
+	watcher := newTestPeerWatcher(t, provider,
+		newWatchedGslb("app", "app.cloud.example.com", "shared.cloud.example.com"),
+		newWatchedGslb("web", "web.cloud.example.com", "shared.cloud.example.com"))
+	watcher.poll()
+	// act
+	provider.set("shared.cloud.example.com", "10.1.0.1")
+	changed := watcher.poll()
+	// assert
+	assert.Equal(t, []string{"app", "web"}, gslbNames(changed))
+}
+
+func TestPeerWatcherReturnsGslbWithChangedHeartbeat(t *testing.T) {
+	// arrange
+	provider := newWatchedProvider()
+	provider.heartbeats["app"] = true
+	watcher := newTestPeerWatcher(t, provider,
+		newWatchedGslb("app", "app.cloud.example.com"),
+		newWatchedGslb("web", "web.cloud.example.com"))
+	watcher.poll()
+	// act
+	provider.Lock()
+	provider.heartbeats["app"] = false
+	provider.Unlock()
+	changed := watcher.poll()
+	// assert
+	assert.Equal(t, []string{"app"}, gslbNames(changed))
+}
+
+func TestPeerWatcherInspectsSharedHeartbeatOnce(t *testing.T) {
+	// arrange
+	provider := newWatchedProvider()
+	other := newWatchedGslb("app", "app.example.org")
+	other.Namespace = "other"
+	watcher := newTestPeerWatcher(t, provider, newWatchedGslb("app", "app.cloud.example.com"), other)
+	// act
+	watcher.poll()
+	// assert
+	assert.Equal(t, 1, provider.queries["app"], "heartbeat of Gslbs of the same name is inspected once per poll")
+}
+
+func TestPeerWatcherSendsEventsOfChangedGslbs(t *testing.T) {
+	// arrange
+	provider := newWatchedProvider()
+	watcher := newTestPeerWatcher(t, provider, newWatchedGslb("app", "app.cloud.example.com"))
+	watcher.interval = 10 * time.Millisecond
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() { _ = watcher.Start(stop) }()
+	time.Sleep(50 * time.Millisecond)
+	// act
+	provider.set("app.cloud.example.com", "10.0.0.1")
+	// assert
+	select {
+	case e := <-watcher.events:
+		assert.Equal(t, "app", e.Meta.GetName())
+	case <-time.After(time.Second):
+		assert.Fail(t, "no event of changed Gslb")
+	}
+}
+
+func newWatchedProvider() *watchedProvider {
+	return &watchedProvider{targets: make(map[string][]string), heartbeats: make(map[string]bool), queries: make(map[string]int)}
+}
+
+func newWatchedGslb(name string, hosts ...string) *k8gbv1beta1.Gslb {
+	gslb := &k8gbv1beta1.Gslb{ObjectMeta: metav1.ObjectMeta{Namespace: "test-gslb", Name: name}}
+	for _, host := range hosts {
+		gslb.Spec.Ingress.Rules = append(gslb.Spec.Ingress.Rules, v1beta1.IngressRule{Host: host})
+	}
+	return gslb
+}
+
+func newTestPeerWatcher(t *testing.T, provider *watchedProvider, gslbs ...runtime.Object) *peerWatcher {
+	t.Helper()
+	s := runtime.NewScheme()
+	require.NoError(t, k8gbv1beta1.AddToScheme(s))
+	return newPeerWatcher(fake.NewFakeClientWithScheme(s, gslbs...), &depresolver.Config{}, provider, time.Hour)
+}
+
+func gslbNames(gslbs []*k8gbv1beta1.Gslb) (names []string) {
+	for _, gslb := range gslbs {
+		names = append(names, gslb.Name)
+	}
+	return
+}
@@ -18,6 +18,7 @@ package assistant
 
 import (
 	"context"
+	"crypto/tls"
 	coreerrors "errors"
 	"fmt"
 	"strings"
@@ -29,10 +30,12 @@ import (
 	externaldns "sigs.k8s.io/external-dns/endpoint"
 
 	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
+	"github.com/AbsaOSS/k8gb/controllers/peerstatus"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	v1beta1 "k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -43,10 +46,16 @@ const coreDNSExtServiceName = "k8gb-coredns-lb"
 // it directly logs messages into logr.Logger and use apimachinery client
 // to call kubernetes API
 type GslbLoggerAssistant struct {
-	log           logr.Logger
-	client        client.Client
-	k8gbNamespace string
-	edgeDNSServer string
+	log             logr.Logger
+	client          client.Client
+	k8gbNamespace   string
+	edgeDNSServer   string
+	resolver        *targetResolver
+	observations    *peerObservations
+	tlsConfig       *tls.Config
+	tsig            *utils.TSIG
+	heartbeatSecret string
+	events          *utils.EventRecorder
 }
 
 func NewGslbAssistant(client client.Client, log logr.Logger, k8gbNamespace, edgeDNSServer string) *GslbLoggerAssistant {
@@ -55,9 +64,66 @@ func NewGslbAssistant(client client.Client, log logr.Logger, k8gbNamespace, edge
 		log:           log,
 		k8gbNamespace: k8gbNamespace,
 		edgeDNSServer: edgeDNSServer,
+		resolver:      newTargetResolver(defaultPeerQueryTimeout),
+		observations:  newPeerObservations(),
 	}
 }
 
+// WithTLS makes queries to edge DNS server and to external clusters go over DNS over TLS
+func (r *GslbLoggerAssistant) WithTLS(tlsConfig *tls.Config) *GslbLoggerAssistant {
+	r.tlsConfig = tlsConfig
+	return r
+}
+
+// WithPeerAuth makes answers of external clusters verified by TSIG and split brain TXT heartbeats verified
+// by HMAC signature. Nil tsig or empty heartbeatSecret disables the particular verification
+func (r *GslbLoggerAssistant) WithPeerAuth(tsig *utils.TSIG, heartbeatSecret string) *GslbLoggerAssistant {
+	r.tsig = tsig
+	r.heartbeatSecret = heartbeatSecret
+	return r
+}
+
+// WithPeerStatus makes targets of external clusters read from their peer status API, when the cluster has
+// status endpoint. DNS is queried when the API can't be reached
+func (r *GslbLoggerAssistant) WithPeerStatus(client *peerstatus.Client) *GslbLoggerAssistant {
+	r.resolver.status = client
+	return r
+}
+
+// WithPeerQueryObserver sets observer notified about DNS queries to external clusters
+func (r *GslbLoggerAssistant) WithPeerQueryObserver(observer PeerQueryObserver) *GslbLoggerAssistant {
+	r.resolver.observer = observer
+	return r
+}
+
+// WithEventRecorder makes Event record Kubernetes events. The recorder is shared with the Gslb controller, which
+// forgets the events of finalized Gslbs
+func (r *GslbLoggerAssistant) WithEventRecorder(events *utils.EventRecorder) *GslbLoggerAssistant {
+	r.events = events
+	return r
+}
+
+// PeerState is what assistants learned about external clusters; answers cached for the TTL of their records and
+// observations reported in status of Gslbs. Assistant recreated on configuration reload takes over the state of
+// the previous one, so that the reload doesn't query all external clusters again
+type PeerState struct {
+	cache        *targetCache
+	observations *peerObservations
+}
+
+// NewPeerState creates empty state shared by assistants
+func NewPeerState() *PeerState {
+	return &PeerState{cache: newTargetCache(), observations: newPeerObservations()}
+}
+
+// WithPeerState makes the assistant share cached answers and observations of external clusters with other
+// assistants of state
+func (r *GslbLoggerAssistant) WithPeerState(state *PeerState) *GslbLoggerAssistant {
+	r.resolver.cache = state.cache
+	r.observations = state.observations
+	return r
+}
+
 // CoreDNSExposedIPs retrieves list of IP's exposed by CoreDNS
 func (r *GslbLoggerAssistant) CoreDNSExposedIPs() ([]string, error) {
 	coreDNSService := &corev1.Service{}
@@ -78,7 +144,7 @@ func (r *GslbLoggerAssistant) CoreDNSExposedIPs() ([]string, error) {
 		err := coreerrors.New(errMessage)
 		return nil, err
 	}
-	IPs, err := utils.Dig(r.edgeDNSServer, lbHostname)
+	IPs, err := utils.Dig(r.edgeDNSServer, lbHostname, r.tlsConfig)
 	if err != nil {
 		r.Info("Can't dig k8gb-coredns-lb service loadbalancer fqdn %s (%s)", lbHostname, err)
 		return nil, err
@@ -110,7 +176,7 @@ func (r *GslbLoggerAssistant) GslbIngressExposedIPs(gslb *k8gbv1beta1.Gslb) ([]s
 			gslbIngressIPs = append(gslbIngressIPs, ip.IP)
 		}
 		if len(ip.Hostname) > 0 {
-			IPs, err := utils.Dig(r.edgeDNSServer, ip.Hostname)
+			IPs, err := utils.Dig(r.edgeDNSServer, ip.Hostname, r.tlsConfig)
 			if err != nil {
 				r.Info("Dig error: %s", err)
 				return nil, err
@@ -162,6 +228,35 @@ func (r *GslbLoggerAssistant) SaveDNSEndpoint(namespace string, i *externaldns.D
 	return nil
 }
 
+// ClusterPeers retrieves ClusterPeer resources describing external clusters
+func (r *GslbLoggerAssistant) ClusterPeers() ([]k8gbv1beta1.ClusterPeer, error) {
+	peerList := &k8gbv1beta1.ClusterPeerList{}
+	err := r.client.List(context.TODO(), peerList)
+	if err != nil {
+		return nil, err
+	}
+	return peerList.Items, nil
+}
+
+// HealthyGslbs counts Gslbs of all namespaces having at least one healthy service
+func (r *GslbLoggerAssistant) HealthyGslbs() (int, error) {
+	gslbList := &k8gbv1beta1.GslbList{}
+	err := r.client.List(context.TODO(), gslbList)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	for _, gslb := range gslbList.Items {
+		for _, health := range gslb.Status.ServiceHealth {
+			if health == "Healthy" {
+				count++
+				break
+			}
+		}
+	}
+	return count, nil
+}
+
 // RemoveEndpoint removes endpoint
 func (r *GslbLoggerAssistant) RemoveEndpoint(endpointName string) error {
 	r.Info("Removing endpoint %s.%s", r.k8gbNamespace, endpointName)
@@ -178,76 +273,100 @@ func (r *GslbLoggerAssistant) RemoveEndpoint(endpointName string) error {
 	return err
 }
 
-// InspectTXTThreshold inspects fqdn TXT record from edgeDNSServer. If record doesn't exists or timestamp is greater than
-// splitBrainThreshold the error is returned. In case fakeDNSEnabled is true, 127.0.0.1:7753 is used as edgeDNSServer
-func (r *GslbLoggerAssistant) InspectTXTThreshold(fqdn string, fakeDNSEnabled bool, splitBrainThreshold time.Duration) error {
+// InspectTXTThreshold inspects fqdn TXT record of cluster geoTag from edgeDNSServer. If record doesn't exists, timestamp is
+// greater than splitBrainThreshold, the heartbeat was written by other cluster or announces draining cluster, the error
+// is returned. Legacy heartbeat without geo tag is accepted. In case fakeDNSEnabled is true, 127.0.0.1:7753 is used as
+// edgeDNSServer
+func (r *GslbLoggerAssistant) InspectTXTThreshold(fqdn, geoTag string, fakeDNSEnabled bool, splitBrainThreshold time.Duration) error {
 	m := new(dns.Msg)
 	m.SetQuestion(dns.Fqdn(fqdn), dns.TypeTXT)
-	ns := overrideWithFakeDNS(fakeDNSEnabled, r.edgeDNSServer)
-	txt, err := dns.Exchange(m, ns)
+	ns, transport := r.dnsServer(fakeDNSEnabled, r.edgeDNSServer)
+	transport.Timeout = defaultPeerQueryTimeout
+	txt, err := utils.Exchange(m, ns, transport)
 	if err != nil {
 		r.Info("Error contacting EdgeDNS server (%s) for TXT split brain record: (%s)", ns, err)
 		return err
 	}
-	var timestamp string
+	r.observations.observeHeartbeat(fqdn, time.Time{})
+	var payload string
 	if len(txt.Answer) > 0 {
 		if t, ok := txt.Answer[0].(*dns.TXT); ok {
 			r.Info("Split brain TXT raw record: %s", t.String())
-			timestamp = strings.Split(t.String(), "\t")[4]
-			timestamp = strings.Trim(timestamp, "\"") // Otherwise time.Parse() will miserably fail
+			payload, err = utils.VerifyHeartbeat(strings.Join(t.Txt, ""), fqdn, geoTag, r.heartbeatSecret)
+			if err != nil {
+				r.resolver.observeAuthFailure(fqdn, heartbeatAuthFailure)
+				return err
+			}
 		}
 	}
 
-	if len(timestamp) > 0 {
-		r.Info("Split brain TXT raw time stamp: %s", timestamp)
-		timeFromTXT, err := time.Parse("2006-01-02T15:04:05", timestamp)
+	if len(payload) > 0 {
+		heartbeat, err := utils.ParseHeartbeat(payload)
 		if err != nil {
 			return err
 		}
-
-		r.Info("Split brain TXT parsed time stamp: %s", timeFromTXT)
+		r.Info("Split brain TXT heartbeat: version=%d, time stamp=%s, k8gb=%s, geo=%s, draining=%t, healthy Gslbs=%d, capacity=%d",
+			heartbeat.Version, heartbeat.Timestamp, heartbeat.OperatorVersion, heartbeat.GeoTag, heartbeat.Draining,
+			heartbeat.HealthyGslbs, heartbeat.Capacity)
+		if heartbeat.Version > 0 && heartbeat.GeoTag != geoTag {
+			r.resolver.observeAuthFailure(fqdn, heartbeatAuthFailure)
+			return fmt.Errorf("%w: split brain TXT record %s of cluster %s was written by cluster %s", utils.ErrUnauthenticated,
+				fqdn, geoTag, heartbeat.GeoTag)
+		}
+		r.observations.observeHeartbeat(fqdn, heartbeat.Timestamp)
 		now := time.Now().UTC()
 
-		diff := now.Sub(timeFromTXT)
+		diff := now.Sub(heartbeat.Timestamp)
 		r.Info("Split brain TXT time diff: %s", diff)
 
 		if diff > splitBrainThreshold {
 			return errors.NewResourceExpired(fmt.Sprintf("Split brain TXT record expired the time threshold: (%s)", splitBrainThreshold))
 		}
+		if heartbeat.Draining {
+			return errors.NewServiceUnavailable(fmt.Sprintf("Cluster of split brain TXT record %s is draining", fqdn))
+		}
 		return nil
 	}
 	return errors.NewResourceExpired(fmt.Sprintf("Can't find split brain TXT record at EdgeDNS server(%s) and record %s ", ns, fqdn))
 }
 
-func (r *GslbLoggerAssistant) GetExternalTargets(host string, fakeDNSEnabled bool, extGslbClusters []string) (targets []string) {
+// GetExternalTargets queries all external clusters concurrently. Clusters which can't be contacted are logged
+// and skipped, so targets of the remaining clusters are still returned. Clusters listed in statusEndpoints are
+// asked by their peer status API first
+func (r *GslbLoggerAssistant) GetExternalTargets(host string, fakeDNSEnabled bool, extGslbClusters []string,
+	statusEndpoints map[string]string) (targets []string) {
 	targets = []string{}
-	for _, cluster := range extGslbClusters {
-		r.Info("Adding external Gslb targets from %s cluster...", cluster)
-		g := new(dns.Msg)
-		host = fmt.Sprintf("localtargets-%s.", host) // Convert to true FQDN with dot at the end. Otherwise dns lib freaks out
-		g.SetQuestion(host, dns.TypeA)
-
-		ns := overrideWithFakeDNS(fakeDNSEnabled, cluster)
-
-		a, err := dns.Exchange(g, ns)
-		if err != nil {
-			r.Info("Error contacting external Gslb cluster(%s) : (%v)", cluster, err)
-			return
+	results := r.resolver.resolve(host, extGslbClusters, func(cluster string) peerServer {
+		addr, transport := r.dnsServer(fakeDNSEnabled, cluster)
+		if !fakeDNSEnabled {
+			transport.TSIG = r.tsig
 		}
-		var clusterTargets []string
-
-		for _, A := range a.Answer {
-			IP := strings.Split(A.String(), "\t")[4]
-			clusterTargets = append(clusterTargets, IP)
+		return peerServer{addr: addr, transport: transport, statusEndpoint: statusEndpoints[cluster]}
+	})
+	for _, result := range results {
+		r.observations.observeTargets(result.peer, host, result.targets, result.err)
+		if result.statusErr != nil {
+			r.Info("Can't read targets from peer status API of external Gslb cluster(%s), falling back to DNS : (%v)",
+				result.peer, result.statusErr)
+		}
+		if result.err != nil {
+			r.Info("Error contacting external Gslb cluster(%s) : (%v)", result.peer, result.err)
+			continue
 		}
-		if len(clusterTargets) > 0 {
-			targets = append(targets, clusterTargets...)
-			r.Info("Added external %s Gslb targets from %s cluster", clusterTargets, cluster)
+		if len(result.targets) > 0 {
+			targets = append(targets, result.targets...)
+			r.Info("Added external %s Gslb targets from %s cluster", result.targets, result.peer)
 		}
 	}
 	return
 }
 
+// ExternalClusterStatus describes external cluster queried on address as observed by the last GetExternalTargets
+// of hosts and the last InspectTXTThreshold of heartbeatFQDN
+func (r *GslbLoggerAssistant) ExternalClusterStatus(geoTag, address, heartbeatFQDN string, hosts []string) k8gbv1beta1.PeerStatus {
+	return r.observations.status(geoTag, address, heartbeatFQDN, hosts)
+}
+
 // Info wraps private logger and provides log.Info()
 func (r *GslbLoggerAssistant) Info(msg string, args ...interface{}) {
 	r.log.Info(fmt.Sprintf(msg, args...))
@@ -258,11 +377,16 @@ func (r *GslbLoggerAssistant) Error(err error, msg string, args ...interface{})
 	r.log.Error(err, fmt.Sprintf(msg, args...))
 }
 
-func overrideWithFakeDNS(fakeDNSEnabled bool, server string) (ns string) {
+// Event records Kubernetes event of object unless it repeats the last event about the same subject
+func (r *GslbLoggerAssistant) Event(object runtime.Object, subject, eventType, reason, message string) {
+	r.events.Event(object, subject, eventType, reason, message)
+}
+
+// dnsServer returns address and transport of DNS server. In case fakeDNSEnabled is true, 127.0.0.1:7753
+// is queried over plain DNS
+func (r *GslbLoggerAssistant) dnsServer(fakeDNSEnabled bool, server string) (string, utils.DNSTransport) {
 	if fakeDNSEnabled {
-		ns = "127.0.0.1:7753"
-	} else {
-		ns = fmt.Sprintf("%s:53", server)
+		return "127.0.0.1:7753", utils.DNSTransport{}
 	}
-	return
+	return utils.DNSAddress(server, r.tlsConfig), utils.DNSTransport{TLS: r.tlsConfig}
 }
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
 	externaldns "sigs.k8s.io/external-dns/endpoint"
 )
 
@@ -28,10 +29,18 @@ type IAssistant interface {
 	CoreDNSExposedIPs() ([]string, error)
 	// GslbIngressExposedIPs retrieves list of IP's exposed by all GSLB ingresses
 	GslbIngressExposedIPs(gslb *k8gbv1beta1.Gslb) ([]string, error)
-	// GetExternalTargets retrieves slice of targets from external clusters
-	GetExternalTargets(host string, fakeDNSEnabled bool, extGslbClusters []string) (targets []string)
+	// GetExternalTargets retrieves slice of targets from external clusters. statusEndpoints maps external clusters
+	// to URLs of their peer status API, which is preferred over DNS
+	GetExternalTargets(host string, fakeDNSEnabled bool, extGslbClusters []string, statusEndpoints map[string]string) (targets []string)
+	// ExternalClusterStatus describes external cluster queried on address as observed by the last GetExternalTargets
+	// of hosts and the last InspectTXTThreshold of heartbeatFQDN
+	ExternalClusterStatus(geoTag, address, heartbeatFQDN string, hosts []string) k8gbv1beta1.PeerStatus
 	// SaveDNSEndpoint update DNS endpoint or create new one if doesnt exist
 	SaveDNSEndpoint(namespace string, i *externaldns.DNSEndpoint) error
+	// ClusterPeers retrieves ClusterPeer resources describing external clusters
+	ClusterPeers() ([]k8gbv1beta1.ClusterPeer, error)
+	// HealthyGslbs counts Gslbs of all namespaces having at least one healthy service
+	HealthyGslbs() (int, error)
 	// RemoveEndpoint removes endpoint
 	RemoveEndpoint(endpointName string) error
 	// Info wraps private logger and provides log.Error()
@@ -40,7 +49,11 @@ type IAssistant interface {
 	// Error wraps private logger and provides log.Info()
 	// TODO: extract logging functions outside
 	Error(err error, msg string, args ...interface{})
-	// InspectTXTThreshold inspects fqdn TXT record from edgeDNSServer. If record doesn't exists or timestamp is greater than
-	// splitBrainThreshold the error is returned. In case fakeDNSEnabled is true, 127.0.0.1:7753 is used as edgeDNSServer
-	InspectTXTThreshold(fqdn string, fakeDNSEnabled bool, splitBrainThreshold time.Duration) error
+	// Event records Kubernetes event of object unless it repeats the last event about the same subject,
+	// e.g. external cluster
+	Event(object runtime.Object, subject, eventType, reason, message string)
+	// InspectTXTThreshold inspects fqdn TXT record of cluster geoTag from edgeDNSServer. If record doesn't exists, timestamp is
+	// greater than splitBrainThreshold, the heartbeat was written by other cluster or announces draining cluster, the error
+	// is returned. In case fakeDNSEnabled is true, 127.0.0.1:7753 is used as edgeDNSServer
+	InspectTXTThreshold(fqdn, geoTag string, fakeDNSEnabled bool, splitBrainThreshold time.Duration) error
 }
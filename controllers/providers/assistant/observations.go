@@ -0,0 +1,90 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assistant
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type observedTargets struct {
+	targets []string
+	err     error
+}
+
+// peerObservations remember the last answers of external clusters, so they can be reported in Gslb status.
+// Targets are indexed by cluster address and host, heartbeats by FQDN of the TXT record. Reported targets are
+// sorted, so the status doesn't change with the order of answers
+type peerObservations struct {
+	mu         sync.Mutex
+	targets    map[string]observedTargets
+	heartbeats map[string]time.Time
+}
+
+func newPeerObservations() *peerObservations {
+	return &peerObservations{
+		targets:    make(map[string]observedTargets),
+		heartbeats: make(map[string]time.Time),
+	}
+}
+
+func (o *peerObservations) observeTargets(cluster, host string, targets []string, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.targets[cluster+"/"+host] = observedTargets{targets: targets, err: err}
+}
+
+// observeHeartbeat remembers time stamp of heartbeat; zero time stamp forgets the heartbeat
+func (o *peerObservations) observeHeartbeat(fqdn string, timestamp time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if timestamp.IsZero() {
+		delete(o.heartbeats, fqdn)
+		return
+	}
+	o.heartbeats[fqdn] = timestamp
+}
+
+func (o *peerObservations) status(geoTag, cluster, heartbeatFQDN string, hosts []string) k8gbv1beta1.PeerStatus {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	status := k8gbv1beta1.PeerStatus{GeoTag: geoTag}
+	for _, host := range hosts {
+		observed, found := o.targets[cluster+"/"+host]
+		if !found || observed.err != nil {
+			continue
+		}
+		status.Reachable = true
+		if len(observed.targets) > 0 {
+			if status.Targets == nil {
+				status.Targets = make(map[string][]string)
+			}
+			targets := append([]string{}, observed.targets...)
+			sort.Strings(targets)
+			status.Targets[host] = targets
+		}
+	}
+	if timestamp, found := o.heartbeats[heartbeatFQDN]; found {
+		lastHeartbeat := metav1.NewTime(timestamp)
+		status.LastHeartbeat = &lastHeartbeat
+	}
+	return status
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assistant
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const heartbeatFQDN = "test-gslb-heartbeat-eu.example.com"
+
+func TestObservationsReportTargetsAndLastHeartbeat(t *testing.T) {
+	// arrange
+	heartbeat := time.Date(2021, 3, 1, 10, 0, 25, 0, time.UTC)
+	o := newPeerObservations()
+	o.observeTargets("eu", "app.cloud.example.com", []string{"10.0.0.2", "10.0.0.1"}, nil)
+	o.observeTargets("eu", "web.cloud.example.com", nil, nil)
+	o.observeTargets("za", "app.cloud.example.com", []string{"10.1.0.1"}, nil)
+	o.observeHeartbeat(heartbeatFQDN, heartbeat)
+	// act
+	status := o.status("eu", "eu", heartbeatFQDN, []string{"app.cloud.example.com", "web.cloud.example.com"})
+	// assert
+	lastHeartbeat := metav1.NewTime(heartbeat)
+	assert.Equal(t, k8gbv1beta1.PeerStatus{GeoTag: "eu", Reachable: true, LastHeartbeat: &lastHeartbeat,
+		Targets: map[string][]string{"app.cloud.example.com": {"10.0.0.1", "10.0.0.2"}}}, status)
+}
+
+func TestObservationsReportUnreachableCluster(t *testing.T) {
+	// arrange
+	o := newPeerObservations()
+	o.observeTargets("eu", "app.cloud.example.com", nil, errors.New("i/o timeout"))
+	// act
+	failed := o.status("eu", "eu", heartbeatFQDN, []string{"app.cloud.example.com"})
+	unknown := o.status("za", "za", "test-gslb-heartbeat-za.example.com", []string{"app.cloud.example.com"})
+	// assert
+	assert.Equal(t, k8gbv1beta1.PeerStatus{GeoTag: "eu"}, failed)
+	assert.Equal(t, k8gbv1beta1.PeerStatus{GeoTag: "za"}, unknown)
+}
+
+func TestObservationsForgetMissingHeartbeat(t *testing.T) {
+	// arrange
+	o := newPeerObservations()
+	o.observeHeartbeat(heartbeatFQDN, time.Now())
+	// act
+	o.observeHeartbeat(heartbeatFQDN, time.Time{})
+	status := o.status("eu", "eu", heartbeatFQDN, nil)
+	// assert
+	assert.Nil(t, status.LastHeartbeat)
+}
+
+func TestPeerStateIsTakenOverByRecreatedAssistant(t *testing.T) {
+	// arrange
+	state := NewPeerState()
+	previous := NewGslbAssistant(nil, nil, "k8gb", "").WithPeerState(state)
+	previous.resolver.store("eu/app.cloud.example.com", []string{"10.0.0.1"}, 30)
+	previous.observations.observeTargets("eu", "app.cloud.example.com", []string{"10.0.0.1"}, nil)
+	// act
+	recreated := NewGslbAssistant(nil, nil, "k8gb", "").WithPeerState(state)
+	// assert
+	targets, found := recreated.resolver.cached("eu/app.cloud.example.com")
+	assert.True(t, found)
+	assert.Equal(t, []string{"10.0.0.1"}, targets)
+	assert.True(t, recreated.observations.status("eu", "eu", heartbeatFQDN, []string{"app.cloud.example.com"}).Reachable)
+}
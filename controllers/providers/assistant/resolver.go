@@ -0,0 +1,210 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assistant
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
+	"github.com/AbsaOSS/k8gb/controllers/peerstatus"
+	"github.com/miekg/dns"
+)
+
+// defaultPeerQueryTimeout bounds single DNS query to external cluster, so unreachable peer can't block
+// the reconciliation
+const defaultPeerQueryTimeout = 2 * time.Second
+
+// Reasons of rejected data received from external clusters
+const (
+	tsigAuthFailure       = "tsig"
+	heartbeatAuthFailure  = "heartbeat"
+	peerStatusAuthFailure = "status"
+)
+
+// PeerQueryObserver is notified about every DNS or status API query sent to external cluster and about data
+// of external clusters rejected by authentication
+type PeerQueryObserver interface {
+	ObservePeerQuery(peer string, duration time.Duration, err error)
+	ObservePeerAuthFailure(peer, reason string)
+}
+
+// peerServer describes how external cluster is queried
+type peerServer struct {
+	// addr and transport of peer's DNS server; the timeout of transport is set by resolver
+	addr      string
+	transport utils.DNSTransport
+	// statusEndpoint of peer status API queried before the DNS server; API isn't used when empty
+	statusEndpoint string
+}
+
+// peerTargets are targets of single host resolved in external cluster. statusErr is set when peer status API
+// failed and targets were resolved by DNS
+type peerTargets struct {
+	peer      string
+	targets   []string
+	err       error
+	statusErr error
+}
+
+type cachedTargets struct {
+	targets []string
+	expires time.Time
+}
+
+// targetResolver queries external clusters concurrently and caches answers for the TTL of returned records.
+// Single instance is shared by all Gslbs, so the same host is not resolved again until its records expire
+type targetResolver struct {
+	timeout  time.Duration
+	observer PeerQueryObserver
+	status   *peerstatus.Client
+	now      func() time.Time
+	cache    *targetCache
+}
+
+// targetCache holds answers of external clusters per peer and host
+type targetCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedTargets
+}
+
+func newTargetResolver(timeout time.Duration) *targetResolver {
+	return &targetResolver{
+		timeout: timeout,
+		now:     time.Now,
+		cache:   newTargetCache(),
+	}
+}
+
+func newTargetCache() *targetCache {
+	return &targetCache{entries: make(map[string]cachedTargets)}
+}
+
+// resolve retrieves targets of localtargets-<host> from all peers. Results keep the order of peers; peer which
+// can't be contacted has err set and doesn't affect results of others. server describes how the peer is queried
+func (t *targetResolver) resolve(host string, peers []string, server func(peer string) peerServer) []peerTargets {
+	results := make([]peerTargets, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(i int, peer string) {
+			defer wg.Done()
+			results[i] = t.resolvePeer(host, peer, server(peer))
+		}(i, peer)
+	}
+	wg.Wait()
+	return results
+}
+
+// resolvePeer reads targets from peer status API if it is configured and falls back to DNS when the API fails
+func (t *targetResolver) resolvePeer(host, peer string, server peerServer) (result peerTargets) {
+	result.peer = peer
+	key := peer + "/" + host
+	if targets, found := t.cached(key); found {
+		result.targets = targets
+		return
+	}
+	var ttl uint32
+	if server.statusEndpoint != "" && t.status != nil {
+		result.targets, ttl, result.statusErr = t.queryStatus(host, peer, server.statusEndpoint)
+		if result.statusErr == nil {
+			t.store(key, result.targets, ttl)
+			return
+		}
+	}
+	server.transport.Timeout = t.timeout
+	result.targets, ttl, result.err = t.queryDNS(host, peer, server.addr, server.transport)
+	if result.err == nil {
+		t.store(key, result.targets, ttl)
+	}
+	return
+}
+
+func (t *targetResolver) queryStatus(host, peer, endpoint string) ([]string, uint32, error) {
+	start := t.now()
+	response, err := t.status.Targets(endpoint, host)
+	t.observeQuery(peer, start, err)
+	if errors.Is(err, peerstatus.ErrUnauthorized) {
+		t.observeAuthFailure(peer, peerStatusAuthFailure)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return response.Targets, uint32(response.TTL), nil
+}
+
+func (t *targetResolver) queryDNS(host, peer, addr string, transport utils.DNSTransport) ([]string, uint32, error) {
+	g := new(dns.Msg)
+	g.SetQuestion(fmt.Sprintf("localtargets-%s.", host), dns.TypeA) // True FQDN with dot at the end. Otherwise dns lib freaks out
+	start := t.now()
+	a, err := utils.Exchange(g, addr, transport)
+	t.observeQuery(peer, start, err)
+	if errors.Is(err, utils.ErrUnauthenticated) {
+		t.observeAuthFailure(peer, tsigAuthFailure)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	var targets []string
+	var ttl uint32
+	for i, A := range a.Answer {
+		targets = append(targets, strings.Split(A.String(), "\t")[4])
+		if i == 0 || A.Header().Ttl < ttl {
+			ttl = A.Header().Ttl
+		}
+	}
+	return targets, ttl, nil
+}
+
+// store caches targets for ttl seconds; targets with zero ttl are not cached
+func (t *targetResolver) store(key string, targets []string, ttl uint32) {
+	if ttl == 0 {
+		return
+	}
+	t.cache.mu.Lock()
+	t.cache.entries[key] = cachedTargets{targets: targets, expires: t.now().Add(time.Duration(ttl) * time.Second)}
+	t.cache.mu.Unlock()
+}
+
+func (t *targetResolver) observeQuery(peer string, start time.Time, err error) {
+	if t.observer != nil {
+		t.observer.ObservePeerQuery(peer, t.now().Sub(start), err)
+	}
+}
+
+func (t *targetResolver) observeAuthFailure(peer, reason string) {
+	if t.observer != nil {
+		t.observer.ObservePeerAuthFailure(peer, reason)
+	}
+}
+
+func (t *targetResolver) cached(key string) ([]string, bool) {
+	t.cache.mu.Lock()
+	defer t.cache.mu.Unlock()
+	entry, found := t.cache.entries[key]
+	if !found {
+		return nil, false
+	}
+	if !t.now().Before(entry.expires) {
+		delete(t.cache.entries, key)
+		return nil, false
+	}
+	return entry.targets, true
+}
@@ -0,0 +1,300 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assistant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
+	"github.com/AbsaOSS/k8gb/controllers/peerstatus"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHost = "roundrobin.cloud.example.com"
+
+// recordingObserver stores observed peer queries
+type recordingObserver struct {
+	sync.Mutex
+	errors       map[string]int
+	total        map[string]int
+	authFailures map[string]string
+}
+
+func (o *recordingObserver) ObservePeerQuery(peer string, _ time.Duration, err error) {
+	o.Lock()
+	defer o.Unlock()
+	o.total[peer]++
+	if err != nil {
+		o.errors[peer]++
+	}
+}
+
+func (o *recordingObserver) ObservePeerAuthFailure(peer, reason string) {
+	o.Lock()
+	defer o.Unlock()
+	o.authFailures[peer] = reason
+}
+
+func TestResolverReturnsTargetsOfAllPeersInOrder(t *testing.T) {
+	// arrange
+	eu, _ := startPeer(t, 30, "10.0.0.1", "10.0.0.2")
+	za, _ := startPeer(t, 30, "10.1.0.1")
+	peers := map[string]string{"eu": eu, "za": za}
+	r := newTargetResolver(time.Second)
+	// act
+	results := r.resolve(testHost, []string{"za", "eu"}, plain(peers))
+	// assert
+	require.Len(t, results, 2)
+	assert.Equal(t, peerTargets{peer: "za", targets: []string{"10.1.0.1"}}, results[0])
+	assert.Equal(t, peerTargets{peer: "eu", targets: []string{"10.0.0.1", "10.0.0.2"}}, results[1])
+}
+
+func TestResolverReturnsPartialResultsWhenPeerIsUnreachable(t *testing.T) {
+	// arrange
+	eu, _ := startPeer(t, 30, "10.0.0.1")
+	peers := map[string]string{"eu": eu, "us": silentPeer(t)}
+	observer := &recordingObserver{errors: map[string]int{}, total: map[string]int{}, authFailures: map[string]string{}}
+	r := newTargetResolver(200 * time.Millisecond)
+	r.observer = observer
+	// act
+	results := r.resolve(testHost, []string{"us", "eu"}, plain(peers))
+	// assert
+	require.Len(t, results, 2)
+	assert.Error(t, results[0].err)
+	assert.Empty(t, results[0].targets)
+	assert.NoError(t, results[1].err)
+	assert.Equal(t, []string{"10.0.0.1"}, results[1].targets)
+	assert.Equal(t, map[string]int{"us": 1, "eu": 1}, observer.total)
+	assert.Equal(t, map[string]int{"us": 1}, observer.errors)
+	assert.Empty(t, observer.authFailures)
+}
+
+func TestResolverRejectsPeerAnswerWithoutTSIG(t *testing.T) {
+	// arrange
+	eu, _ := startPeer(t, 30, "10.0.0.1")
+	observer := &recordingObserver{errors: map[string]int{}, total: map[string]int{}, authFailures: map[string]string{}}
+	r := newTargetResolver(time.Second)
+	r.observer = observer
+	tsig := utils.NewTSIG("k8gb-peers", "c2VjcmV0LXNoYXJlZC1ieS1hbGwtY2x1c3RlcnM=", dns.HmacSHA256)
+	// act
+	results := r.resolve(testHost, []string{"eu"}, func(string) peerServer {
+		return peerServer{addr: eu, transport: utils.DNSTransport{TSIG: tsig}}
+	})
+	// assert
+	require.Len(t, results, 1)
+	assert.True(t, errors.Is(results[0].err, utils.ErrUnauthenticated))
+	assert.Empty(t, results[0].targets)
+	assert.Equal(t, map[string]string{"eu": tsigAuthFailure}, observer.authFailures)
+}
+
+func TestResolverReportsPeerAnsweringErrorRcode(t *testing.T) {
+	// arrange
+	eu, _ := startPeer(t, 30, "10.0.0.1")
+	peers := map[string]string{"eu": eu, "us": failingPeer(t, dns.RcodeServerFailure), "za": failingPeer(t, dns.RcodeNameError)}
+	r := newTargetResolver(time.Second)
+	// act
+	results := r.resolve(testHost, []string{"us", "za", "eu"}, plain(peers))
+	// assert
+	require.Len(t, results, 3)
+	assert.Error(t, results[0].err)
+	assert.Error(t, results[1].err)
+	assert.Equal(t, peerTargets{peer: "eu", targets: []string{"10.0.0.1"}}, results[2])
+}
+
+func TestResolverQueriesPeersConcurrently(t *testing.T) {
+	// arrange
+	const timeout = 300 * time.Millisecond
+	peers := map[string]string{"us": silentPeer(t), "za": silentPeer(t), "eu": silentPeer(t)}
+	r := newTargetResolver(timeout)
+	start := time.Now()
+	// act
+	results := r.resolve(testHost, []string{"us", "za", "eu"}, plain(peers))
+	// assert
+	assert.Len(t, results, 3)
+	assert.Less(t, int64(time.Since(start)), int64(2*timeout))
+}
+
+func TestResolverCachesTargetsForTTL(t *testing.T) {
+	// arrange
+	eu, queries := startPeer(t, 30, "10.0.0.1")
+	now := time.Now()
+	r := newTargetResolver(time.Second)
+	r.now = func() time.Time { return now }
+	address := plain(map[string]string{"eu": eu})
+	// act
+	first := r.resolve(testHost, []string{"eu"}, address)
+	now = now.Add(29 * time.Second)
+	cached := r.resolve(testHost, []string{"eu"}, address)
+	cachedQueries := atomic.LoadInt32(queries)
+	now = now.Add(time.Second)
+	expired := r.resolve(testHost, []string{"eu"}, address)
+	// assert
+	assert.Equal(t, first, cached)
+	assert.Equal(t, first, expired)
+	assert.Equal(t, int32(1), cachedQueries)
+	assert.Equal(t, int32(2), atomic.LoadInt32(queries))
+}
+
+func TestResolverDoesNotCacheZeroTTL(t *testing.T) {
+	// arrange
+	eu, queries := startPeer(t, 0, "10.0.0.1")
+	r := newTargetResolver(time.Second)
+	address := plain(map[string]string{"eu": eu})
+	// act
+	r.resolve(testHost, []string{"eu"}, address)
+	r.resolve(testHost, []string{"eu"}, address)
+	// assert
+	assert.Equal(t, int32(2), atomic.LoadInt32(queries))
+}
+
+func TestResolverPrefersPeerStatusAPI(t *testing.T) {
+	// arrange
+	eu, queries := startPeer(t, 30, "10.0.0.1")
+	api := startStatusAPI(t, http.StatusOK, peerstatus.TargetsResponse{Host: testHost, Targets: []string{"10.0.0.1", "10.0.0.2"}, TTL: 30})
+	observer := &recordingObserver{errors: map[string]int{}, total: map[string]int{}, authFailures: map[string]string{}}
+	r := newTargetResolver(time.Second)
+	r.observer = observer
+	r.status = newTestStatusClient(api)
+	// act
+	results := r.resolve(testHost, []string{"eu"}, withStatus(map[string]string{"eu": eu}, api.URL))
+	// assert
+	require.Len(t, results, 1)
+	assert.Equal(t, peerTargets{peer: "eu", targets: []string{"10.0.0.1", "10.0.0.2"}}, results[0])
+	assert.Equal(t, int32(0), atomic.LoadInt32(queries))
+	assert.Equal(t, map[string]int{"eu": 1}, observer.total)
+}
+
+func TestResolverFallsBackToDNSWhenPeerStatusAPIIsUnreachable(t *testing.T) {
+	// arrange
+	eu, queries := startPeer(t, 30, "10.0.0.1")
+	api := startStatusAPI(t, http.StatusOK, peerstatus.TargetsResponse{Host: testHost, Targets: []string{"10.9.9.9"}})
+	observer := &recordingObserver{errors: map[string]int{}, total: map[string]int{}, authFailures: map[string]string{}}
+	r := newTargetResolver(time.Second)
+	r.observer = observer
+	r.status = newTestStatusClient(api)
+	api.Close()
+	// act
+	results := r.resolve(testHost, []string{"eu"}, withStatus(map[string]string{"eu": eu}, api.URL))
+	// assert
+	require.Len(t, results, 1)
+	assert.NoError(t, results[0].err)
+	assert.Error(t, results[0].statusErr)
+	assert.Equal(t, []string{"10.0.0.1"}, results[0].targets)
+	assert.Equal(t, int32(1), atomic.LoadInt32(queries))
+	assert.Equal(t, map[string]int{"eu": 2}, observer.total)
+	assert.Equal(t, map[string]int{"eu": 1}, observer.errors)
+}
+
+func TestResolverReportsRejectedPeerStatusToken(t *testing.T) {
+	// arrange
+	eu, _ := startPeer(t, 30, "10.0.0.1")
+	api := startStatusAPI(t, http.StatusUnauthorized, peerstatus.ErrorResponse{Error: "missing or invalid token"})
+	observer := &recordingObserver{errors: map[string]int{}, total: map[string]int{}, authFailures: map[string]string{}}
+	r := newTargetResolver(time.Second)
+	r.observer = observer
+	r.status = newTestStatusClient(api)
+	// act
+	results := r.resolve(testHost, []string{"eu"}, withStatus(map[string]string{"eu": eu}, api.URL))
+	// assert
+	require.Len(t, results, 1)
+	assert.True(t, errors.Is(results[0].statusErr, peerstatus.ErrUnauthorized))
+	assert.Equal(t, []string{"10.0.0.1"}, results[0].targets)
+	assert.Equal(t, map[string]string{"eu": peerStatusAuthFailure}, observer.authFailures)
+}
+
+// plain returns peer addresses queried over plain DNS
+func plain(peers map[string]string) func(string) peerServer {
+	return func(peer string) peerServer {
+		return peerServer{addr: peers[peer]}
+	}
+}
+
+// withStatus returns peer addresses queried over plain DNS having peer status API on endpoint
+func withStatus(peers map[string]string, endpoint string) func(string) peerServer {
+	return func(peer string) peerServer {
+		return peerServer{addr: peers[peer], statusEndpoint: endpoint}
+	}
+}
+
+// startStatusAPI runs HTTPS server answering every request with given status and body
+func startStatusAPI(t *testing.T, status int, body interface{}) *httptest.Server {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestStatusClient(server *httptest.Server) *peerstatus.Client {
+	return peerstatus.NewClient("token", server.Client().Transport.(*http.Transport).TLSClientConfig, time.Second)
+}
+
+// startPeer runs DNS server answering A records of every question with given IPs
+func startPeer(t *testing.T, ttl uint32, ips ...string) (addr string, queries *int32) {
+	queries = new(int32)
+	addr = servePeer(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		atomic.AddInt32(queries, 1)
+		m := new(dns.Msg)
+		m.SetReply(req)
+		for _, ip := range ips {
+			rr, _ := dns.NewRR(fmt.Sprintf("%s %d IN A %s", req.Question[0].Name, ttl, ip))
+			m.Answer = append(m.Answer, rr)
+		}
+		_ = w.WriteMsg(m)
+	})
+	return
+}
+
+// failingPeer runs DNS server answering every question with given rcode
+func failingPeer(t *testing.T, rcode int) string {
+	return servePeer(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetRcode(req, rcode)
+		_ = w.WriteMsg(m)
+	})
+}
+
+func servePeer(t *testing.T, handler dns.HandlerFunc) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	started := make(chan struct{})
+	server := &dns.Server{PacketConn: pc, Handler: handler, NotifyStartedFunc: func() { close(started) }}
+	go func() { _ = server.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return pc.LocalAddr().String()
+}
+
+// silentPeer returns address which accepts queries but never answers
+func silentPeer(t *testing.T) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pc.Close() })
+	return pc.LocalAddr().String()
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cloudflareAPIEndpoint = "https://api.cloudflare.com/client/v4"
+
+// cloudflarePageSize is the maximal number of records listed by single request
+const cloudflarePageSize = 100
+
+const (
+	// cloudflareAutomaticTTL lets Cloudflare choose TTL of the record
+	cloudflareAutomaticTTL = 1
+	// cloudflareMinTTL is the lowest TTL other than automatic accepted by Cloudflare outside Enterprise zones
+	cloudflareMinTTL = 60
+)
+
+// cloudflareRecord is DNS record as defined by Cloudflare API v4
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+}
+
+type cloudflareError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type cloudflareResultInfo struct {
+	Page       int `json:"page"`
+	TotalPages int `json:"total_pages"`
+}
+
+type cloudflareResponse struct {
+	Success    bool                  `json:"success"`
+	Errors     []cloudflareError     `json:"errors"`
+	Result     json.RawMessage       `json:"result"`
+	ResultInfo *cloudflareResultInfo `json:"result_info"`
+}
+
+// cloudflareClient is minimal client of Cloudflare DNS records API
+type cloudflareClient struct {
+	endpoint string
+	zoneID   string
+	token    string
+	http     *http.Client
+}
+
+func newCloudflareClient(endpoint, zoneID, token string) *cloudflareClient {
+	return &cloudflareClient{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		zoneID:   zoneID,
+		token:    token,
+		http:     &http.Client{Timeout: 20 * time.Second},
+	}
+}
+
+// listRecords returns records of given type and name from all pages
+func (c *cloudflareClient) listRecords(recordType, name string) (records []cloudflareRecord, err error) {
+	query := url.Values{}
+	query.Set("type", recordType)
+	query.Set("name", name)
+	query.Set("per_page", strconv.Itoa(cloudflarePageSize))
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+		var pageRecords []cloudflareRecord
+		info, err := c.request(http.MethodGet, fmt.Sprintf("/zones/%s/dns_records?%s", c.zoneID, query.Encode()), nil, &pageRecords)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, pageRecords...)
+		if info == nil || page >= info.TotalPages {
+			return records, nil
+		}
+	}
+}
+
+func (c *cloudflareClient) createRecord(record cloudflareRecord) error {
+	return c.do(http.MethodPost, fmt.Sprintf("/zones/%s/dns_records", c.zoneID), record, nil)
+}
+
+func (c *cloudflareClient) updateRecord(record cloudflareRecord) error {
+	return c.do(http.MethodPut, fmt.Sprintf("/zones/%s/dns_records/%s", c.zoneID, record.ID), record, nil)
+}
+
+func (c *cloudflareClient) deleteRecord(id string) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/zones/%s/dns_records/%s", c.zoneID, id), nil, nil)
+}
+
+func (c *cloudflareClient) do(method, path string, body interface{}, result interface{}) error {
+	_, err := c.request(method, path, body, result)
+	return err
+}
+
+// request sends request to Cloudflare API and decodes its result. Pagination info is returned by list requests only
+func (c *cloudflareClient) request(method, path string, body interface{}, result interface{}) (*cloudflareResultInfo, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.endpoint+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	cfResponse := cloudflareResponse{}
+	if err = json.Unmarshal(raw, &cfResponse); err != nil {
+		return nil, fmt.Errorf("cloudflare %s %s: unexpected response (%s): %s", method, path, resp.Status, err)
+	}
+	if !cfResponse.Success {
+		var messages []string
+		for _, e := range cfResponse.Errors {
+			messages = append(messages, fmt.Sprintf("%d: %s", e.Code, e.Message))
+		}
+		return nil, fmt.Errorf("cloudflare %s %s failed (%s): %s", method, path, resp.Status, strings.Join(messages, "; "))
+	}
+	if result != nil {
+		if err = json.Unmarshal(cfResponse.Result, result); err != nil {
+			return nil, err
+		}
+	}
+	return cfResponse.ResultInfo, nil
+}
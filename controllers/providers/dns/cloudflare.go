@@ -0,0 +1,230 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/providers/assistant"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+// CloudflareProvider manages zone delegation directly through Cloudflare API
+type CloudflareProvider struct {
+	assistant assistant.IAssistant
+	config    depresolver.Config
+	endpoint  string
+	dryRun    *dryRunRecorder
+}
+
+func NewCloudflareDNS(config depresolver.Config, assistant assistant.IAssistant) *CloudflareProvider {
+	return &CloudflareProvider{
+		assistant: assistant,
+		config:    config,
+		endpoint:  cloudflareAPIEndpoint,
+	}
+}
+
+// CreateZoneDelegationForExternalDNS creates or updates NS records of DNSZone and glue A record
+// of the local nameserver
+func (p *CloudflareProvider) CreateZoneDelegationForExternalDNS(gslb *k8gbv1beta1.Gslb) error {
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	var NSServerList []string
+	NSServerList = append(NSServerList, nsServerName(p.config))
+	NSServerList = append(NSServerList, nsServerNameExt(p.config, clusterPeers(p.assistant)...)...)
+	sort.Strings(NSServerList)
+	var NSServerIPs []string
+	if p.config.CoreDNSExposed {
+		NSServerIPs, err = p.assistant.CoreDNSExposedIPs()
+	} else {
+		NSServerIPs, err = p.assistant.GslbIngressExposedIPs(gslb)
+	}
+	if err != nil {
+		return err
+	}
+	p.assistant.Info("Updating delegated zone(%s) with the server list(%v) in %s", p.config.DNSZone, NSServerList, p)
+	err = p.syncRecords(c, "NS", p.config.DNSZone, NSServerList, p.delegationTTL())
+	if err != nil {
+		return err
+	}
+	p.assistant.Info("Updating glue record(%s) with IPs(%v) in %s", nsServerName(p.config), NSServerIPs, p)
+	return p.syncRecords(c, "A", nsServerName(p.config), NSServerIPs, p.delegationTTL())
+}
+
+// Finalize removes local nameserver from delegation and its glue record once the last Gslb of the zone is
+// finalized. Nameservers of other clusters are kept untouched
+func (p *CloudflareProvider) Finalize(_ *k8gbv1beta1.Gslb, others []k8gbv1beta1.Gslb) error {
+	if len(others) > 0 {
+		return nil
+	}
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	nsRecords, err := c.listRecords("NS", p.config.DNSZone)
+	if err != nil {
+		return err
+	}
+	for _, r := range nsRecords {
+		if r.Content == nsServerName(p.config) {
+			p.assistant.Info("Removing %s from delegated zone(%s)...", r.Content, p.config.DNSZone)
+			if err = p.deleteRecord(c, r); err != nil {
+				return err
+			}
+		}
+	}
+	aRecords, err := c.listRecords("A", nsServerName(p.config))
+	if err != nil {
+		return err
+	}
+	for _, r := range aRecords {
+		p.assistant.Info("Removing glue record %s(%s)...", r.Name, r.Content)
+		if err = p.deleteRecord(c, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *CloudflareProvider) GetExternalTargets(host string) (targets []string) {
+	return externalTargets(p.config, p.assistant, host)
+}
+
+func (p *CloudflareProvider) ExternalClustersStatus(gslb *k8gbv1beta1.Gslb) []k8gbv1beta1.PeerStatus {
+	return externalClustersStatus(p.config, p.assistant, gslb)
+}
+
+func (p *CloudflareProvider) ExternalHeartbeats(*k8gbv1beta1.Gslb) []Heartbeat {
+	return nil
+}
+
+func (p *CloudflareProvider) GslbIngressExposedIPs(gslb *k8gbv1beta1.Gslb) ([]string, error) {
+	return p.assistant.GslbIngressExposedIPs(gslb)
+}
+
+func (p *CloudflareProvider) SaveDNSEndpoint(gslb *k8gbv1beta1.Gslb, i *externaldns.DNSEndpoint) error {
+	return p.assistant.SaveDNSEndpoint(gslb.Namespace, i)
+}
+
+func (p *CloudflareProvider) String() string {
+	return "Cloudflare"
+}
+
+// client creates Cloudflare client. Token file is read on every call, so the rotated token is picked up
+// without restart
+func (p *CloudflareProvider) client() (*cloudflareClient, error) {
+	token := p.config.Cloudflare.APIToken
+	if p.config.Cloudflare.APITokenFile != "" {
+		b, err := ioutil.ReadFile(p.config.Cloudflare.APITokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read cloudflare API token file: %s", err)
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	return newCloudflareClient(p.endpoint, p.config.Cloudflare.ZoneID, token), nil
+}
+
+// delegationTTL is TTL of NS and glue records shared by all Gslbs of the zone. Cloudflare accepts automatic TTL or
+// TTL of at least 60 seconds
+func (p *CloudflareProvider) delegationTTL() int {
+	ttl := p.config.Cloudflare.DelegationTTL
+	if ttl <= cloudflareAutomaticTTL {
+		return cloudflareAutomaticTTL
+	}
+	if ttl < cloudflareMinTTL {
+		return cloudflareMinTTL
+	}
+	return ttl
+}
+
+// syncRecords makes records of given type and name to contain exactly desired contents.
+// Cloudflare stores each target as separate record, so records of changed TTL are updated, missing ones are created
+// and stale ones deleted. Stale records are deleted last, so that the name is never left without records
+func (p *CloudflareProvider) syncRecords(c *cloudflareClient, recordType, name string, contents []string, ttl int) error {
+	existing, err := c.listRecords(recordType, name)
+	if err != nil {
+		return err
+	}
+	missing := make(map[string]bool)
+	for _, content := range contents {
+		missing[content] = true
+	}
+	var stale []cloudflareRecord
+	for _, r := range existing {
+		if !missing[r.Content] {
+			stale = append(stale, r)
+			continue
+		}
+		delete(missing, r.Content)
+		if r.TTL != ttl {
+			r.TTL = ttl
+			if err = p.updateRecord(c, r); err != nil {
+				return err
+			}
+		}
+	}
+	for _, content := range contents {
+		if !missing[content] {
+			continue
+		}
+		err = p.createRecord(c, cloudflareRecord{Type: recordType, Name: name, Content: content, TTL: ttl})
+		if err != nil {
+			return err
+		}
+	}
+	for _, r := range stale {
+		if err = p.deleteRecord(c, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createRecord creates record in Cloudflare; in dry run the record is only recorded
+func (p *CloudflareProvider) createRecord(c *cloudflareClient, r cloudflareRecord) error {
+	if p.dryRun != nil {
+		p.dryRun.record(p, dryRunChange{Action: dryRunActionUpsert, Type: r.Type, Name: r.Name, TTL: r.TTL, Targets: []string{r.Content}})
+		return nil
+	}
+	return c.createRecord(r)
+}
+
+// updateRecord updates record in Cloudflare; in dry run the record is only recorded
+func (p *CloudflareProvider) updateRecord(c *cloudflareClient, r cloudflareRecord) error {
+	if p.dryRun != nil {
+		p.dryRun.record(p, dryRunChange{Action: dryRunActionUpsert, Type: r.Type, Name: r.Name, TTL: r.TTL, Targets: []string{r.Content}})
+		return nil
+	}
+	return c.updateRecord(r)
+}
+
+// deleteRecord deletes record from Cloudflare; in dry run the deletion is only recorded
+func (p *CloudflareProvider) deleteRecord(c *cloudflareClient, r cloudflareRecord) error {
+	if p.dryRun != nil {
+		p.dryRun.record(p, dryRunChange{Action: dryRunActionDelete, Type: r.Type, Name: r.Name, TTL: r.TTL, Targets: []string{r.Content}})
+		return nil
+	}
+	return c.deleteRecord(r.ID)
+}
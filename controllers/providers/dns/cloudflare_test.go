@@ -0,0 +1,335 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/providers/assistant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeCloudflare is in-memory stand-in of Cloudflare DNS records API
+type fakeCloudflare struct {
+	sync.Mutex
+	token   string
+	records map[string]cloudflareRecord
+	nextID  int
+	deleted int
+	// requests records methods of write requests in order
+	requests []string
+}
+
+func newFakeCloudflare(token string, records ...cloudflareRecord) *fakeCloudflare {
+	f := &fakeCloudflare{token: token, records: make(map[string]cloudflareRecord)}
+	for _, r := range records {
+		f.add(r)
+	}
+	return f
+}
+
+func (f *fakeCloudflare) add(r cloudflareRecord) {
+	f.nextID++
+	r.ID = fmt.Sprintf("id-%d", f.nextID)
+	f.records[r.ID] = r
+}
+
+// contents returns sorted contents of records with given type and name
+func (f *fakeCloudflare) contents(recordType, name string) (contents []string) {
+	f.Lock()
+	defer f.Unlock()
+	for _, r := range f.records {
+		if r.Type == recordType && r.Name == name {
+			contents = append(contents, r.Content)
+		}
+	}
+	sort.Strings(contents)
+	return
+}
+
+func (f *fakeCloudflare) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	reply := func(status int, result interface{}) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": status == http.StatusOK, "result": result,
+			"errors": []cloudflareError{}})
+	}
+	if req.Header.Get("Authorization") != "Bearer "+f.token {
+		reply(http.StatusForbidden, nil)
+		return
+	}
+	if req.Method != http.MethodGet {
+		f.requests = append(f.requests, req.Method)
+	}
+	switch req.Method {
+	case http.MethodGet:
+		records := []cloudflareRecord{}
+		for _, r := range f.records {
+			if r.Type == req.URL.Query().Get("type") && r.Name == req.URL.Query().Get("name") {
+				records = append(records, r)
+			}
+		}
+		sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+		perPage, _ := strconv.Atoi(req.URL.Query().Get("per_page"))
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+		totalPages := (len(records) + perPage - 1) / perPage
+		from, to := (page-1)*perPage, page*perPage
+		if from > len(records) {
+			from = len(records)
+		}
+		if to > len(records) {
+			to = len(records)
+		}
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "result": records[from:to],
+			"errors": []cloudflareError{}, "result_info": cloudflareResultInfo{Page: page, TotalPages: totalPages}})
+	case http.MethodPost:
+		r := cloudflareRecord{}
+		_ = json.NewDecoder(req.Body).Decode(&r)
+		f.add(r)
+		reply(http.StatusOK, r)
+	case http.MethodPut:
+		r := cloudflareRecord{}
+		_ = json.NewDecoder(req.Body).Decode(&r)
+		r.ID = req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+		f.records[r.ID] = r
+		reply(http.StatusOK, r)
+	case http.MethodDelete:
+		id := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+		delete(f.records, id)
+		f.deleted++
+		reply(http.StatusOK, nil)
+	}
+}
+
+func TestCloudflareCreatesZoneDelegation(t *testing.T) {
+	// arrange
+	cf := newFakeCloudflare("secret",
+		cloudflareRecord{Type: "NS", Name: "cloud.example.com", Content: "gslb-ns-cloud-example-com-us-east-1.example.com", TTL: 30},
+		cloudflareRecord{Type: "NS", Name: "cloud.example.com", Content: "gslb-ns-cloud-example-com-eu.example.com", TTL: 30},
+		cloudflareRecord{Type: "A", Name: "gslb-ns-cloud-example-com-us-west-1.example.com", Content: "10.9.9.9", TTL: 30},
+	)
+	provider, gslb := newTestCloudflareProvider(t, cf, "secret", "")
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gslb-ns-cloud-example-com-us-east-1.example.com", "gslb-ns-cloud-example-com-us-west-1.example.com"},
+		cf.contents("NS", "cloud.example.com"))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, cf.contents("A", "gslb-ns-cloud-example-com-us-west-1.example.com"))
+}
+
+func TestCloudflareRemovesStaleRecordsFromAllPages(t *testing.T) {
+	// arrange
+	var stale []cloudflareRecord
+	for i := 0; i < 2*cloudflarePageSize+50; i++ {
+		stale = append(stale, cloudflareRecord{Type: "A", Name: "gslb-ns-cloud-example-com-us-west-1.example.com",
+			Content: fmt.Sprintf("10.10.%d.%d", i/256, i%256), TTL: 30})
+	}
+	cf := newFakeCloudflare("secret", stale...)
+	provider, gslb := newTestCloudflareProvider(t, cf, "secret", "")
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, len(stale), cf.deleted)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, cf.contents("A", "gslb-ns-cloud-example-com-us-west-1.example.com"))
+}
+
+func TestCloudflareZoneDelegationIsIdempotent(t *testing.T) {
+	// arrange
+	cf := newFakeCloudflare("secret")
+	provider, gslb := newTestCloudflareProvider(t, cf, "secret", "")
+	require.NoError(t, provider.CreateZoneDelegationForExternalDNS(gslb))
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, 0, cf.deleted)
+	assert.Len(t, cf.records, 4)
+}
+
+func TestCloudflareZoneDelegationWritesBeforeDeleting(t *testing.T) {
+	// arrange
+	cf := newFakeCloudflare("secret",
+		cloudflareRecord{Type: "A", Name: "gslb-ns-cloud-example-com-us-west-1.example.com", Content: "10.0.0.1", TTL: 30},
+		cloudflareRecord{Type: "A", Name: "gslb-ns-cloud-example-com-us-west-1.example.com", Content: "10.9.9.9", TTL: 30},
+	)
+	provider, gslb := newTestCloudflareProvider(t, cf, "secret", "")
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"POST", "POST", "PUT", "POST", "DELETE"}, cf.requests, "NS records created, glue TTL updated, glue created, stale glue deleted")
+	assert.Equal(t, 1, cf.deleted)
+	for _, r := range cf.records {
+		assert.Equal(t, cloudflareAutomaticTTL, r.TTL)
+	}
+}
+
+func TestCloudflareDelegationTTL(t *testing.T) {
+	var tests = []struct {
+		ttl      int
+		expected int
+	}{
+		{ttl: 0, expected: 1},
+		{ttl: 1, expected: 1},
+		{ttl: 30, expected: 60},
+		{ttl: 300, expected: 300},
+	}
+	for _, test := range tests {
+		t.Run(strconv.Itoa(test.ttl), func(t *testing.T) {
+			// arrange
+			provider := NewCloudflareDNS(depresolver.Config{Cloudflare: depresolver.Cloudflare{DelegationTTL: test.ttl}}, nil)
+			// act
+			ttl := provider.delegationTTL()
+			// assert
+			assert.Equal(t, test.expected, ttl)
+		})
+	}
+}
+
+func TestCloudflareZoneDelegationIgnoresTTLOfGslb(t *testing.T) {
+	// arrange
+	cf := newFakeCloudflare("secret")
+	provider, gslb := newTestCloudflareProvider(t, cf, "secret", "")
+	require.NoError(t, provider.CreateZoneDelegationForExternalDNS(gslb))
+	other := gslb.DeepCopy()
+	other.Spec.Strategy.DNSTtlSeconds = 120
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(other)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"POST", "POST", "POST", "POST"}, cf.requests, "delegation must not be rewritten")
+}
+
+func TestCloudflareFinalizeRemovesOnlyLocalCluster(t *testing.T) {
+	// arrange
+	cf := newFakeCloudflare("secret")
+	provider, gslb := newTestCloudflareProvider(t, cf, "secret", "")
+	require.NoError(t, provider.CreateZoneDelegationForExternalDNS(gslb))
+	// act
+	err := provider.Finalize(gslb, nil)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gslb-ns-cloud-example-com-us-east-1.example.com"}, cf.contents("NS", "cloud.example.com"))
+	assert.Empty(t, cf.contents("A", "gslb-ns-cloud-example-com-us-west-1.example.com"))
+}
+
+func TestCloudflareFinalizeKeepsDelegationOfOtherGslbs(t *testing.T) {
+	// arrange
+	cf := newFakeCloudflare("secret")
+	provider, gslb := newTestCloudflareProvider(t, cf, "secret", "")
+	require.NoError(t, provider.CreateZoneDelegationForExternalDNS(gslb))
+	other := *gslb
+	other.Name = "other-gslb"
+	// act
+	err := provider.Finalize(gslb, []k8gbv1beta1.Gslb{other})
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, 0, cf.deleted)
+	assert.Equal(t, []string{"gslb-ns-cloud-example-com-us-east-1.example.com", "gslb-ns-cloud-example-com-us-west-1.example.com"},
+		cf.contents("NS", "cloud.example.com"))
+}
+
+func TestCloudflareZoneDelegationFollowsClusterPeers(t *testing.T) {
+	// arrange
+	cf := newFakeCloudflare("secret",
+		cloudflareRecord{Type: "NS", Name: "cloud.example.com", Content: "gslb-ns-cloud-example-com-us-east-1.example.com", TTL: 30},
+	)
+	provider, gslb := newTestCloudflareProvider(t, cf, "secret", "",
+		&k8gbv1beta1.ClusterPeer{ObjectMeta: metav1.ObjectMeta{Name: "us-east-1"},
+			Spec: k8gbv1beta1.ClusterPeerSpec{GeoTag: "us-east-1", Enabled: false}},
+		&k8gbv1beta1.ClusterPeer{ObjectMeta: metav1.ObjectMeta{Name: "eu-west-1"},
+			Spec: k8gbv1beta1.ClusterPeerSpec{GeoTag: "eu-west-1", Enabled: true}},
+	)
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gslb-ns-cloud-example-com-eu-west-1.example.com", "gslb-ns-cloud-example-com-us-west-1.example.com"},
+		cf.contents("NS", "cloud.example.com"))
+}
+
+func TestCloudflareTokenFileTakesPrecedence(t *testing.T) {
+	// arrange
+	cf := newFakeCloudflare("from-file")
+	file := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, ioutil.WriteFile(file, []byte("from-file\n"), 0600))
+	provider, gslb := newTestCloudflareProvider(t, cf, "from-env", file)
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	require.NoError(t, err)
+}
+
+func TestCloudflareReturnsAPIError(t *testing.T) {
+	// arrange
+	cf := newFakeCloudflare("secret")
+	provider, gslb := newTestCloudflareProvider(t, cf, "invalid", "")
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	assert.Error(t, err)
+}
+
+func TestCloudflareMissingTokenFile(t *testing.T) {
+	// arrange
+	cf := newFakeCloudflare("secret")
+	provider, gslb := newTestCloudflareProvider(t, cf, "", filepath.Join(os.TempDir(), "k8gb-non-existing-token"))
+	// act
+	err := provider.Finalize(gslb, nil)
+	// assert
+	assert.Error(t, err)
+}
+
+func newTestCloudflareProvider(t *testing.T, cf *fakeCloudflare, token, tokenFile string, peers ...runtime.Object) (*CloudflareProvider, *k8gbv1beta1.Gslb) {
+	server := httptest.NewServer(cf)
+	t.Cleanup(server.Close)
+	gslb := getGSLB(t)
+	gslb.Spec.Strategy.DNSTtlSeconds = 30
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	require.NoError(t, k8gbv1beta1.AddToScheme(s))
+	client := fake.NewFakeClientWithScheme(s, append(peers, gslbIngress(gslb, "10.0.0.2", "10.0.0.1"))...)
+	a := assistant.NewGslbAssistant(client, ctrl.Log.WithName("dummy"), predefinedConfig.K8gbNamespace, predefinedConfig.EdgeDNSServer)
+	config := predefinedConfig
+	config.Cloudflare = depresolver.Cloudflare{ZoneID: "zone", APIToken: token, APITokenFile: tokenFile}
+	provider := NewCloudflareDNS(config, a)
+	provider.endpoint = server.URL
+	return provider, gslb
+}
@@ -18,32 +18,218 @@ package dns
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
+	"github.com/AbsaOSS/k8gb/controllers/providers/assistant"
 
 	"github.com/AbsaOSS/k8gb/controllers/depresolver"
 )
 
 func nsServerName(config depresolver.Config) string {
-	dnsZoneIntoNS := strings.ReplaceAll(config.DNSZone, ".", "-")
-	return fmt.Sprintf("gslb-ns-%s-%s.%s", dnsZoneIntoNS, config.ClusterGeoTag, config.EdgeDNSZone)
+	return nsServerNameOf(config, config.ClusterGeoTag)
 }
 
-func nsServerNameExt(config depresolver.Config) (extNSServers []string) {
+func nsServerNameOf(config depresolver.Config, geoTag string) string {
 	dnsZoneIntoNS := strings.ReplaceAll(config.DNSZone, ".", "-")
-	extNSServers = []string{}
-	for _, clusterGeoTag := range config.ExtClustersGeoTags {
+	return fmt.Sprintf("gslb-ns-%s-%s.%s", dnsZoneIntoNS, geoTag, config.EdgeDNSZone)
+}
 
-		extNSServers = append(extNSServers,
-			fmt.Sprintf("gslb-ns-%s-%s.%s", dnsZoneIntoNS, clusterGeoTag, config.EdgeDNSZone))
+// gslbZones returns distinct delegation zones of gslb hosts in the order of DelegationZones. DNSZone is returned
+// when no host belongs to any zone
+func gslbZones(config depresolver.Config, gslb *k8gbv1beta1.Gslb) (zones []depresolver.DelegationZone) {
+	used := make(map[string]bool)
+	for _, rule := range gslb.Spec.Ingress.Rules {
+		if zone, found := config.ZoneOf(rule.Host); found {
+			used[zone.DNSZone] = true
+		}
+	}
+	for _, zone := range config.DelegationZones() {
+		if used[zone.DNSZone] {
+			zones = append(zones, zone)
+		}
+	}
+	if len(zones) == 0 {
+		zones = config.DelegationZones()[:1]
+	}
+	return
+}
+
+// gslbsOfZone returns Gslbs out of gslbs delegated by zone. See gslbZones
+func gslbsOfZone(config depresolver.Config, zone depresolver.DelegationZone, gslbs []k8gbv1beta1.Gslb) (of []k8gbv1beta1.Gslb) {
+	for i := range gslbs {
+		for _, z := range gslbZones(config, &gslbs[i]) {
+			if z.DNSZone == zone.DNSZone {
+				of = append(of, gslbs[i])
+				break
+			}
+		}
+	}
+	return
+}
+
+// nsServerNameExt returns nameservers of external clusters. See extGeoTags
+func nsServerNameExt(config depresolver.Config, peers ...k8gbv1beta1.ClusterPeer) (extNSServers []string) {
+	extNSServers = []string{}
+	for _, clusterGeoTag := range extGeoTags(config, peers...) {
+		extNSServers = append(extNSServers, nsServerNameOf(config, clusterGeoTag))
 	}
 	return extNSServers
 }
 
-func getExternalClusterHeartbeatFQDNs(gslb *k8gbv1beta1.Gslb, config depresolver.Config) (extGslbClusters []string) {
-	for _, geoTag := range config.ExtClustersGeoTags {
-		extGslbClusters = append(extGslbClusters, fmt.Sprintf("%s-heartbeat-%s.%s", gslb.Name, geoTag, config.EdgeDNSZone))
+// nsAddressExt returns addresses used to query nameservers of external clusters. It is the explicit
+// ClusterPeer NSAddress, or the nameserver name when address isn't set
+func nsAddressExt(config depresolver.Config, peers ...k8gbv1beta1.ClusterPeer) (addresses []string) {
+	explicit := make(map[string]string)
+	for _, peer := range peers {
+		if peer.Spec.Enabled && peer.Spec.NSAddress != "" {
+			explicit[peer.Spec.GeoTag] = peer.Spec.NSAddress
+		}
+	}
+	addresses = []string{}
+	for _, geoTag := range extGeoTags(config, peers...) {
+		if address, found := explicit[geoTag]; found {
+			addresses = append(addresses, address)
+			continue
+		}
+		addresses = append(addresses, nsServerNameOf(config, geoTag))
+	}
+	return addresses
+}
+
+// statusEndpointsExt maps addresses of external clusters returned by nsAddressExt to URLs of their peer status API.
+// Only enabled ClusterPeers having status endpoint are included
+func statusEndpointsExt(config depresolver.Config, peers ...k8gbv1beta1.ClusterPeer) map[string]string {
+	endpoints := make(map[string]string)
+	for _, peer := range peers {
+		if !peer.Spec.Enabled || peer.Spec.StatusEndpoint == "" {
+			continue
+		}
+		address := peer.Spec.NSAddress
+		if address == "" {
+			address = nsServerNameOf(config, peer.Spec.GeoTag)
+		}
+		endpoints[address] = peer.Spec.StatusEndpoint
+	}
+	return endpoints
+}
+
+// externalTargets retrieves targets of host from external clusters. Clusters having status endpoint are asked by
+// their peer status API first
+func externalTargets(config depresolver.Config, a assistant.IAssistant, host string) []string {
+	peers := clusterPeers(a)
+	return a.GetExternalTargets(host, config.Override.FakeDNSEnabled, nsAddressExt(config, peers...), statusEndpointsExt(config, peers...))
+}
+
+// externalClustersStatus describes external clusters of the membership in the order of extGeoTags
+func externalClustersStatus(config depresolver.Config, a assistant.IAssistant, gslb *k8gbv1beta1.Gslb) []k8gbv1beta1.PeerStatus {
+	peers := clusterPeers(a)
+	addresses := nsAddressExt(config, peers...)
+	heartbeats := getExternalClusterHeartbeatFQDNs(gslb, config, peers...)
+	var hosts []string
+	for _, rule := range gslb.Spec.Ingress.Rules {
+		hosts = append(hosts, rule.Host)
+	}
+	var status []k8gbv1beta1.PeerStatus
+	for i, geoTag := range extGeoTags(config, peers...) {
+		status = append(status, a.ExternalClusterStatus(geoTag, addresses[i], heartbeats[i], hosts))
+	}
+	return status
+}
+
+// externalHeartbeats inspects split brain TXT records of gslb in external clusters; heartbeat is alive when it is
+// within the split brain threshold of gslb and its cluster isn't draining
+func externalHeartbeats(config depresolver.Config, a assistant.IAssistant, gslb *k8gbv1beta1.Gslb) (heartbeats []Heartbeat) {
+	threshold := time.Second * time.Duration(gslb.Spec.Strategy.SplitBrainThresholdSeconds)
+	for _, geoTag := range extGeoTags(config, clusterPeers(a)...) {
+		fqdn, geoTag := heartbeatFQDN(gslb, config, geoTag), geoTag
+		heartbeats = append(heartbeats, Heartbeat{FQDN: fqdn, Threshold: threshold, Alive: func() bool {
+			return a.InspectTXTThreshold(fqdn, geoTag, config.Override.FakeDNSEnabled, threshold) == nil
+		}})
 	}
 	return
 }
+
+// nsServerNameDisabled returns nameservers of external clusters disabled by ClusterPeer
+func nsServerNameDisabled(config depresolver.Config, peers ...k8gbv1beta1.ClusterPeer) (disabled []string) {
+	for _, peer := range peers {
+		if !peer.Spec.Enabled && peer.Spec.GeoTag != config.ClusterGeoTag {
+			disabled = append(disabled, nsServerNameOf(config, peer.Spec.GeoTag))
+		}
+	}
+	return
+}
+
+func getExternalClusterHeartbeatFQDNs(gslb *k8gbv1beta1.Gslb, config depresolver.Config, peers ...k8gbv1beta1.ClusterPeer) (extGslbClusters []string) {
+	for _, geoTag := range extGeoTags(config, peers...) {
+		extGslbClusters = append(extGslbClusters, heartbeatFQDN(gslb, config, geoTag))
+	}
+	return
+}
+
+// heartbeatFQDN returns split brain TXT record of gslb written by cluster geoTag
+func heartbeatFQDN(gslb *k8gbv1beta1.Gslb, config depresolver.Config, geoTag string) string {
+	return fmt.Sprintf("%s-heartbeat-%s.%s", gslb.Name, geoTag, config.EdgeDNSZone)
+}
+
+// extGeoTags returns geo tags of external clusters. Geo tags from EXT_GSLB_CLUSTERS_GEO_TAGS are followed by
+// geo tags of enabled ClusterPeers. Disabled ClusterPeer removes the geo tag from membership. ClusterPeer of
+// the local cluster is ignored
+func extGeoTags(config depresolver.Config, peers ...k8gbv1beta1.ClusterPeer) (geoTags []string) {
+	disabled := make(map[string]bool)
+	var enabled []string
+	for _, peer := range peers {
+		if !peer.Spec.Enabled {
+			disabled[peer.Spec.GeoTag] = true
+			continue
+		}
+		enabled = append(enabled, peer.Spec.GeoTag)
+	}
+	sort.Strings(enabled)
+	seen := map[string]bool{config.ClusterGeoTag: true, "": true}
+	for _, geoTag := range append(append([]string{}, config.ExtClustersGeoTags...), enabled...) {
+		if seen[geoTag] || disabled[geoTag] {
+			continue
+		}
+		seen[geoTag] = true
+		geoTags = append(geoTags, geoTag)
+	}
+	return
+}
+
+// clusterPeers retrieves ClusterPeer resources. When they can't be read, e.g. CRD is not installed yet,
+// only EXT_GSLB_CLUSTERS_GEO_TAGS determine membership
+func clusterPeers(a assistant.IAssistant) []k8gbv1beta1.ClusterPeer {
+	peers, err := a.ClusterPeers()
+	if err != nil {
+		a.Info("Can't read ClusterPeers, using %s only: %s", depresolver.ExtClustersGeoTagsKey, err)
+		return nil
+	}
+	return peers
+}
+
+// heartbeat returns signed payload of split brain TXT record fqdn describing this cluster. Heartbeat is written even
+// if healthy Gslbs can't be counted, so the cluster stays alive for others. With LegacyHeartbeat, the payload is
+// bare timestamp, so clusters of older versions keep reading it
+func heartbeat(config depresolver.Config, a assistant.IAssistant, fqdn string) string {
+	healthy, err := a.HealthyGslbs()
+	if err != nil {
+		a.Error(err, "Can't count healthy Gslbs of split brain TXT heartbeat")
+	}
+	h := utils.Heartbeat{
+		Timestamp:       time.Now().UTC(),
+		OperatorVersion: config.K8gbVersion,
+		GeoTag:          config.ClusterGeoTag,
+		Draining:        config.ClusterDraining,
+		HealthyGslbs:    healthy,
+		Capacity:        config.ClusterCapacity,
+	}
+	payload := h.String()
+	if config.LegacyHeartbeat {
+		payload = h.Legacy()
+	}
+	return utils.SignHeartbeat(payload, fqdn, config.ClusterGeoTag, config.PeerAuth.HeartbeatSecret)
+}
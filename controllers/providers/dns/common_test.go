@@ -25,6 +25,9 @@ import (
 	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var commonConfig = depresolver.Config{
@@ -83,6 +86,44 @@ func TestGeneratesProperExternalNSTargetFQDNsAccordingToTheGeoTags(t *testing.T)
 	assert.Equal(t, want, got, "got:\n %q externalGslb NS records,\n\n want:\n %q", got, want)
 }
 
+func TestNsServerNameExtWithClusterPeers(t *testing.T) {
+	// arrange
+	peers := []k8gbv1beta1.ClusterPeer{clusterPeer("za", "", true), clusterPeer("uk", "", false),
+		clusterPeer("us", "", true), clusterPeer("eu", "", true), clusterPeer("af", "", true)}
+	expected := []string{"gslb-ns-example-com-eu.8.8.8.8", "gslb-ns-example-com-af.8.8.8.8", "gslb-ns-example-com-za.8.8.8.8"}
+	// act
+	result := nsServerNameExt(commonConfig, peers...)
+	// assert
+	assert.Equal(t, expected, result)
+	assert.Equal(t, []string{"gslb-ns-example-com-uk.8.8.8.8"}, nsServerNameDisabled(commonConfig, peers...))
+}
+
+func TestNsAddressExtWithClusterPeers(t *testing.T) {
+	// arrange
+	peers := []k8gbv1beta1.ClusterPeer{clusterPeer("za", "10.0.0.10", true), clusterPeer("eu", "", true)}
+	expected := []string{"gslb-ns-example-com-uk.8.8.8.8", "gslb-ns-example-com-eu.8.8.8.8", "10.0.0.10"}
+	// act
+	result := nsAddressExt(commonConfig, peers...)
+	// assert
+	assert.Equal(t, expected, result)
+}
+
+func TestStatusEndpointsExtWithClusterPeers(t *testing.T) {
+	// arrange
+	za := clusterPeer("za", "10.0.0.10", true)
+	za.Spec.StatusEndpoint = "https://k8gb.za.example.com:8443"
+	eu := clusterPeer("eu", "", true)
+	eu.Spec.StatusEndpoint = "https://k8gb.eu.example.com:8443"
+	us := clusterPeer("us", "", false)
+	us.Spec.StatusEndpoint = "https://k8gb.us.example.com:8443"
+	expected := map[string]string{"10.0.0.10": "https://k8gb.za.example.com:8443",
+		"gslb-ns-example-com-eu.8.8.8.8": "https://k8gb.eu.example.com:8443"}
+	// act
+	result := statusEndpointsExt(commonConfig, za, eu, us, clusterPeer("af", "", true))
+	// assert
+	assert.Equal(t, expected, result)
+}
+
 func TestCanGenerateExternalHeartbeatFQDNs(t *testing.T) {
 	// arrange
 	want := []string{"test-gslb-heartbeat-za.example.com"}
@@ -105,3 +146,19 @@ func getGSLB(t *testing.T) *k8gbv1beta1.Gslb {
 	gslb, _ := utils.YamlToGslb(gslbYaml)
 	return gslb
 }
+
+// gslbIngress returns ingress of the gslb exposing given IPs
+func gslbIngress(gslb *k8gbv1beta1.Gslb, ips ...string) *v1beta1.Ingress {
+	ingress := &v1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: gslb.Name, Namespace: gslb.Namespace}}
+	for _, ip := range ips {
+		ingress.Status.LoadBalancer.Ingress = append(ingress.Status.LoadBalancer.Ingress, corev1.LoadBalancerIngress{IP: ip})
+	}
+	return ingress
+}
+
+func clusterPeer(geoTag, nsAddress string, enabled bool) k8gbv1beta1.ClusterPeer {
+	return k8gbv1beta1.ClusterPeer{
+		ObjectMeta: metav1.ObjectMeta{Name: geoTag},
+		Spec:       k8gbv1beta1.ClusterPeerSpec{GeoTag: geoTag, NSAddress: nsAddress, Enabled: enabled},
+	}
+}
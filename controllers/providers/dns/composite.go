@@ -0,0 +1,103 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"strings"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/providers/assistant"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+// CompositeDNSProvider is executed when multiple edge DNS providers are enabled at once, e.g. during
+// migration from Infoblox to Route53. Zone delegation and finalization are fanned out to every provider,
+// while operations over local resources (ingress IPs, external targets, gslb DNSEndpoint) are shared
+// and executed only once by the first provider. Status of every provider is reported by the aggregated error,
+// which is recorded in DelegationSynced condition of Gslb.
+type CompositeDNSProvider struct {
+	assistant assistant.IAssistant
+	providers []IDnsProvider
+}
+
+func NewCompositeDNS(assistant assistant.IAssistant, providers ...IDnsProvider) *CompositeDNSProvider {
+	return &CompositeDNSProvider{
+		assistant: assistant,
+		providers: providers,
+	}
+}
+
+func (p *CompositeDNSProvider) CreateZoneDelegationForExternalDNS(gslb *k8gbv1beta1.Gslb) error {
+	return p.forEach("zone delegation", func(provider IDnsProvider) error {
+		return provider.CreateZoneDelegationForExternalDNS(gslb)
+	})
+}
+
+func (p *CompositeDNSProvider) Finalize(gslb *k8gbv1beta1.Gslb, others []k8gbv1beta1.Gslb) error {
+	return p.forEach("finalize", func(provider IDnsProvider) error {
+		return provider.Finalize(gslb, others)
+	})
+}
+
+func (p *CompositeDNSProvider) GslbIngressExposedIPs(gslb *k8gbv1beta1.Gslb) ([]string, error) {
+	return p.providers[0].GslbIngressExposedIPs(gslb)
+}
+
+func (p *CompositeDNSProvider) GetExternalTargets(host string) (targets []string) {
+	return p.providers[0].GetExternalTargets(host)
+}
+
+func (p *CompositeDNSProvider) ExternalClustersStatus(gslb *k8gbv1beta1.Gslb) []k8gbv1beta1.PeerStatus {
+	return p.providers[0].ExternalClustersStatus(gslb)
+}
+
+// ExternalHeartbeats merges heartbeats of all providers, as each of them filters its zone delegation
+func (p *CompositeDNSProvider) ExternalHeartbeats(gslb *k8gbv1beta1.Gslb) (heartbeats []Heartbeat) {
+	for _, provider := range p.providers {
+		heartbeats = append(heartbeats, provider.ExternalHeartbeats(gslb)...)
+	}
+	return
+}
+
+func (p *CompositeDNSProvider) SaveDNSEndpoint(gslb *k8gbv1beta1.Gslb, i *externaldns.DNSEndpoint) error {
+	return p.providers[0].SaveDNSEndpoint(gslb, i)
+}
+
+func (p *CompositeDNSProvider) String() string {
+	var names []string
+	for _, provider := range p.providers {
+		names = append(names, fmt.Sprintf("%s", provider))
+	}
+	return strings.Join(names, ",")
+}
+
+// forEach executes fn against all providers, even if some of them fail. Errors are aggregated into single one
+func (p *CompositeDNSProvider) forEach(operation string, fn func(IDnsProvider) error) error {
+	var messages []string
+	for _, provider := range p.providers {
+		if err := fn(provider); err != nil {
+			p.assistant.Error(err, "%s failed for provider %s", operation, provider)
+			messages = append(messages, fmt.Sprintf("%s: %s", provider, err))
+		}
+	}
+	if len(messages) > 0 {
+		return fmt.Errorf("%s failed for %d of %d providers: [%s]", operation, len(messages), len(p.providers),
+			strings.Join(messages, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"testing"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/providers/assistant"
+	"github.com/stretchr/testify/assert"
+	ctrl "sigs.k8s.io/controller-runtime"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+// stubProvider counts calls and returns err from zone delegation and finalize
+type stubProvider struct {
+	name        string
+	err         error
+	delegations int
+	finalized   int
+	saved       int
+	heartbeats  map[string]bool
+	others      []k8gbv1beta1.Gslb
+}
+
+func (s *stubProvider) CreateZoneDelegationForExternalDNS(*k8gbv1beta1.Gslb) error {
+	s.delegations++
+	return s.err
+}
+
+func (s *stubProvider) GslbIngressExposedIPs(*k8gbv1beta1.Gslb) ([]string, error) {
+	return []string{s.name}, nil
+}
+
+func (s *stubProvider) GetExternalTargets(string) []string {
+	return []string{s.name}
+}
+
+func (s *stubProvider) ExternalClustersStatus(*k8gbv1beta1.Gslb) []k8gbv1beta1.PeerStatus {
+	return nil
+}
+
+func (s *stubProvider) ExternalHeartbeats(*k8gbv1beta1.Gslb) (heartbeats []Heartbeat) {
+	for fqdn, alive := range s.heartbeats {
+		alive := alive
+		heartbeats = append(heartbeats, Heartbeat{FQDN: fqdn, Alive: func() bool { return alive }})
+	}
+	return
+}
+
+func (s *stubProvider) SaveDNSEndpoint(*k8gbv1beta1.Gslb, *externaldns.DNSEndpoint) error {
+	s.saved++
+	return nil
+}
+
+func (s *stubProvider) Finalize(_ *k8gbv1beta1.Gslb, others []k8gbv1beta1.Gslb) error {
+	s.finalized++
+	s.others = others
+	return s.err
+}
+
+func (s *stubProvider) String() string {
+	return s.name
+}
+
+func TestCompositeFansOutDelegationAndFinalize(t *testing.T) {
+	// arrange
+	first := &stubProvider{name: "first"}
+	second := &stubProvider{name: "second"}
+	gslb := getGSLB(t)
+	provider := NewCompositeDNS(newTestAssistant(), first, second)
+	// act
+	errDelegation := provider.CreateZoneDelegationForExternalDNS(gslb)
+	errFinalize := provider.Finalize(gslb, nil)
+	errSave := provider.SaveDNSEndpoint(gslb, &externaldns.DNSEndpoint{})
+	targets := provider.GetExternalTargets("roundrobin.cloud.example.com")
+	// assert
+	assert.NoError(t, errDelegation)
+	assert.NoError(t, errFinalize)
+	assert.NoError(t, errSave)
+	assert.Equal(t, 1, first.delegations)
+	assert.Equal(t, 1, second.delegations)
+	assert.Equal(t, 1, first.finalized)
+	assert.Equal(t, 1, second.finalized)
+	assert.Equal(t, 1, first.saved, "DNSEndpoint is shared and saved only once")
+	assert.Equal(t, 0, second.saved)
+	assert.Equal(t, []string{"first"}, targets)
+	assert.Equal(t, "first,second", provider.String())
+}
+
+func TestCompositeContinuesAndAggregatesErrors(t *testing.T) {
+	// arrange
+	failing := &stubProvider{name: "failing", err: fmt.Errorf("connection refused")}
+	healthy := &stubProvider{name: "healthy"}
+	gslb := getGSLB(t)
+	provider := NewCompositeDNS(newTestAssistant(), failing, healthy)
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	assert.EqualError(t, err, "zone delegation failed for 1 of 2 providers: [failing: connection refused]")
+	assert.Equal(t, 1, healthy.delegations, "healthy provider must be updated even if previous one fails")
+}
+
+func TestCompositeMergesHeartbeats(t *testing.T) {
+	// arrange
+	infoblox := &stubProvider{name: "infoblox", heartbeats: map[string]bool{"test-gslb-heartbeat-eu.example.com": true}}
+	route53 := &stubProvider{name: "route53"}
+	provider := NewCompositeDNS(newTestAssistant(), route53, infoblox)
+	// act
+	heartbeats := inspectHeartbeats(provider.ExternalHeartbeats(getGSLB(t)))
+	// assert
+	assert.Equal(t, map[string]bool{"test-gslb-heartbeat-eu.example.com": true}, heartbeats)
+}
+
+// inspectHeartbeats returns liveness per FQDN of heartbeats
+func inspectHeartbeats(heartbeats []Heartbeat) map[string]bool {
+	alive := make(map[string]bool)
+	for _, heartbeat := range heartbeats {
+		alive[heartbeat.FQDN] = heartbeat.Alive()
+	}
+	return alive
+}
+
+func newTestAssistant() assistant.IAssistant {
+	return assistant.NewGslbAssistant(nil, ctrl.Log.WithName("dummy"), predefinedConfig.K8gbNamespace, predefinedConfig.EdgeDNSServer)
+}
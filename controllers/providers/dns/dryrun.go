@@ -0,0 +1,243 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/providers/assistant"
+	ibclient "github.com/infobloxopen/infoblox-go-client"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	dryRunActionUpsert = "UPSERT"
+	dryRunActionDelete = "DELETE"
+)
+
+// DryRunProvider is executed when DryRun is enabled. It wraps the configured provider, which is built to record
+// edge DNS writes instead of applying them, so the recorded plan follows the same decisions (split brain filtering,
+// ClusterPeers, Gslbs sharing the zone) as the real provider. Reads of edge DNS are not affected. Writes intended by
+// the zone delegation or finalization of a Gslb are stored into ConfigMap <gslb>-dryrun and logged. Operations over
+// local resources (ingress IPs, external targets, gslb DNSEndpoint) are delegated to the wrapped provider.
+type DryRunProvider struct {
+	assistant assistant.IAssistant
+	client    client.Client
+	provider  IDnsProvider
+	recorder  *dryRunRecorder
+	mu        sync.Mutex
+}
+
+// dryRunChange is single change of edge DNS record which would be applied without dry run
+type dryRunChange struct {
+	Provider string   `json:"provider"`
+	Action   string   `json:"action"`
+	Type     string   `json:"type"`
+	Name     string   `json:"name"`
+	TTL      int      `json:"ttl,omitempty"`
+	Targets  []string `json:"targets,omitempty"`
+}
+
+// dryRunRecorder collects edge DNS writes of providers built for dry run. It is used under the lock of DryRunProvider
+type dryRunRecorder struct {
+	changes []dryRunChange
+}
+
+func (r *dryRunRecorder) record(provider fmt.Stringer, change dryRunChange) {
+	change.Provider = provider.String()
+	r.changes = append(r.changes, change)
+}
+
+// take returns recorded changes and clears the recorder
+func (r *dryRunRecorder) take() (changes []dryRunChange) {
+	changes, r.changes = r.changes, nil
+	return
+}
+
+func NewDryRunDNS(assistant assistant.IAssistant, client client.Client, provider IDnsProvider, recorder *dryRunRecorder) *DryRunProvider {
+	return &DryRunProvider{
+		assistant: assistant,
+		client:    client,
+		provider:  provider,
+		recorder:  recorder,
+	}
+}
+
+func (p *DryRunProvider) CreateZoneDelegationForExternalDNS(gslb *k8gbv1beta1.Gslb) error {
+	return p.run(gslb, func() error {
+		return p.provider.CreateZoneDelegationForExternalDNS(gslb)
+	})
+}
+
+func (p *DryRunProvider) Finalize(gslb *k8gbv1beta1.Gslb, others []k8gbv1beta1.Gslb) error {
+	return p.run(gslb, func() error {
+		return p.provider.Finalize(gslb, others)
+	})
+}
+
+func (p *DryRunProvider) GetExternalTargets(host string) (targets []string) {
+	return p.provider.GetExternalTargets(host)
+}
+
+func (p *DryRunProvider) ExternalClustersStatus(gslb *k8gbv1beta1.Gslb) []k8gbv1beta1.PeerStatus {
+	return p.provider.ExternalClustersStatus(gslb)
+}
+
+func (p *DryRunProvider) ExternalHeartbeats(gslb *k8gbv1beta1.Gslb) []Heartbeat {
+	return p.provider.ExternalHeartbeats(gslb)
+}
+
+func (p *DryRunProvider) GslbIngressExposedIPs(gslb *k8gbv1beta1.Gslb) ([]string, error) {
+	return p.provider.GslbIngressExposedIPs(gslb)
+}
+
+func (p *DryRunProvider) SaveDNSEndpoint(gslb *k8gbv1beta1.Gslb, i *externaldns.DNSEndpoint) error {
+	return p.provider.SaveDNSEndpoint(gslb, i)
+}
+
+func (p *DryRunProvider) String() string {
+	return fmt.Sprintf("DryRun(%s)", p.provider)
+}
+
+// dryRunInfobloxConnector reads objects through the wrapped connector, but only records the objects it would write
+type dryRunInfobloxConnector struct {
+	ibclient.IBConnector
+	provider fmt.Stringer
+	recorder *dryRunRecorder
+}
+
+func (c *dryRunInfobloxConnector) CreateObject(obj ibclient.IBObject) (string, error) {
+	c.recorder.record(c.provider, infobloxChange(obj, ""))
+	return "", nil
+}
+
+func (c *dryRunInfobloxConnector) UpdateObject(obj ibclient.IBObject, ref string) (string, error) {
+	c.recorder.record(c.provider, infobloxChange(obj, ref))
+	return ref, nil
+}
+
+func (c *dryRunInfobloxConnector) DeleteObject(ref string) (string, error) {
+	recordType, name := parseInfobloxRef(ref)
+	c.recorder.record(c.provider, dryRunChange{Action: dryRunActionDelete, Type: recordType, Name: name})
+	return ref, nil
+}
+
+// infobloxChange describes creation or update of delegated zone or TXT record. Updated objects carry reference only,
+// so their name is taken from ref
+func infobloxChange(obj ibclient.IBObject, ref string) dryRunChange {
+	change := dryRunChange{Action: dryRunActionUpsert}
+	switch o := obj.(type) {
+	case *ibclient.ZoneDelegated:
+		change.Type, change.Name = "NS", o.Fqdn
+		for _, ns := range o.DelegateTo {
+			change.Targets = append(change.Targets, fmt.Sprintf("%s(%s)", ns.Name, ns.Address))
+		}
+	case *ibclient.RecordTXT:
+		change.Type, change.Name, change.TTL, change.Targets = "TXT", o.Name, o.TTL, []string{o.Text}
+	}
+	if change.Name == "" {
+		_, change.Name = parseInfobloxRef(ref)
+	}
+	return change
+}
+
+// parseInfobloxRef returns record type and name of WAPI object reference <object type>/<id>:<name>/<view>
+func parseInfobloxRef(ref string) (recordType, name string) {
+	slash := strings.Index(ref, "/")
+	if slash < 0 {
+		return "", ref
+	}
+	switch ref[:slash] {
+	case "zone_delegated":
+		recordType = "NS"
+	case "record:txt":
+		recordType = "TXT"
+	default:
+		recordType = ref[:slash]
+	}
+	name = ref[slash+1:]
+	if colon := strings.Index(name, ":"); colon >= 0 {
+		name = name[colon+1:]
+	}
+	if view := strings.LastIndex(name, "/"); view >= 0 {
+		name = name[:view]
+	}
+	return
+}
+
+// run executes operation of the wrapped provider and stores edge DNS writes it intended. Operations are serialized,
+// so writes of distinct Gslbs are never mixed
+func (p *DryRunProvider) run(gslb *k8gbv1beta1.Gslb, operation func() error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.recorder.take()
+	err := operation()
+	changes := p.recorder.take()
+	if err != nil {
+		return err
+	}
+	return p.record(gslb, changes)
+}
+
+// record logs changes and stores them into ConfigMap owned by gslb, so it is garbage collected together with gslb
+func (p *DryRunProvider) record(gslb *k8gbv1beta1.Gslb, changes []dryRunChange) error {
+	for _, c := range changes {
+		p.assistant.Info("[dry-run] %s would %s %s record %s (ttl=%d) %v", c.Provider, c.Action, c.Type, c.Name, c.TTL, c.Targets)
+	}
+	if changes == nil {
+		changes = []dryRunChange{}
+	}
+	raw, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{}
+	nn := types.NamespacedName{Namespace: gslb.Namespace, Name: fmt.Sprintf("%s-dryrun", gslb.Name)}
+	err = p.client.Get(context.TODO(), nn, cm)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	data := map[string]string{"provider": fmt.Sprintf("%s", p.provider), "changes": string(raw)}
+	if errors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      nn.Name,
+				Namespace: nn.Namespace,
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: k8gbv1beta1.GroupVersion.String(),
+					Kind:       "Gslb",
+					Name:       gslb.Name,
+					UID:        gslb.UID,
+				}},
+			},
+			Data: data,
+		}
+		return p.client.Create(context.TODO(), cm)
+	}
+	cm.Data = data
+	return p.client.Update(context.TODO(), cm)
+}
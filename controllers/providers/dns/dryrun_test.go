@@ -0,0 +1,274 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestDryRunRecordsZoneDelegation(t *testing.T) {
+	// arrange
+	config := predefinedConfig
+	config.EdgeDNSType = depresolver.DNSTypeRoute53
+	provider, cl, gslb := newTestDryRunProvider(t, config)
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	require.NoError(t, err)
+	assertNoDNSEndpoint(t, cl, "k8gb-ns-route53")
+	data, changes := getDryRunChanges(t, cl, gslb)
+	assert.Equal(t, "ROUTE53", data["provider"])
+	assert.Equal(t, []dryRunChange{
+		{Provider: "ROUTE53", Action: dryRunActionUpsert, Type: "NS", Name: "cloud.example.com", TTL: gslb.Spec.Strategy.DNSTtlSeconds,
+			Targets: []string{"gslb-ns-cloud-example-com-us-east-1.example.com", "gslb-ns-cloud-example-com-us-west-1.example.com"}},
+		{Provider: "ROUTE53", Action: dryRunActionUpsert, Type: "A", Name: "gslb-ns-cloud-example-com-us-west-1.example.com",
+			TTL: gslb.Spec.Strategy.DNSTtlSeconds, Targets: []string{"10.0.0.1", "10.0.0.2"}},
+	}, changes)
+}
+
+func TestDryRunFollowsClusterPeers(t *testing.T) {
+	// arrange
+	config := predefinedConfig
+	config.EdgeDNSType = depresolver.DNSTypeRoute53
+	peer := clusterPeer("us-east-1", "", false)
+	provider, cl, gslb := newTestDryRunProvider(t, config, &peer)
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	require.NoError(t, err)
+	_, changes := getDryRunChanges(t, cl, gslb)
+	require.Len(t, changes, 2)
+	assert.Equal(t, []string{"gslb-ns-cloud-example-com-us-west-1.example.com"}, changes[0].Targets,
+		"cluster disabled by ClusterPeer is not delegated")
+}
+
+func TestDryRunRecordsInfobloxDelegationAndHeartbeat(t *testing.T) {
+	// arrange
+	config := predefinedConfig
+	config.EdgeDNSType = depresolver.DNSTypeInfoblox
+	config.K8gbVersion = "v0.7.1"
+	config.ClusterCapacity = 50
+	config.PeerAuth.HeartbeatSecret = "secret"
+	provider, cl, gslb := newTestDryRunProvider(t, config)
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	require.NoError(t, err)
+	err = provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	require.NoError(t, err)
+	_, changes := getDryRunChanges(t, cl, gslb)
+	require.Len(t, changes, 2)
+	assert.Equal(t, dryRunChange{Provider: "Infoblox", Action: dryRunActionUpsert, Type: "NS", Name: "cloud.example.com",
+		Targets: []string{"gslb-ns-cloud-example-com-us-west-1.example.com(10.0.0.1)", "gslb-ns-cloud-example-com-us-west-1.example.com(10.0.0.2)"}},
+		changes[0])
+	assert.Equal(t, "TXT", changes[1].Type)
+	assert.Equal(t, "test-gslb-heartbeat-us-west-1.example.com", changes[1].Name)
+	require.Len(t, changes[1].Targets, 1)
+	payload, err := utils.VerifyHeartbeat(changes[1].Targets[0], changes[1].Name, "us-west-1", "secret")
+	require.NoError(t, err)
+	heartbeat, err := utils.ParseHeartbeat(payload)
+	require.NoError(t, err)
+	assert.Equal(t, utils.HeartbeatVersion, heartbeat.Version)
+	assert.Equal(t, "v0.7.1", heartbeat.OperatorVersion)
+	assert.Equal(t, "us-west-1", heartbeat.GeoTag)
+	assert.Equal(t, 50, heartbeat.Capacity)
+	assert.False(t, heartbeat.Draining)
+}
+
+func TestDryRunRecordsLegacyHeartbeat(t *testing.T) {
+	// arrange
+	config := predefinedConfig
+	config.EdgeDNSType = depresolver.DNSTypeInfoblox
+	config.LegacyHeartbeat = true
+	config.PeerAuth.HeartbeatSecret = "secret"
+	provider, cl, gslb := newTestDryRunProvider(t, config)
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	require.NoError(t, err)
+	_, changes := getDryRunChanges(t, cl, gslb)
+	require.Len(t, changes, 2)
+	payload, err := utils.VerifyHeartbeat(changes[1].Targets[0], changes[1].Name, "us-west-1", "secret")
+	require.NoError(t, err)
+	heartbeat, err := utils.ParseHeartbeat(payload)
+	require.NoError(t, err)
+	assert.Equal(t, 0, heartbeat.Version, "older clusters read bare timestamp only")
+	assert.WithinDuration(t, time.Now(), heartbeat.Timestamp, time.Minute)
+}
+
+func TestDryRunRecordsZoneDelegationOfEveryZone(t *testing.T) {
+	// arrange
+	config := multiZoneConfig()
+	config.EdgeDNSType = depresolver.DNSTypeRoute53
+	provider, cl, gslb := newTestDryRunProvider(t, config)
+	gslb.Spec.Ingress.Rules = append(gslb.Spec.Ingress.Rules, v1beta1.IngressRule{Host: "app.api.example.org"})
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	require.NoError(t, err)
+	_, changes := getDryRunChanges(t, cl, gslb)
+	var names []string
+	for _, c := range changes {
+		names = append(names, c.Type+" "+c.Name)
+	}
+	assert.Equal(t, []string{
+		"NS cloud.example.com",
+		"A gslb-ns-cloud-example-com-us-west-1.example.com",
+		"NS api.example.org",
+		"A gslb-ns-api-example-org-us-west-1.example.org",
+	}, names)
+}
+
+func TestDryRunReadsButDoesNotWriteCloudflare(t *testing.T) {
+	// arrange
+	cf := newFakeCloudflare("secret",
+		cloudflareRecord{Type: "A", Name: "gslb-ns-cloud-example-com-us-west-1.example.com", Content: "10.9.9.9", TTL: 30},
+	)
+	server := httptest.NewServer(cf)
+	t.Cleanup(server.Close)
+	config := predefinedConfig
+	config.EdgeDNSType = depresolver.DNSTypeCloudflare
+	config.Cloudflare = depresolver.Cloudflare{ZoneID: "zone", APIToken: "secret"}
+	provider, cl, gslb := newTestDryRunProvider(t, config)
+	provider.provider.(*CloudflareProvider).endpoint = server.URL
+	gslb.Spec.Strategy.DNSTtlSeconds = 30
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, 0, cf.deleted)
+	assert.Len(t, cf.records, 1)
+	_, changes := getDryRunChanges(t, cl, gslb)
+	assert.Contains(t, changes, dryRunChange{Provider: "Cloudflare", Action: dryRunActionDelete, Type: "A",
+		Name: "gslb-ns-cloud-example-com-us-west-1.example.com", TTL: 30, Targets: []string{"10.9.9.9"}}, "stale glue record is removed")
+	assert.Contains(t, changes, dryRunChange{Provider: "Cloudflare", Action: dryRunActionUpsert, Type: "A",
+		Name: "gslb-ns-cloud-example-com-us-west-1.example.com", TTL: 1, Targets: []string{"10.0.0.1"}})
+}
+
+func TestDryRunRecordsFinalize(t *testing.T) {
+	// arrange
+	config := predefinedConfig
+	config.EdgeDNSType = depresolver.DNSTypeRoute53
+	provider, cl, gslb := newTestDryRunProvider(t, config)
+	// act
+	err := provider.Finalize(gslb, nil)
+	// assert
+	require.NoError(t, err)
+	_, changes := getDryRunChanges(t, cl, gslb)
+	assert.Equal(t, []dryRunChange{
+		{Provider: "ROUTE53", Action: dryRunActionDelete, Type: "NS", Name: "cloud.example.com"},
+		{Provider: "ROUTE53", Action: dryRunActionDelete, Type: "A", Name: "gslb-ns-cloud-example-com-us-west-1.example.com"},
+	}, changes)
+}
+
+func TestDryRunRecordsFinalizeKeepingDelegationOfOtherGslbs(t *testing.T) {
+	// arrange
+	config := predefinedConfig
+	config.EdgeDNSType = depresolver.DNSTypeInfoblox
+	provider, cl, gslb := newTestDryRunProvider(t, config)
+	other := *gslb
+	other.Name = "other-gslb"
+	// act
+	err := provider.Finalize(gslb, []k8gbv1beta1.Gslb{other})
+	// assert
+	require.NoError(t, err)
+	_, changes := getDryRunChanges(t, cl, gslb)
+	assert.Empty(t, changes, "delegation is kept and fake infoblox has no heartbeat of gslb")
+}
+
+func TestParseInfobloxRef(t *testing.T) {
+	// arrange
+	refs := map[string][2]string{
+		"zone_delegated/ZG5zLnpvbmU:cloud.example.com/default":            {"NS", "cloud.example.com"},
+		"record:txt/ZG5zLmJpbmRfdHh0:test-gslb-heartbeat-eu.example.com/": {"TXT", "test-gslb-heartbeat-eu.example.com"},
+		"unknown": {"", "unknown"},
+	}
+	for ref, expected := range refs {
+		// act
+		recordType, name := parseInfobloxRef(ref)
+		// assert
+		assert.Equal(t, expected, [2]string{recordType, name}, ref)
+	}
+}
+
+func TestFactoryDryRun(t *testing.T) {
+	// arrange
+	log := ctrl.Log.WithName("dummy")
+	cl := fake.NewFakeClientWithScheme(scheme.Scheme, []runtime.Object{}...)
+	customConfig := predefinedConfig
+	customConfig.EdgeDNSType = depresolver.DNSTypeInfoblox
+	customConfig.DryRun = true
+	// act
+	f, err := NewDNSProviderFactory(cl, customConfig, log, nil)
+	require.NoError(t, err)
+	provider := f.Provider()
+	// assert
+	assert.NotNil(t, provider)
+	assert.Equal(t, "*DryRunProvider", utils.GetType(provider))
+	assert.Equal(t, "DryRun(Infoblox)", fmt.Sprintf("%s", provider))
+}
+
+// newTestDryRunProvider returns dry run provider built by factory from config and gslb exposed by ingress with IPs
+// 10.0.0.1 and 10.0.0.2
+func newTestDryRunProvider(t *testing.T, config depresolver.Config, objects ...runtime.Object) (*DryRunProvider, client.Client, *k8gbv1beta1.Gslb) {
+	gslb := getGSLB(t)
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	require.NoError(t, k8gbv1beta1.AddToScheme(s))
+	s.AddKnownTypes(schema.GroupVersion{Group: "externaldns.k8s.io", Version: "v1alpha1"}, &externaldns.DNSEndpoint{})
+	cl := fake.NewFakeClientWithScheme(s, append(objects, gslbIngress(gslb, "10.0.0.1", "10.0.0.2"))...)
+	config.DryRun = true
+	f, err := NewDNSProviderFactory(cl, config, ctrl.Log.WithName("dummy"), nil)
+	require.NoError(t, err)
+	return f.Provider().(*DryRunProvider), cl, gslb
+}
+
+func assertNoDNSEndpoint(t *testing.T, cl client.Client, name string) {
+	err := cl.Get(context.TODO(), types.NamespacedName{Namespace: predefinedConfig.K8gbNamespace, Name: name}, &externaldns.DNSEndpoint{})
+	assert.True(t, errors.IsNotFound(err), "DNSEndpoint %s must not be written in dry run", name)
+}
+
+func getDryRunChanges(t *testing.T, cl client.Client, gslb *k8gbv1beta1.Gslb) (map[string]string, []dryRunChange) {
+	cm := &corev1.ConfigMap{}
+	err := cl.Get(context.TODO(), types.NamespacedName{Namespace: gslb.Namespace, Name: gslb.Name + "-dryrun"}, cm)
+	require.NoError(t, err)
+	var changes []dryRunChange
+	require.NoError(t, json.Unmarshal([]byte(cm.Data["changes"]), &changes))
+	return cm.Data, changes
+}
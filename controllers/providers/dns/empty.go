@@ -40,20 +40,29 @@ func (p *EmptyDNSProvider) CreateZoneDelegationForExternalDNS(*k8gbv1beta1.Gslb)
 	return
 }
 
+func (p *EmptyDNSProvider) ExternalHeartbeats(*k8gbv1beta1.Gslb) []Heartbeat {
+	return nil
+}
+
 func (p *EmptyDNSProvider) GslbIngressExposedIPs(gslb *k8gbv1beta1.Gslb) (r []string, err error) {
 	return p.assistant.GslbIngressExposedIPs(gslb)
 }
 
 func (p *EmptyDNSProvider) GetExternalTargets(host string) (targets []string) {
-	return p.assistant.GetExternalTargets(host, p.config.Override.FakeDNSEnabled, nsServerNameExt(p.config))
+	return externalTargets(p.config, p.assistant, host)
+}
+
+func (p *EmptyDNSProvider) ExternalClustersStatus(gslb *k8gbv1beta1.Gslb) []k8gbv1beta1.PeerStatus {
+	return externalClustersStatus(p.config, p.assistant, gslb)
 }
 
 func (p *EmptyDNSProvider) SaveDNSEndpoint(gslb *k8gbv1beta1.Gslb, i *externaldns.DNSEndpoint) error {
 	return p.assistant.SaveDNSEndpoint(gslb.Namespace, i)
 }
 
-func (p *EmptyDNSProvider) Finalize(gslb *k8gbv1beta1.Gslb) (err error) {
-	return p.assistant.RemoveEndpoint(gslb.Name)
+// Finalize does nothing, there is no edge DNS to clean up. DNSEndpoint of the Gslb is removed by the reconciler
+func (p *EmptyDNSProvider) Finalize(*k8gbv1beta1.Gslb, []k8gbv1beta1.Gslb) (err error) {
+	return
 }
 
 func (p *EmptyDNSProvider) String() string {
@@ -41,6 +41,7 @@ type ExternalDNSProvider struct {
 	dnsType      ExternalDNSType
 	config       depresolver.Config
 	endpointName string
+	dryRun       *dryRunRecorder
 }
 
 func NewExternalDNS(dnsType ExternalDNSType, config depresolver.Config, assistant assistant2.IAssistant) *ExternalDNSProvider {
@@ -57,7 +58,7 @@ func (p *ExternalDNSProvider) CreateZoneDelegationForExternalDNS(gslb *k8gbv1bet
 	p.assistant.Info("Creating/Updating DNSEndpoint CRDs for %s...", p)
 	var NSServerList []string
 	NSServerList = append(NSServerList, nsServerName(p.config))
-	NSServerList = append(NSServerList, nsServerNameExt(p.config)...)
+	NSServerList = append(NSServerList, nsServerNameExt(p.config, clusterPeers(p.assistant)...)...)
 	sort.Strings(NSServerList)
 	var NSServerIPs []string
 	var err error
@@ -92,6 +93,13 @@ func (p *ExternalDNSProvider) CreateZoneDelegationForExternalDNS(gslb *k8gbv1bet
 			},
 		},
 	}
+	if p.dryRun != nil {
+		for _, e := range NSRecord.Spec.Endpoints {
+			p.dryRun.record(p, dryRunChange{Action: dryRunActionUpsert, Type: e.RecordType, Name: e.DNSName,
+				TTL: int(e.RecordTTL), Targets: e.Targets})
+		}
+		return nil
+	}
 	err = p.assistant.SaveDNSEndpoint(p.config.K8gbNamespace, NSRecord)
 	if err != nil {
 		return err
@@ -99,12 +107,29 @@ func (p *ExternalDNSProvider) CreateZoneDelegationForExternalDNS(gslb *k8gbv1bet
 	return nil
 }
 
-func (p *ExternalDNSProvider) Finalize(*k8gbv1beta1.Gslb) error {
+func (p *ExternalDNSProvider) Finalize(_ *k8gbv1beta1.Gslb, others []k8gbv1beta1.Gslb) error {
+	if len(others) > 0 {
+		p.assistant.Info("Keeping DNSEndpoint %s delegating zone(%s) to %d other Gslbs", p.endpointName, p.config.DNSZone, len(others))
+		return nil
+	}
+	if p.dryRun != nil {
+		p.dryRun.record(p, dryRunChange{Action: dryRunActionDelete, Type: "NS", Name: p.config.DNSZone})
+		p.dryRun.record(p, dryRunChange{Action: dryRunActionDelete, Type: "A", Name: nsServerName(p.config)})
+		return nil
+	}
 	return p.assistant.RemoveEndpoint(p.endpointName)
 }
 
 func (p *ExternalDNSProvider) GetExternalTargets(host string) (targets []string) {
-	return p.assistant.GetExternalTargets(host, p.config.Override.FakeDNSEnabled, nsServerNameExt(p.config))
+	return externalTargets(p.config, p.assistant, host)
+}
+
+func (p *ExternalDNSProvider) ExternalClustersStatus(gslb *k8gbv1beta1.Gslb) []k8gbv1beta1.PeerStatus {
+	return externalClustersStatus(p.config, p.assistant, gslb)
+}
+
+func (p *ExternalDNSProvider) ExternalHeartbeats(*k8gbv1beta1.Gslb) []Heartbeat {
+	return nil
 }
 
 func (p *ExternalDNSProvider) GslbIngressExposedIPs(gslb *k8gbv1beta1.Gslb) ([]string, error) {
@@ -17,22 +17,35 @@ limitations under the License.
 package dns
 
 import (
+	"crypto/tls"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
+	"github.com/AbsaOSS/k8gb/controllers/peerstatus"
 	"github.com/AbsaOSS/k8gb/controllers/providers/assistant"
+	"github.com/AbsaOSS/k8gb/controllers/providers/metrics"
 
 	"github.com/go-logr/logr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type ProviderFactory struct {
-	config depresolver.Config
-	client client.Client
-	log    logr.Logger
+	config     depresolver.Config
+	client     client.Client
+	log        logr.Logger
+	metrics    *metrics.PrometheusMetrics
+	tlsConfig  *tls.Config
+	peerStatus *peerstatus.Client
+	recorder   *utils.EventRecorder
+	peerState  *assistant.PeerState
 }
 
-func NewDNSProviderFactory(client client.Client, config depresolver.Config, log logr.Logger) (f *ProviderFactory, err error) {
+// NewDNSProviderFactory creates factory of DNS providers. Metrics are optional; when set, DNS queries to external
+// clusters are observed
+func NewDNSProviderFactory(client client.Client, config depresolver.Config, log logr.Logger, metrics *metrics.PrometheusMetrics) (f *ProviderFactory, err error) {
 	if log == nil {
 		err = fmt.Errorf("nil log")
 	}
@@ -40,24 +53,130 @@ func NewDNSProviderFactory(client client.Client, config depresolver.Config, log
 		err = fmt.Errorf("nil client")
 	}
 	f = &ProviderFactory{
-		config: config,
-		log:    log,
-		client: client,
+		config:  config,
+		log:     log,
+		client:  client,
+		metrics: metrics,
+	}
+	if err == nil && config.DoT.Enabled {
+		f.tlsConfig, err = utils.NewTLSClientConfig(config.DoT.CAFile, config.DoT.ServerName)
+	}
+	if err == nil && config.PeerStatus.Token != "" {
+		var tlsConfig *tls.Config
+		if tlsConfig, err = utils.NewTLSClientConfig(config.PeerStatus.CAFile, ""); err == nil {
+			f.peerStatus = peerstatus.NewClient(config.PeerStatus.Token, tlsConfig, time.Duration(config.PeerStatus.Timeout)*time.Second)
+		}
 	}
 	return
 }
 
+// WithEventRecorder makes providers record Kubernetes events of Gslbs, e.g. when an external cluster is filtered out
+// from the zone delegation. The recorder is shared with the Gslb controller, so the events are forgotten when Gslb
+// is finalized
+func (f *ProviderFactory) WithEventRecorder(recorder *utils.EventRecorder) *ProviderFactory {
+	f.recorder = recorder
+	return f
+}
+
+// WithPeerState makes providers share cached answers and observations of external clusters with providers created
+// before, e.g. with the provider replaced by configuration reload
+func (f *ProviderFactory) WithPeerState(state *assistant.PeerState) *ProviderFactory {
+	f.peerState = state
+	return f
+}
+
 func (f *ProviderFactory) Provider() (provider IDnsProvider) {
 	a := assistant.NewGslbAssistant(f.client, f.log, f.config.K8gbNamespace, f.config.EdgeDNSServer)
-	switch f.config.EdgeDNSType {
+	if f.metrics != nil {
+		a.WithPeerQueryObserver(f.metrics)
+	}
+	if f.tlsConfig != nil {
+		a.WithTLS(f.tlsConfig)
+	}
+	if f.peerStatus != nil {
+		a.WithPeerStatus(f.peerStatus)
+	}
+	if f.recorder != nil {
+		a.WithEventRecorder(f.recorder)
+	}
+	if f.peerState != nil {
+		a.WithPeerState(f.peerState)
+	}
+	var tsig *utils.TSIG
+	if f.config.PeerAuth.TSIGKeyName != "" {
+		tsig = utils.NewTSIG(f.config.PeerAuth.TSIGKeyName, f.config.PeerAuth.TSIGSecret, f.config.PeerAuth.TSIGAlgorithm)
+	}
+	a.WithPeerAuth(tsig, f.config.PeerAuth.HeartbeatSecret)
+	var recorder *dryRunRecorder
+	if f.config.DryRun {
+		recorder = &dryRunRecorder{}
+	}
+	var zoneProviders []IDnsProvider
+	for _, zone := range f.config.DelegationZones() {
+		zoneProviders = append(zoneProviders, f.edgeDNSProvider(f.config.ForZone(zone), a, recorder))
+	}
+	provider = zoneProviders[0]
+	if len(zoneProviders) > 1 {
+		provider = NewMultiZoneDNS(f.config, zoneProviders...)
+	}
+	if f.config.DryRun {
+		provider = NewDryRunDNS(a, f.client, provider, recorder)
+	}
+	return
+}
+
+// edgeDNSProvider returns provider of all enabled edge DNS types delegating zone of config. Providers given dry run
+// recorder record their edge DNS writes into it instead of applying them
+func (f *ProviderFactory) edgeDNSProvider(config depresolver.Config, a assistant.IAssistant, recorder *dryRunRecorder) (provider IDnsProvider) {
+	var providers []IDnsProvider
+	for _, t := range []depresolver.EdgeDNSType{depresolver.DNSTypeInfoblox, depresolver.DNSTypeRoute53, depresolver.DNSTypeNS1,
+		depresolver.DNSTypeCloudflare, depresolver.DNSTypePlugin} {
+		if config.EdgeDNSType&t == t {
+			providers = append(providers, f.provider(config, t, a, recorder))
+		}
+	}
+	switch len(providers) {
+	case 0:
+		provider = f.provider(config, config.EdgeDNSType, a, recorder)
+	case 1:
+		provider = providers[0]
+	default:
+		provider = NewCompositeDNS(a, providers...)
+	}
+	return
+}
+
+func (f *ProviderFactory) provider(config depresolver.Config, t depresolver.EdgeDNSType, a assistant.IAssistant, recorder *dryRunRecorder) (provider IDnsProvider) {
+	switch t {
 	case depresolver.DNSTypeNS1:
-		provider = NewExternalDNS(externalDNSTypeNS1, f.config, a)
+		provider = f.externalDNS(externalDNSTypeNS1, config, a, recorder)
 	case depresolver.DNSTypeRoute53:
-		provider = NewExternalDNS(externalDNSTypeRoute53, f.config, a)
+		provider = f.externalDNS(externalDNSTypeRoute53, config, a, recorder)
 	case depresolver.DNSTypeInfoblox:
-		provider = NewInfobloxDNS(f.config, a)
+		infoblox := NewInfobloxDNS(config, a)
+		infoblox.dryRun = recorder
+		provider = infoblox
+	case depresolver.DNSTypeCloudflare:
+		cloudflare := NewCloudflareDNS(config, a)
+		cloudflare.dryRun = recorder
+		provider = cloudflare
+	case depresolver.DNSTypePlugin:
+		plugin := NewPluginDNS(config, a)
+		plugin.dryRun = recorder
+		provider = plugin
 	case depresolver.DNSTypeNoEdgeDNS:
-		provider = NewEmptyDNS(f.config, a)
+		provider = NewEmptyDNS(config, a)
 	}
 	return
 }
+
+// externalDNS returns external-dns provider of zone of config. DNSEndpoint of DNSZone keeps its original name, so
+// that configuring additional zones doesn't orphan it. DNSEndpoints of additional zones are named after the zone
+func (f *ProviderFactory) externalDNS(dnsType ExternalDNSType, config depresolver.Config, a assistant.IAssistant, recorder *dryRunRecorder) *ExternalDNSProvider {
+	provider := NewExternalDNS(dnsType, config, a)
+	provider.dryRun = recorder
+	if config.DNSZone != f.config.DNSZone {
+		provider.endpointName = fmt.Sprintf("%s-%s", provider.endpointName, strings.ReplaceAll(config.DNSZone, ".", "-"))
+	}
+	return provider
+}
@@ -37,7 +37,7 @@ func TestFactoryInfoblox(t *testing.T) {
 	customConfig := predefinedConfig
 	customConfig.EdgeDNSType = depresolver.DNSTypeInfoblox
 	// act
-	f, err := NewDNSProviderFactory(client, customConfig, log)
+	f, err := NewDNSProviderFactory(client, customConfig, log, nil)
 	require.NoError(t, err)
 	provider := f.Provider()
 	// assert
@@ -53,7 +53,7 @@ func TestFactoryNS1(t *testing.T) {
 	customConfig := predefinedConfig
 	customConfig.EdgeDNSType = depresolver.DNSTypeNS1
 	// act
-	f, err := NewDNSProviderFactory(client, customConfig, log)
+	f, err := NewDNSProviderFactory(client, customConfig, log, nil)
 	require.NoError(t, err)
 	provider := f.Provider()
 	// assert
@@ -69,7 +69,7 @@ func TestFactoryRoute53(t *testing.T) {
 	customConfig := predefinedConfig
 	customConfig.EdgeDNSType = depresolver.DNSTypeRoute53
 	// act
-	f, err := NewDNSProviderFactory(client, customConfig, log)
+	f, err := NewDNSProviderFactory(client, customConfig, log, nil)
 	require.NoError(t, err)
 	provider := f.Provider()
 	// assert
@@ -85,7 +85,7 @@ func TestFactoryNoEdgeDNS(t *testing.T) {
 	customConfig := predefinedConfig
 	customConfig.EdgeDNSType = depresolver.DNSTypeNoEdgeDNS
 	// act
-	f, err := NewDNSProviderFactory(client, customConfig, log)
+	f, err := NewDNSProviderFactory(client, customConfig, log, nil)
 	require.NoError(t, err)
 	provider := f.Provider()
 	// assert
@@ -100,7 +100,7 @@ func TestFactoryNilLogger(t *testing.T) {
 	customConfig.EdgeDNSType = depresolver.DNSTypeNoEdgeDNS
 	// act
 	// assert
-	_, err := NewDNSProviderFactory(nil, customConfig, log)
+	_, err := NewDNSProviderFactory(nil, customConfig, log, nil)
 	require.Error(t, err)
 }
 
@@ -111,6 +111,69 @@ func TestFactoryNilClient(t *testing.T) {
 	customConfig.EdgeDNSType = depresolver.DNSTypeNoEdgeDNS
 	// act
 	// assert
-	_, err := NewDNSProviderFactory(client, customConfig, nil)
+	_, err := NewDNSProviderFactory(client, customConfig, nil, nil)
 	require.Error(t, err)
 }
+
+func TestFactoryWithInvalidDoTCAFile(t *testing.T) {
+	// arrange
+	log := ctrl.Log.WithName("dummy")
+	client := fake.NewFakeClientWithScheme(scheme.Scheme, []runtime.Object{}...)
+	customConfig := predefinedConfig
+	customConfig.DoT = depresolver.DoT{Enabled: true, CAFile: "/non/existing/ca.crt"}
+	// act
+	// assert
+	_, err := NewDNSProviderFactory(client, customConfig, log, nil)
+	require.Error(t, err)
+}
+
+func TestFactoryInfobloxAndRoute53(t *testing.T) {
+	// arrange
+	log := ctrl.Log.WithName("dummy")
+	client := fake.NewFakeClientWithScheme(scheme.Scheme, []runtime.Object{}...)
+	customConfig := predefinedConfig
+	customConfig.EdgeDNSType = depresolver.DNSTypeInfoblox | depresolver.DNSTypeRoute53
+	// act
+	f, err := NewDNSProviderFactory(client, customConfig, log, nil)
+	require.NoError(t, err)
+	provider := f.Provider()
+	// assert
+	assert.NotNil(t, provider)
+	assert.Equal(t, "*CompositeDNSProvider", utils.GetType(provider))
+	assert.Equal(t, "Infoblox,ROUTE53", fmt.Sprintf("%s", provider))
+}
+
+func TestFactoryCloudflare(t *testing.T) {
+	// arrange
+	log := ctrl.Log.WithName("dummy")
+	client := fake.NewFakeClientWithScheme(scheme.Scheme, []runtime.Object{}...)
+	customConfig := predefinedConfig
+	customConfig.EdgeDNSType = depresolver.DNSTypeCloudflare
+	// act
+	f, err := NewDNSProviderFactory(client, customConfig, log, nil)
+	require.NoError(t, err)
+	provider := f.Provider()
+	// assert
+	assert.NotNil(t, provider)
+	assert.Equal(t, "*CloudflareProvider", utils.GetType(provider))
+	assert.Equal(t, "Cloudflare", fmt.Sprintf("%s", provider))
+}
+
+func TestFactoryMultiZone(t *testing.T) {
+	// arrange
+	log := ctrl.Log.WithName("dummy")
+	client := fake.NewFakeClientWithScheme(scheme.Scheme, []runtime.Object{}...)
+	customConfig := multiZoneConfig()
+	customConfig.EdgeDNSType = depresolver.DNSTypeRoute53
+	// act
+	f, err := NewDNSProviderFactory(client, customConfig, log, nil)
+	require.NoError(t, err)
+	provider := f.Provider()
+	// assert
+	require.Equal(t, "*MultiZoneDNSProvider", utils.GetType(provider))
+	assert.Equal(t, "ROUTE53", fmt.Sprintf("%s", provider))
+	multiZone := provider.(*MultiZoneDNSProvider)
+	assert.Equal(t, "k8gb-ns-route53", multiZone.providers["cloud.example.com"].(*ExternalDNSProvider).endpointName)
+	assert.Equal(t, "k8gb-ns-route53-api-example-org", multiZone.providers["api.example.org"].(*ExternalDNSProvider).endpointName)
+	assert.Equal(t, "example.org", multiZone.providers["api.example.org"].(*ExternalDNSProvider).config.EdgeDNSZone)
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package dns
 
 import (
+	"time"
+
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
 	externaldns "sigs.k8s.io/external-dns/endpoint"
 )
@@ -28,8 +30,26 @@ type IDnsProvider interface {
 	GslbIngressExposedIPs(*k8gbv1beta1.Gslb) ([]string, error)
 	// GetExternalTargets retrieves list of external targets for specified host
 	GetExternalTargets(string) []string
+	// ExternalClustersStatus describes external clusters as observed by the last GetExternalTargets of Gslb hosts and
+	// by the last inspection of their heartbeats
+	ExternalClustersStatus(*k8gbv1beta1.Gslb) []k8gbv1beta1.PeerStatus
+	// ExternalHeartbeats returns heartbeats of Gslb in external clusters which zone delegation is filtered by; nil
+	// when the provider doesn't use heartbeats. Heartbeats are inspected by their Alive
+	ExternalHeartbeats(*k8gbv1beta1.Gslb) []Heartbeat
 	// SaveDNSEndpoint update DNS endpoint in gslb or create new one if doesn't exist
 	SaveDNSEndpoint(*k8gbv1beta1.Gslb, *externaldns.DNSEndpoint) error
-	// Finalize finalize gslb in k8gbNamespace
-	Finalize(*k8gbv1beta1.Gslb) error
+	// Finalize removes records of Gslb from Edge DNS, e.g. its heartbeat. Zone delegation is shared by all Gslbs of the
+	// zone, so it is removed only when others, the remaining Gslbs delegated by the zone, is empty
+	Finalize(gslb *k8gbv1beta1.Gslb, others []k8gbv1beta1.Gslb) error
+}
+
+// Heartbeat is split brain TXT record of Gslb written by external cluster. Inspection is left to the caller, so
+// heartbeat shared by several Gslbs, e.g. by Gslbs of the same name in different namespaces, can be inspected once
+type Heartbeat struct {
+	// FQDN of the TXT record
+	FQDN string
+	// Threshold after which the heartbeat is considered dead
+	Threshold time.Duration
+	// Alive inspects the heartbeat in the external cluster
+	Alive func() bool
 }
@@ -21,22 +21,23 @@ import (
 )
 
 type fakeInfobloxConnector struct {
-	// createObjectObj interface{}
+	createObjectObj interface{}
 
 	getObjectObj interface{}
 	getObjectRef string
 
 	// deleteObjectRef string
 
-	// updateObjectObj interface{}
-	// updateObjectRef string
+	updateObjectObj interface{}
+	updateObjectRef string
 
 	resultObject interface{}
 
 	fakeRefReturn string
 }
 
-func (c *fakeInfobloxConnector) CreateObject(ibclient.IBObject) (string, error) {
+func (c *fakeInfobloxConnector) CreateObject(obj ibclient.IBObject) (string, error) {
+	c.createObjectObj = obj
 	return c.fakeRefReturn, nil
 }
 
@@ -48,6 +49,8 @@ func (c *fakeInfobloxConnector) DeleteObject(string) (string, error) {
 	return c.fakeRefReturn, nil
 }
 
-func (c *fakeInfobloxConnector) UpdateObject(ibclient.IBObject, string) (string, error) {
+func (c *fakeInfobloxConnector) UpdateObject(obj ibclient.IBObject, ref string) (string, error) {
+	c.updateObjectObj = obj
+	c.updateObjectRef = ref
 	return c.fakeRefReturn, nil
 }
@@ -23,7 +23,17 @@ import (
 	ibclient "github.com/infobloxopen/infoblox-go-client"
 )
 
-func (p *InfobloxProvider) infobloxConnection() (*ibclient.ObjectManager, error) {
+// infobloxClient extends ibclient.ObjectManager by DNS view and extensible attributes.
+// ObjectManager creates delegated zones and TXT records without them, so the objects k8gb
+// owns are read and written through the connector directly.
+type infobloxClient struct {
+	*ibclient.ObjectManager
+	connector ibclient.IBConnector
+	view      string
+	ea        ibclient.EA
+}
+
+func (p *InfobloxProvider) infobloxConnection() (*infobloxClient, error) {
 	hostConfig := ibclient.HostConfig{
 		Host:     p.config.Infoblox.Host,
 		Version:  p.config.Infoblox.Version,
@@ -35,17 +45,16 @@ func (p *InfobloxProvider) infobloxConnection() (*ibclient.ObjectManager, error)
 	requestBuilder := &ibclient.WapiRequestBuilder{}
 	requestor := &ibclient.WapiHttpRequestor{}
 
-	var objMgr *ibclient.ObjectManager
+	var connector ibclient.IBConnector
 
 	if p.config.Override.FakeInfobloxEnabled {
 		fqdn := "fakezone.example.com"
 		fakeRefReturn := "zone_delegated/ZG5zLnpvbmUkLl9kZWZhdWx0LnphLmNvLmFic2EuY2Fhcy5vaG15Z2xiLmdzbGJpYmNsaWVudA:fakezone.example.com/default"
-		ohmyFakeConnector := &fakeInfobloxConnector{
+		connector = &fakeInfobloxConnector{
 			getObjectObj: ibclient.NewZoneDelegated(ibclient.ZoneDelegated{Fqdn: fqdn}),
 			getObjectRef: "",
 			resultObject: []ibclient.ZoneDelegated{*ibclient.NewZoneDelegated(ibclient.ZoneDelegated{Fqdn: fqdn, Ref: fakeRefReturn})},
 		}
-		objMgr = ibclient.NewObjectManager(ohmyFakeConnector, "ohmyclient", "")
 	} else {
 		conn, err := ibclient.NewConnector(hostConfig, transportConfig, requestBuilder, requestor)
 		if err != nil {
@@ -57,9 +66,79 @@ func (p *InfobloxProvider) infobloxConnection() (*ibclient.ObjectManager, error)
 				p.assistant.Error(err, "Failed to close connection to infoblox")
 			}
 		}()
-		objMgr = ibclient.NewObjectManager(conn, "ohmyclient", "")
+		connector = conn
+	}
+	if p.dryRun != nil {
+		connector = &dryRunInfobloxConnector{IBConnector: connector, provider: p, recorder: p.dryRun}
+	}
+	return &infobloxClient{
+		ObjectManager: ibclient.NewObjectManager(connector, "ohmyclient", p.config.Infoblox.TenantID),
+		connector:     connector,
+		view:          p.config.Infoblox.View,
+		ea:            p.extensibleAttributes(),
+	}, nil
+}
+
+// extensibleAttributes returns attributes applied to every object created or updated by k8gb
+func (p *InfobloxProvider) extensibleAttributes() ibclient.EA {
+	ea := make(ibclient.EA)
+	for name, value := range p.config.Infoblox.ExtensibleAttributes {
+		ea[name] = value
 	}
-	return objMgr, nil
+	if p.config.Infoblox.TenantID != "" {
+		ea["Tenant ID"] = p.config.Infoblox.TenantID
+	}
+	return ea
+}
+
+// getZoneDelegated returns the delegated zone from configured view or nil if zone doesn't exist
+func (c *infobloxClient) getZoneDelegated(fqdn string) (*ibclient.ZoneDelegated, error) {
+	var res []ibclient.ZoneDelegated
+	zoneDelegated := ibclient.NewZoneDelegated(ibclient.ZoneDelegated{Fqdn: fqdn, View: c.view})
+	err := c.connector.GetObject(zoneDelegated, "", &res)
+	if err != nil || len(res) == 0 {
+		return nil, err
+	}
+	return &res[0], nil
+}
+
+func (c *infobloxClient) createZoneDelegated(fqdn string, delegateTo []ibclient.NameServer) (*ibclient.ZoneDelegated, error) {
+	zoneDelegated := ibclient.NewZoneDelegated(ibclient.ZoneDelegated{Fqdn: fqdn, DelegateTo: delegateTo, View: c.view, Ea: c.ea})
+	ref, err := c.connector.CreateObject(zoneDelegated)
+	zoneDelegated.Ref = ref
+	return zoneDelegated, err
+}
+
+func (c *infobloxClient) updateZoneDelegated(ref string, delegateTo []ibclient.NameServer) (*ibclient.ZoneDelegated, error) {
+	zoneDelegated := ibclient.NewZoneDelegated(ibclient.ZoneDelegated{Ref: ref, DelegateTo: delegateTo, Ea: c.ea})
+	refResp, err := c.connector.UpdateObject(zoneDelegated, ref)
+	zoneDelegated.Ref = refResp
+	return zoneDelegated, err
+}
+
+// getTXTRecord returns TXT record from configured view or nil if record doesn't exist
+func (c *infobloxClient) getTXTRecord(name string) (*ibclient.RecordTXT, error) {
+	var res []ibclient.RecordTXT
+	recordTXT := ibclient.NewRecordTXT(ibclient.RecordTXT{Name: name, View: c.view})
+	err := c.connector.GetObject(recordTXT, "", &res)
+	if err != nil || len(res) == 0 {
+		return nil, err
+	}
+	return &res[0], nil
+}
+
+func (c *infobloxClient) createTXTRecord(name, text string, ttl int) (*ibclient.RecordTXT, error) {
+	recordTXT := ibclient.NewRecordTXT(ibclient.RecordTXT{Name: name, Text: text, TTL: ttl, View: c.view, Ea: c.ea})
+	ref, err := c.connector.CreateObject(recordTXT)
+	recordTXT.Ref = ref
+	return recordTXT, err
+}
+
+func (c *infobloxClient) updateTXTRecord(ref, text string) (*ibclient.RecordTXT, error) {
+	recordTXT := ibclient.NewRecordTXT(ibclient.RecordTXT{Ref: ref, Text: text, Ea: c.ea})
+	refResp, err := c.connector.UpdateObject(recordTXT, ref)
+	recordTXT.Ref = refResp
+	return recordTXT, err
 }
 
 func (p *InfobloxProvider) checkZoneDelegated(findZone *ibclient.ZoneDelegated) error {
@@ -27,11 +27,20 @@ import (
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
 	"github.com/AbsaOSS/k8gb/controllers/depresolver"
 	ibclient "github.com/infobloxopen/infoblox-go-client"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Reasons of events recorded while delegating zone
+const (
+	eventReasonPeerDisabled    = "PeerDisabled"
+	eventReasonPeerFilteredOut = "PeerFilteredOut"
+	eventReasonPeerDelegated   = "PeerDelegated"
 )
 
 type InfobloxProvider struct {
 	assistant assistant.IAssistant
 	config    depresolver.Config
+	dryRun    *dryRunRecorder
 }
 
 func NewInfobloxDNS(config depresolver.Config, assistant assistant.IAssistant) *InfobloxProvider {
@@ -57,7 +66,7 @@ func (p *InfobloxProvider) CreateZoneDelegationForExternalDNS(gslb *k8gbv1beta1.
 		delegateTo = append(delegateTo, nameServer)
 	}
 
-	findZone, err := objMgr.GetZoneDelegated(p.config.DNSZone)
+	findZone, err := objMgr.getZoneDelegated(p.config.DNSZone)
 	if err != nil {
 		return err
 	}
@@ -73,49 +82,63 @@ func (p *InfobloxProvider) CreateZoneDelegationForExternalDNS(gslb *k8gbv1beta1.
 			existingDelegateTo := p.filterOutDelegateTo(findZone.DelegateTo, nsServerName(p.config))
 			existingDelegateTo = append(existingDelegateTo, delegateTo...)
 
+			// Drop records of clusters disabled by ClusterPeer
+			peers := clusterPeers(p.assistant)
+			for _, disabled := range nsServerNameDisabled(p.config, peers...) {
+				p.assistant.Event(gslb, disabled, corev1.EventTypeNormal, eventReasonPeerDisabled,
+					fmt.Sprintf("External cluster %s is disabled by ClusterPeer, filtering it out from delegated zone %s", disabled, p.config.DNSZone))
+				existingDelegateTo = p.filterOutDelegateTo(existingDelegateTo, disabled)
+			}
+
 			// Drop external records if they are stale
-			extClusters := getExternalClusterHeartbeatFQDNs(gslb, p.config)
-			for _, extCluster := range extClusters {
+			for _, geoTag := range extGeoTags(p.config, peers...) {
+				extCluster := heartbeatFQDN(gslb, p.config, geoTag)
 				err = p.assistant.InspectTXTThreshold(
 					extCluster,
+					geoTag,
 					p.config.Override.FakeDNSEnabled,
 					time.Second*time.Duration(gslb.Spec.Strategy.SplitBrainThresholdSeconds))
 				if err != nil {
 					p.assistant.Error(err, "Got the error from TXT based checkAlive. External cluster (%s) doesn't "+
 						"look alive, filtering it out from delegated zone configuration...", extCluster)
+					p.assistant.Event(gslb, extCluster, corev1.EventTypeWarning, eventReasonPeerFilteredOut,
+						fmt.Sprintf("External cluster %s doesn't look alive, filtering it out from delegated zone %s", extCluster, p.config.DNSZone))
 					existingDelegateTo = p.filterOutDelegateTo(existingDelegateTo, extCluster)
+					continue
 				}
+				p.assistant.Event(gslb, extCluster, corev1.EventTypeNormal, eventReasonPeerDelegated,
+					fmt.Sprintf("External cluster %s is alive, keeping it in delegated zone %s", extCluster, p.config.DNSZone))
 			}
 			p.assistant.Info("Updating delegated zone(%s) with the server list(%v)", p.config.DNSZone, existingDelegateTo)
 
-			_, err = objMgr.UpdateZoneDelegated(findZone.Ref, existingDelegateTo)
+			_, err = objMgr.updateZoneDelegated(findZone.Ref, existingDelegateTo)
 			if err != nil {
 				return err
 			}
 		}
 	} else {
 		p.assistant.Info("Creating delegated zone(%s)...", p.config.DNSZone)
-		_, err = objMgr.CreateZoneDelegated(p.config.DNSZone, delegateTo)
+		_, err = objMgr.createZoneDelegated(p.config.DNSZone, delegateTo)
 		if err != nil {
 			return err
 		}
 	}
 
-	edgeTimestamp := fmt.Sprint(time.Now().UTC().Format("2006-01-02T15:04:05"))
-	heartbeatTXTName := fmt.Sprintf("%s-heartbeat-%s.%s", gslb.Name, p.config.ClusterGeoTag, p.config.EdgeDNSZone)
-	heartbeatTXTRecord, err := objMgr.GetTXTRecord(heartbeatTXTName)
+	heartbeatTXTName := heartbeatFQDN(gslb, p.config, p.config.ClusterGeoTag)
+	heartbeatTXT := heartbeat(p.config, p.assistant, heartbeatTXTName)
+	heartbeatTXTRecord, err := objMgr.getTXTRecord(heartbeatTXTName)
 	if err != nil {
 		return err
 	}
 	if heartbeatTXTRecord == nil {
 		p.assistant.Info("Creating split brain TXT record(%s)...", heartbeatTXTName)
-		_, err := objMgr.CreateTXTRecord(heartbeatTXTName, edgeTimestamp, gslb.Spec.Strategy.DNSTtlSeconds, "default")
+		_, err := objMgr.createTXTRecord(heartbeatTXTName, heartbeatTXT, gslb.Spec.Strategy.DNSTtlSeconds)
 		if err != nil {
 			return err
 		}
 	} else {
 		p.assistant.Info("Updating split brain TXT record(%s)...", heartbeatTXTName)
-		_, err := objMgr.UpdateTXTRecord(heartbeatTXTName, edgeTimestamp)
+		_, err := objMgr.updateTXTRecord(heartbeatTXTRecord.Ref, heartbeatTXT)
 		if err != nil {
 			return err
 		}
@@ -123,32 +146,21 @@ func (p *InfobloxProvider) CreateZoneDelegationForExternalDNS(gslb *k8gbv1beta1.
 	return nil
 }
 
-func (p *InfobloxProvider) Finalize(gslb *k8gbv1beta1.Gslb) error {
+// Finalize removes split brain TXT record of gslb. Local nameservers are removed from the delegated zone once the
+// last Gslb of the zone is finalized; the zone is deleted when no nameservers of other clusters are left
+func (p *InfobloxProvider) Finalize(gslb *k8gbv1beta1.Gslb, others []k8gbv1beta1.Gslb) error {
 	objMgr, err := p.infobloxConnection()
 	if err != nil {
 		return err
 	}
-	findZone, err := objMgr.GetZoneDelegated(p.config.DNSZone)
-	if err != nil {
-		return err
-	}
-
-	if findZone != nil {
-		err = p.checkZoneDelegated(findZone)
-		if err != nil {
+	if len(others) == 0 {
+		if err = p.removeZoneDelegation(objMgr); err != nil {
 			return err
 		}
-		if len(findZone.Ref) > 0 {
-			p.assistant.Info("Deleting delegated zone(%s)...", p.config.DNSZone)
-			_, err := objMgr.DeleteZoneDelegated(findZone.Ref)
-			if err != nil {
-				return err
-			}
-		}
 	}
 
-	heartbeatTXTName := fmt.Sprintf("%s-heartbeat-%s.%s", gslb.Name, p.config.ClusterGeoTag, p.config.EdgeDNSZone)
-	findTXT, err := objMgr.GetTXTRecord(heartbeatTXTName)
+	heartbeatTXTName := heartbeatFQDN(gslb, p.config, p.config.ClusterGeoTag)
+	findTXT, err := objMgr.getTXTRecord(heartbeatTXTName)
 	if err != nil {
 		return err
 	}
@@ -165,8 +177,36 @@ func (p *InfobloxProvider) Finalize(gslb *k8gbv1beta1.Gslb) error {
 	return nil
 }
 
+// removeZoneDelegation removes local nameservers from the delegated zone and deletes the zone if it's left empty
+func (p *InfobloxProvider) removeZoneDelegation(objMgr *infobloxClient) error {
+	findZone, err := objMgr.getZoneDelegated(p.config.DNSZone)
+	if err != nil || findZone == nil || len(findZone.Ref) == 0 {
+		return err
+	}
+	if err = p.checkZoneDelegated(findZone); err != nil {
+		return err
+	}
+	delegateTo := p.filterOutDelegateTo(findZone.DelegateTo, nsServerName(p.config))
+	if len(delegateTo) > 0 {
+		p.assistant.Info("Removing %s from delegated zone(%s)...", nsServerName(p.config), p.config.DNSZone)
+		_, err = objMgr.updateZoneDelegated(findZone.Ref, delegateTo)
+		return err
+	}
+	p.assistant.Info("Deleting delegated zone(%s)...", p.config.DNSZone)
+	_, err = objMgr.DeleteZoneDelegated(findZone.Ref)
+	return err
+}
+
 func (p *InfobloxProvider) GetExternalTargets(host string) (targets []string) {
-	return p.assistant.GetExternalTargets(host, p.config.Override.FakeDNSEnabled, nsServerNameExt(p.config))
+	return externalTargets(p.config, p.assistant, host)
+}
+
+func (p *InfobloxProvider) ExternalClustersStatus(gslb *k8gbv1beta1.Gslb) []k8gbv1beta1.PeerStatus {
+	return externalClustersStatus(p.config, p.assistant, gslb)
+}
+
+func (p *InfobloxProvider) ExternalHeartbeats(gslb *k8gbv1beta1.Gslb) []Heartbeat {
+	return externalHeartbeats(p.config, p.assistant, gslb)
 }
 
 func (p *InfobloxProvider) GslbIngressExposedIPs(gslb *k8gbv1beta1.Gslb) ([]string, error) {
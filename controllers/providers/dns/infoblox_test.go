@@ -24,6 +24,7 @@ import (
 
 	ibclient "github.com/infobloxopen/infoblox-go-client"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var predefinedConfig = depresolver.Config{
@@ -72,3 +73,42 @@ func TestCanFilterOutDelegatedZoneEntryAccordingFQDNProvided(t *testing.T) {
 	// assert
 	assert.Equal(t, want, got, "got:\n %q filtered out delegation records,\n\n want:\n %q", got, want)
 }
+
+func TestInfobloxClientAppliesViewAndExtensibleAttributes(t *testing.T) {
+	// arrange
+	customConfig := predefinedConfig
+	customConfig.Infoblox.View = "internal"
+	customConfig.Infoblox.TenantID = "k8gb-tenant"
+	customConfig.Infoblox.ExtensibleAttributes = map[string]string{"owner": "team-a", "cost-centre": "1234"}
+	wantEA := ibclient.EA{"owner": "team-a", "cost-centre": "1234", "Tenant ID": "k8gb-tenant"}
+	a := assistant.NewGslbAssistant(nil, nil, customConfig.K8gbNamespace, customConfig.EdgeDNSServer)
+	provider := NewInfobloxDNS(customConfig, a)
+	connector := &fakeInfobloxConnector{}
+	client := &infobloxClient{connector: connector, view: customConfig.Infoblox.View, ea: provider.extensibleAttributes()}
+	// act
+	_, err := client.createZoneDelegated(customConfig.DNSZone, []ibclient.NameServer{{Address: "10.0.0.1", Name: "gslb-ns-us.example.com"}})
+	require.NoError(t, err)
+	zone := connector.createObjectObj.(*ibclient.ZoneDelegated)
+	_, err = client.createTXTRecord("test-gslb-heartbeat-us.example.com", "2021-01-01T00:00:00", 30)
+	require.NoError(t, err)
+	txt := connector.createObjectObj.(*ibclient.RecordTXT)
+	// assert
+	assert.Equal(t, "internal", zone.View)
+	assert.Equal(t, wantEA, zone.Ea)
+	assert.Equal(t, "internal", txt.View)
+	assert.Equal(t, wantEA, txt.Ea)
+}
+
+func TestInfobloxClientWithoutTenantIDAndExtensibleAttributes(t *testing.T) {
+	// arrange
+	a := assistant.NewGslbAssistant(nil, nil, predefinedConfig.K8gbNamespace, predefinedConfig.EdgeDNSServer)
+	provider := NewInfobloxDNS(predefinedConfig, a)
+	connector := &fakeInfobloxConnector{fakeRefReturn: "record:txt/ref"}
+	client := &infobloxClient{connector: connector, view: "default", ea: provider.extensibleAttributes()}
+	// act
+	_, err := client.updateTXTRecord("record:txt/ref", "2021-01-01T00:00:00")
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, "record:txt/ref", connector.updateObjectRef)
+	assert.Empty(t, connector.updateObjectObj.(*ibclient.RecordTXT).Ea)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"strings"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+// MultiZoneDNSProvider is executed when AdditionalDNSZones are configured. Every delegation zone is served by its
+// own provider configured for the zone. Zone delegation, heartbeats and finalization are executed by providers of
+// zones of Gslb hosts and external targets of host are resolved in the zone of the host. Operations over local
+// resources (ingress IPs, gslb DNSEndpoint) are executed by provider of DNSZone.
+type MultiZoneDNSProvider struct {
+	config    depresolver.Config
+	providers map[string]IDnsProvider
+}
+
+// NewMultiZoneDNS creates provider of DelegationZones; providers are in the order of DelegationZones
+func NewMultiZoneDNS(config depresolver.Config, providers ...IDnsProvider) *MultiZoneDNSProvider {
+	p := &MultiZoneDNSProvider{
+		config:    config,
+		providers: make(map[string]IDnsProvider),
+	}
+	for i, zone := range config.DelegationZones() {
+		p.providers[zone.DNSZone] = providers[i]
+	}
+	return p
+}
+
+func (p *MultiZoneDNSProvider) CreateZoneDelegationForExternalDNS(gslb *k8gbv1beta1.Gslb) error {
+	return p.forEachZone(gslb, "zone delegation", func(_ depresolver.DelegationZone, provider IDnsProvider) error {
+		return provider.CreateZoneDelegationForExternalDNS(gslb)
+	})
+}
+
+// Finalize finalizes gslb in providers of its zones. Provider of each zone is given only the Gslbs delegated by the zone
+func (p *MultiZoneDNSProvider) Finalize(gslb *k8gbv1beta1.Gslb, others []k8gbv1beta1.Gslb) error {
+	return p.forEachZone(gslb, "finalize", func(zone depresolver.DelegationZone, provider IDnsProvider) error {
+		return provider.Finalize(gslb, gslbsOfZone(p.config, zone, others))
+	})
+}
+
+func (p *MultiZoneDNSProvider) GslbIngressExposedIPs(gslb *k8gbv1beta1.Gslb) ([]string, error) {
+	return p.primary().GslbIngressExposedIPs(gslb)
+}
+
+// GetExternalTargets asks external clusters for targets of host by nameservers of the zone of host
+func (p *MultiZoneDNSProvider) GetExternalTargets(host string) (targets []string) {
+	zone, found := p.config.ZoneOf(host)
+	if !found {
+		return p.primary().GetExternalTargets(host)
+	}
+	return p.providers[zone.DNSZone].GetExternalTargets(host)
+}
+
+// ExternalClustersStatus describes external clusters as observed in the first zone of gslb
+func (p *MultiZoneDNSProvider) ExternalClustersStatus(gslb *k8gbv1beta1.Gslb) []k8gbv1beta1.PeerStatus {
+	return p.providers[gslbZones(p.config, gslb)[0].DNSZone].ExternalClustersStatus(gslb)
+}
+
+// ExternalHeartbeats merges heartbeats of all zones of gslb
+func (p *MultiZoneDNSProvider) ExternalHeartbeats(gslb *k8gbv1beta1.Gslb) (heartbeats []Heartbeat) {
+	for _, zone := range gslbZones(p.config, gslb) {
+		heartbeats = append(heartbeats, p.providers[zone.DNSZone].ExternalHeartbeats(gslb)...)
+	}
+	return
+}
+
+func (p *MultiZoneDNSProvider) SaveDNSEndpoint(gslb *k8gbv1beta1.Gslb, i *externaldns.DNSEndpoint) error {
+	return p.primary().SaveDNSEndpoint(gslb, i)
+}
+
+func (p *MultiZoneDNSProvider) String() string {
+	return fmt.Sprintf("%s", p.primary())
+}
+
+func (p *MultiZoneDNSProvider) primary() IDnsProvider {
+	return p.providers[p.config.DNSZone]
+}
+
+// forEachZone executes fn against providers of all zones of gslb, even if some of them fail. Errors are aggregated
+// into single one
+func (p *MultiZoneDNSProvider) forEachZone(gslb *k8gbv1beta1.Gslb, operation string,
+	fn func(depresolver.DelegationZone, IDnsProvider) error) error {
+	zones := gslbZones(p.config, gslb)
+	var messages []string
+	for _, zone := range zones {
+		if err := fn(zone, p.providers[zone.DNSZone]); err != nil {
+			messages = append(messages, fmt.Sprintf("%s: %s", zone.DNSZone, err))
+		}
+	}
+	if len(messages) > 0 {
+		return fmt.Errorf("%s failed for %d of %d zones: [%s]", operation, len(messages), len(zones),
+			strings.Join(messages, "; "))
+	}
+	return nil
+}
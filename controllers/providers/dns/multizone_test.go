@@ -0,0 +1,126 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"testing"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/extensions/v1beta1"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestMultiZoneDelegatesOnlyZonesOfGslbHosts(t *testing.T) {
+	// arrange
+	cloud, api, net := &stubProvider{name: "cloud"}, &stubProvider{name: "api"}, &stubProvider{name: "net"}
+	provider := NewMultiZoneDNS(multiZoneConfig(), cloud, api, net)
+	gslb := getGSLB(t)
+	gslb.Spec.Ingress.Rules = append(gslb.Spec.Ingress.Rules, v1beta1.IngressRule{Host: "app.api.example.org"})
+	// act
+	errDelegation := provider.CreateZoneDelegationForExternalDNS(gslb)
+	errFinalize := provider.Finalize(gslb, nil)
+	errSave := provider.SaveDNSEndpoint(gslb, &externaldns.DNSEndpoint{})
+	// assert
+	assert.NoError(t, errDelegation)
+	assert.NoError(t, errFinalize)
+	assert.NoError(t, errSave)
+	assert.Equal(t, 1, cloud.delegations)
+	assert.Equal(t, 1, api.delegations)
+	assert.Equal(t, 0, net.delegations, "zone without Gslb hosts must not be delegated")
+	assert.Equal(t, 1, cloud.finalized)
+	assert.Equal(t, 1, api.finalized)
+	assert.Equal(t, 0, net.finalized)
+	assert.Equal(t, 1, cloud.saved, "DNSEndpoint is saved by provider of DNSZone")
+	assert.Equal(t, "cloud", provider.String())
+}
+
+func TestMultiZoneFinalizesZonesWithGslbsOfZone(t *testing.T) {
+	// arrange
+	cloud, api, net := &stubProvider{name: "cloud"}, &stubProvider{name: "api"}, &stubProvider{name: "net"}
+	provider := NewMultiZoneDNS(multiZoneConfig(), cloud, api, net)
+	gslb := getGSLB(t)
+	gslb.Spec.Ingress.Rules = append(gslb.Spec.Ingress.Rules, v1beta1.IngressRule{Host: "app.api.example.org"})
+	cloudGslb := *getGSLB(t)
+	cloudGslb.Name = "cloud-gslb"
+	netGslb := *getGSLB(t)
+	netGslb.Name = "net-gslb"
+	netGslb.Spec.Ingress.Rules = []v1beta1.IngressRule{{Host: "app.cloud.example.net"}}
+	// act
+	err := provider.Finalize(gslb, []k8gbv1beta1.Gslb{cloudGslb, netGslb})
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, []k8gbv1beta1.Gslb{cloudGslb}, cloud.others, "delegation of cloud zone is kept for cloud-gslb")
+	assert.Empty(t, api.others, "delegation of api zone is removed with its last Gslb")
+	assert.Equal(t, 0, net.finalized)
+}
+
+func TestMultiZoneResolvesExternalTargetsInZoneOfHost(t *testing.T) {
+	// arrange
+	cloud, api, net := &stubProvider{name: "cloud"}, &stubProvider{name: "api"}, &stubProvider{name: "net"}
+	provider := NewMultiZoneDNS(multiZoneConfig(), cloud, api, net)
+	// act
+	cloudTargets := provider.GetExternalTargets("roundrobin.cloud.example.com")
+	apiTargets := provider.GetExternalTargets("app.api.example.org")
+	unknownTargets := provider.GetExternalTargets("app.example.io")
+	// assert
+	assert.Equal(t, []string{"cloud"}, cloudTargets)
+	assert.Equal(t, []string{"api"}, apiTargets)
+	assert.Equal(t, []string{"cloud"}, unknownTargets)
+}
+
+func TestMultiZoneMergesHeartbeatsOfGslbZones(t *testing.T) {
+	// arrange
+	cloud := &stubProvider{name: "cloud", heartbeats: map[string]bool{"test-gslb-heartbeat-us-east-1.example.com": true}}
+	api := &stubProvider{name: "api", heartbeats: map[string]bool{"test-gslb-heartbeat-us-east-1.example.org": false}}
+	net := &stubProvider{name: "net", heartbeats: map[string]bool{"test-gslb-heartbeat-us-east-1.example.net": true}}
+	provider := NewMultiZoneDNS(multiZoneConfig(), cloud, api, net)
+	gslb := getGSLB(t)
+	gslb.Spec.Ingress.Rules = append(gslb.Spec.Ingress.Rules, v1beta1.IngressRule{Host: "app.api.example.org"})
+	// act
+	heartbeats := inspectHeartbeats(provider.ExternalHeartbeats(gslb))
+	// assert
+	assert.Equal(t, map[string]bool{
+		"test-gslb-heartbeat-us-east-1.example.com": true,
+		"test-gslb-heartbeat-us-east-1.example.org": false,
+	}, heartbeats)
+}
+
+func TestMultiZoneContinuesAndAggregatesErrors(t *testing.T) {
+	// arrange
+	cloud := &stubProvider{name: "cloud", err: fmt.Errorf("connection refused")}
+	api := &stubProvider{name: "api"}
+	provider := NewMultiZoneDNS(multiZoneConfig(), cloud, api, &stubProvider{name: "net"})
+	gslb := getGSLB(t)
+	gslb.Spec.Ingress.Rules = append(gslb.Spec.Ingress.Rules, v1beta1.IngressRule{Host: "app.api.example.org"})
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	assert.EqualError(t, err, "zone delegation failed for 1 of 2 zones: [cloud.example.com: connection refused]")
+	assert.Equal(t, 1, api.delegations)
+}
+
+func multiZoneConfig() depresolver.Config {
+	config := predefinedConfig
+	config.AdditionalDNSZones = []depresolver.DelegationZone{
+		{DNSZone: "api.example.org", EdgeDNSZone: "example.org"},
+		{DNSZone: "cloud.example.net", EdgeDNSZone: "example.net"},
+	}
+	return config
+}
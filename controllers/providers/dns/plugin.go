@@ -0,0 +1,136 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/providers/assistant"
+	"github.com/AbsaOSS/k8gb/controllers/providers/dns/plugin"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+// PluginProvider calls out-of-process edge DNS plugin (usually sidecar) over the plugin protocol.
+// Optional operations which are not implemented by plugin fall back to the built-in behaviour
+type PluginProvider struct {
+	assistant assistant.IAssistant
+	config    depresolver.Config
+	client    *plugin.Client
+	dryRun    *dryRunRecorder
+}
+
+func NewPluginDNS(config depresolver.Config, assistant assistant.IAssistant) *PluginProvider {
+	return &PluginProvider{
+		assistant: assistant,
+		config:    config,
+		client:    plugin.NewClient(config.Plugin.Endpoint, time.Duration(config.Plugin.Timeout)*time.Second),
+	}
+}
+
+func (p *PluginProvider) CreateZoneDelegationForExternalDNS(gslb *k8gbv1beta1.Gslb) error {
+	var NSServerList []string
+	NSServerList = append(NSServerList, nsServerName(p.config))
+	NSServerList = append(NSServerList, nsServerNameExt(p.config, clusterPeers(p.assistant)...)...)
+	sort.Strings(NSServerList)
+	var NSServerIPs []string
+	var err error
+	if p.config.CoreDNSExposed {
+		NSServerIPs, err = p.assistant.CoreDNSExposedIPs()
+	} else {
+		NSServerIPs, err = p.GslbIngressExposedIPs(gslb)
+	}
+	if err != nil {
+		return err
+	}
+	if p.dryRun != nil {
+		ttl := gslb.Spec.Strategy.DNSTtlSeconds
+		p.dryRun.record(p, dryRunChange{Action: dryRunActionUpsert, Type: "NS", Name: p.config.DNSZone, TTL: ttl, Targets: NSServerList})
+		p.dryRun.record(p, dryRunChange{Action: dryRunActionUpsert, Type: "A", Name: nsServerName(p.config), TTL: ttl, Targets: NSServerIPs})
+		return nil
+	}
+	p.assistant.Info("Updating delegated zone(%s) with the server list(%v) in %s", p.config.DNSZone, NSServerList, p)
+	return p.client.CreateZoneDelegation(plugin.ZoneDelegationRequest{
+		Gslb:            gslb,
+		Zone:            p.config.DNSZone,
+		TTL:             gslb.Spec.Strategy.DNSTtlSeconds,
+		NameServers:     NSServerList,
+		LocalNameServer: nsServerName(p.config),
+		LocalAddresses:  NSServerIPs,
+	})
+}
+
+func (p *PluginProvider) Finalize(gslb *k8gbv1beta1.Gslb, others []k8gbv1beta1.Gslb) error {
+	if len(others) > 0 {
+		return nil
+	}
+	if p.dryRun != nil {
+		p.dryRun.record(p, dryRunChange{Action: dryRunActionDelete, Type: "NS", Name: p.config.DNSZone, Targets: []string{nsServerName(p.config)}})
+		p.dryRun.record(p, dryRunChange{Action: dryRunActionDelete, Type: "A", Name: nsServerName(p.config)})
+		return nil
+	}
+	p.assistant.Info("Removing %s from delegated zone(%s) in %s", nsServerName(p.config), p.config.DNSZone, p)
+	return p.client.Finalize(plugin.FinalizeRequest{
+		Gslb:            gslb,
+		Zone:            p.config.DNSZone,
+		LocalNameServer: nsServerName(p.config),
+	})
+}
+
+func (p *PluginProvider) GetExternalTargets(host string) (targets []string) {
+	peers := clusterPeers(p.assistant)
+	extNameServers := nsAddressExt(p.config, peers...)
+	targets, err := p.client.GetExternalTargets(plugin.ExternalTargetsRequest{Host: host, ExternalNameServers: extNameServers})
+	if errors.Is(err, plugin.ErrNotImplemented) {
+		return p.assistant.GetExternalTargets(host, p.config.Override.FakeDNSEnabled, extNameServers, statusEndpointsExt(p.config, peers...))
+	}
+	if err != nil {
+		p.assistant.Error(err, "can't get external targets of %s from %s", host, p)
+	}
+	return targets
+}
+
+func (p *PluginProvider) ExternalClustersStatus(gslb *k8gbv1beta1.Gslb) []k8gbv1beta1.PeerStatus {
+	return externalClustersStatus(p.config, p.assistant, gslb)
+}
+
+func (p *PluginProvider) ExternalHeartbeats(*k8gbv1beta1.Gslb) []Heartbeat {
+	return nil
+}
+
+func (p *PluginProvider) GslbIngressExposedIPs(gslb *k8gbv1beta1.Gslb) ([]string, error) {
+	addresses, err := p.client.GslbIngressExposedIPs(plugin.GslbIngressIPsRequest{Gslb: gslb})
+	if errors.Is(err, plugin.ErrNotImplemented) {
+		return p.assistant.GslbIngressExposedIPs(gslb)
+	}
+	return addresses, err
+}
+
+func (p *PluginProvider) SaveDNSEndpoint(gslb *k8gbv1beta1.Gslb, i *externaldns.DNSEndpoint) error {
+	err := p.client.SaveDNSEndpoint(plugin.SaveDNSEndpointRequest{Gslb: gslb, Endpoint: i})
+	if errors.Is(err, plugin.ErrNotImplemented) {
+		return p.assistant.SaveDNSEndpoint(gslb.Namespace, i)
+	}
+	return err
+}
+
+func (p *PluginProvider) String() string {
+	return "Plugin"
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client calls plugin over the protocol
+type Client struct {
+	endpoint string
+	http     *http.Client
+}
+
+// NewClient creates client of plugin listening on endpoint, e.g. http://localhost:8090
+func NewClient(endpoint string, timeout time.Duration) *Client {
+	return &Client{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		http:     &http.Client{Timeout: timeout},
+	}
+}
+
+// Info returns plugin description
+func (c *Client) Info() (info InfoResponse, err error) {
+	err = c.do(http.MethodGet, PathInfo, nil, &info)
+	return
+}
+
+// CreateZoneDelegation delegates zone to nameservers
+func (c *Client) CreateZoneDelegation(request ZoneDelegationRequest) error {
+	return c.do(http.MethodPost, PathZoneDelegation, request, nil)
+}
+
+// Finalize removes local nameserver from zone delegation
+func (c *Client) Finalize(request FinalizeRequest) error {
+	return c.do(http.MethodPost, PathFinalize, request, nil)
+}
+
+// GslbIngressExposedIPs returns IPs exposed by gslb ingress or ErrNotImplemented
+func (c *Client) GslbIngressExposedIPs(request GslbIngressIPsRequest) ([]string, error) {
+	response := GslbIngressIPsResponse{}
+	err := c.do(http.MethodPost, PathGslbIngressIPs, request, &response)
+	return response.Addresses, err
+}
+
+// GetExternalTargets returns targets of the host in other clusters or ErrNotImplemented
+func (c *Client) GetExternalTargets(request ExternalTargetsRequest) ([]string, error) {
+	response := ExternalTargetsResponse{}
+	err := c.do(http.MethodPost, PathExternalTargets, request, &response)
+	return response.Targets, err
+}
+
+// SaveDNSEndpoint stores DNSEndpoint of the gslb or returns ErrNotImplemented
+func (c *Client) SaveDNSEndpoint(request SaveDNSEndpointRequest) error {
+	return c.do(http.MethodPost, PathSaveDNSEndpoint, request, nil)
+}
+
+func (c *Client) do(method, path string, request interface{}, response interface{}) error {
+	var body []byte
+	var err error
+	if request != nil {
+		if body, err = json.Marshal(request); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, c.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(headerContentType, ContentTypeJSON)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if response == nil {
+			return nil
+		}
+		if err = json.Unmarshal(raw, response); err != nil {
+			return fmt.Errorf("plugin %s: malformed response: %s", path, err)
+		}
+		return nil
+	case http.StatusNotImplemented:
+		return ErrNotImplemented
+	default:
+		errResponse := ErrorResponse{}
+		if json.Unmarshal(raw, &errResponse) != nil || errResponse.Error == "" {
+			errResponse.Error = strings.TrimSpace(string(raw))
+		}
+		return fmt.Errorf("plugin %s failed (%s): %s", path, resp.Status, errResponse.Error)
+	}
+}
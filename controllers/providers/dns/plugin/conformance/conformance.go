@@ -0,0 +1,161 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance contains test suite verifying that edge DNS plugin implements the plugin protocol.
+// Plugin authors run it from their own tests against running plugin:
+//
+//	func TestConformance(t *testing.T) {
+//		server := httptest.NewServer(myPluginHandler)
+//		defer server.Close()
+//		conformance.Run(t, server.URL)
+//	}
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/providers/dns/plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	zone            = "cloud.conformance.example.com"
+	localNameServer = "gslb-ns-cloud-conformance-example-com-eu.conformance.example.com"
+	extNameServer   = "gslb-ns-cloud-conformance-example-com-us.conformance.example.com"
+)
+
+// Run executes conformance suite against plugin listening on endpoint. Suite creates and removes
+// delegation of cloud.conformance.example.com, so plugin must not point to production edge DNS
+func Run(t *testing.T, endpoint string) {
+	c := plugin.NewClient(endpoint, 10*time.Second)
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	t.Run("Info", func(t *testing.T) {
+		info, err := c.Info()
+		require.NoError(t, err)
+		assert.NotEmpty(t, info.Name, "plugin must have name")
+		assert.Equal(t, plugin.Version, info.Version, "unsupported protocol version")
+	})
+
+	t.Run("ZoneDelegation", func(t *testing.T) {
+		request := delegationRequest()
+		require.NoError(t, c.CreateZoneDelegation(request))
+		require.NoError(t, c.CreateZoneDelegation(request), "zone delegation must be idempotent")
+		request.LocalAddresses = []string{"10.0.0.3"}
+		require.NoError(t, c.CreateZoneDelegation(request), "zone delegation must update existing delegation")
+	})
+
+	t.Run("ZoneDelegationWithoutZone", func(t *testing.T) {
+		request := delegationRequest()
+		request.Zone = ""
+		assertStatus(t, endpoint, plugin.PathZoneDelegation, request, http.StatusBadRequest)
+	})
+
+	t.Run("MalformedRequest", func(t *testing.T) {
+		for _, path := range []string{plugin.PathZoneDelegation, plugin.PathFinalize, plugin.PathGslbIngressIPs,
+			plugin.PathExternalTargets, plugin.PathSaveDNSEndpoint} {
+			resp, err := http.Post(endpoint+path, plugin.ContentTypeJSON, bytes.NewBufferString("{malformed"))
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Contains(t, []int{http.StatusBadRequest, http.StatusNotImplemented}, resp.StatusCode,
+				"malformed request to %s must be rejected", path)
+		}
+	})
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		resp, err := http.Get(endpoint + plugin.PathZoneDelegation)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.NotEqual(t, http.StatusOK, resp.StatusCode, "zone delegation must accept POST only")
+	})
+
+	t.Run("GslbIngressExposedIPs", func(t *testing.T) {
+		_, err := c.GslbIngressExposedIPs(plugin.GslbIngressIPsRequest{Gslb: gslb()})
+		assertOptional(t, err)
+	})
+
+	t.Run("GetExternalTargets", func(t *testing.T) {
+		_, err := c.GetExternalTargets(plugin.ExternalTargetsRequest{Host: "app." + zone, ExternalNameServers: []string{extNameServer}})
+		assertOptional(t, err)
+	})
+
+	t.Run("SaveDNSEndpoint", func(t *testing.T) {
+		err := c.SaveDNSEndpoint(plugin.SaveDNSEndpointRequest{Gslb: gslb(), Endpoint: &externaldns.DNSEndpoint{
+			ObjectMeta: metav1.ObjectMeta{Name: "conformance", Namespace: "conformance"},
+			Spec: externaldns.DNSEndpointSpec{Endpoints: []*externaldns.Endpoint{
+				{DNSName: "app." + zone, RecordType: "A", RecordTTL: 30, Targets: []string{"10.0.0.1"}},
+			}},
+		}})
+		assertOptional(t, err)
+	})
+
+	t.Run("Finalize", func(t *testing.T) {
+		request := plugin.FinalizeRequest{Gslb: gslb(), Zone: zone, LocalNameServer: localNameServer}
+		require.NoError(t, c.Finalize(request))
+		require.NoError(t, c.Finalize(request), "finalize must succeed when delegation doesn't exist")
+	})
+
+	t.Run("FinalizeWithoutLocalNameServer", func(t *testing.T) {
+		request := plugin.FinalizeRequest{Gslb: gslb(), Zone: zone}
+		assertStatus(t, endpoint, plugin.PathFinalize, request, http.StatusBadRequest)
+	})
+}
+
+func delegationRequest() plugin.ZoneDelegationRequest {
+	return plugin.ZoneDelegationRequest{
+		Gslb:            gslb(),
+		Zone:            zone,
+		TTL:             30,
+		NameServers:     []string{localNameServer, extNameServer},
+		LocalNameServer: localNameServer,
+		LocalAddresses:  []string{"10.0.0.1", "10.0.0.2"},
+	}
+}
+
+func gslb() *k8gbv1beta1.Gslb {
+	return &k8gbv1beta1.Gslb{
+		ObjectMeta: metav1.ObjectMeta{Name: "conformance", Namespace: "conformance"},
+		Spec: k8gbv1beta1.GslbSpec{Strategy: k8gbv1beta1.Strategy{
+			Type: "roundRobin", DNSTtlSeconds: 30, SplitBrainThresholdSeconds: 300,
+		}},
+	}
+}
+
+// assertOptional passes when optional operation succeeded or plugin responded 501 Not Implemented
+func assertOptional(t *testing.T, err error) {
+	if err != nil && !errors.Is(err, plugin.ErrNotImplemented) {
+		t.Errorf("optional operation must succeed or respond with %d: %s", http.StatusNotImplemented, err)
+	}
+}
+
+func assertStatus(t *testing.T, endpoint, path string, request interface{}, status int) {
+	body, err := json.Marshal(request)
+	require.NoError(t, err)
+	resp, err := http.Post(endpoint+path, plugin.ContentTypeJSON, bytes.NewReader(body))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, status, resp.StatusCode, "unexpected status of %s", path)
+}
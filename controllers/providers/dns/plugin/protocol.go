@@ -0,0 +1,112 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plugin defines HTTP/JSON protocol between k8gb and out-of-process edge DNS providers.
+//
+// Every operation of dns.IDnsProvider is mapped to POST request on its own path. Request and response
+// bodies are JSON documents defined in this file. Plugin returns 200 on success, 501 when it doesn't implement
+// optional operation and any other status with ErrorResponse body on failure. Zone delegation and finalize are
+// mandatory, the rest is optional and k8gb falls back to its built-in behaviour.
+package plugin
+
+import (
+	"errors"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+// Version of the protocol; it is the prefix of all paths
+const Version = "v1"
+
+// Paths of the protocol operations
+const (
+	PathInfo              = "/" + Version + "/info"
+	PathZoneDelegation    = "/" + Version + "/zone-delegation"
+	PathFinalize          = "/" + Version + "/finalize"
+	PathGslbIngressIPs    = "/" + Version + "/gslb-ingress-exposed-ips"
+	PathExternalTargets   = "/" + Version + "/external-targets"
+	PathSaveDNSEndpoint   = "/" + Version + "/save-dns-endpoint"
+	ContentTypeJSON       = "application/json"
+	headerContentType     = "Content-Type"
+	maxRequestBodyInBytes = 1 << 20
+)
+
+// ErrNotImplemented is returned by optional operations which plugin doesn't support
+var ErrNotImplemented = errors.New("operation is not implemented by plugin")
+
+// InfoResponse describes plugin
+type InfoResponse struct {
+	// Name of the edge DNS, e.g. "MyDNS"
+	Name string `json:"name"`
+	// Version of the protocol the plugin implements
+	Version string `json:"version"`
+}
+
+// ZoneDelegationRequest asks plugin to delegate Zone to NameServers. k8gb resolves all values, so plugin
+// only writes them into edge DNS
+type ZoneDelegationRequest struct {
+	Gslb *k8gbv1beta1.Gslb `json:"gslb"`
+	// Zone delegated to k8gb, e.g. cloud.example.com
+	Zone string `json:"zone"`
+	// TTL of the records in seconds
+	TTL int `json:"ttl"`
+	// NameServers are sorted nameservers of all clusters (NS records of Zone)
+	NameServers []string `json:"nameServers"`
+	// LocalNameServer is nameserver of this cluster
+	LocalNameServer string `json:"localNameServer"`
+	// LocalAddresses are IP addresses of LocalNameServer (glue A records)
+	LocalAddresses []string `json:"localAddresses"`
+}
+
+// FinalizeRequest asks plugin to remove LocalNameServer from delegation of Zone together with its glue records
+type FinalizeRequest struct {
+	Gslb            *k8gbv1beta1.Gslb `json:"gslb"`
+	Zone            string            `json:"zone"`
+	LocalNameServer string            `json:"localNameServer"`
+}
+
+// GslbIngressIPsRequest asks plugin for IPs exposed by gslb ingress
+type GslbIngressIPsRequest struct {
+	Gslb *k8gbv1beta1.Gslb `json:"gslb"`
+}
+
+// GslbIngressIPsResponse contains IPs exposed by gslb ingress
+type GslbIngressIPsResponse struct {
+	Addresses []string `json:"addresses"`
+}
+
+// ExternalTargetsRequest asks plugin for targets of Host served by ExternalNameServers
+type ExternalTargetsRequest struct {
+	Host                string   `json:"host"`
+	ExternalNameServers []string `json:"externalNameServers"`
+}
+
+// ExternalTargetsResponse contains targets of the host in other clusters
+type ExternalTargetsResponse struct {
+	Targets []string `json:"targets"`
+}
+
+// SaveDNSEndpointRequest asks plugin to store DNSEndpoint of the gslb
+type SaveDNSEndpointRequest struct {
+	Gslb     *k8gbv1beta1.Gslb        `json:"gslb"`
+	Endpoint *externaldns.DNSEndpoint `json:"endpoint"`
+}
+
+// ErrorResponse is body of every failed request
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
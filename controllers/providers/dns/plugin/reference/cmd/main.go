@@ -0,0 +1,42 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command cmd runs reference plugin, e.g. as sidecar of k8gb with DNS_PLUGIN_ENDPOINT=http://localhost:8090
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/AbsaOSS/k8gb/controllers/providers/dns/plugin"
+	"github.com/AbsaOSS/k8gb/controllers/providers/dns/plugin/reference"
+)
+
+func main() {
+	var addr string
+	flag.StringVar(&addr, "addr", ":8090", "The address the plugin binds to.")
+	flag.Parse()
+	log.Printf("reference DNS plugin listening on %s", addr)
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      plugin.NewHandler(reference.NewPlugin()),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	log.Fatal(server.ListenAndServe())
+}
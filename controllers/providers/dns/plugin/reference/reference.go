@@ -0,0 +1,107 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package reference contains in-memory edge DNS plugin. It is the reference implementation of the plugin
+// protocol and the starting point for custom plugins.
+package reference
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/AbsaOSS/k8gb/controllers/providers/dns/plugin"
+)
+
+// Plugin keeps delegated zones in memory. Optional operations are not implemented, so k8gb falls back
+// to its built-in behaviour
+type Plugin struct {
+	mux sync.RWMutex
+	// zones maps delegated zone to its nameservers
+	zones map[string][]string
+	// glue maps nameserver to its addresses
+	glue map[string][]string
+}
+
+// NewPlugin creates empty in-memory plugin
+func NewPlugin() *Plugin {
+	return &Plugin{
+		zones: make(map[string][]string),
+		glue:  make(map[string][]string),
+	}
+}
+
+func (p *Plugin) Info() plugin.InfoResponse {
+	return plugin.InfoResponse{Name: "Reference", Version: plugin.Version}
+}
+
+func (p *Plugin) CreateZoneDelegation(request plugin.ZoneDelegationRequest) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.zones[request.Zone] = sorted(request.NameServers)
+	p.glue[request.LocalNameServer] = sorted(request.LocalAddresses)
+	return nil
+}
+
+func (p *Plugin) Finalize(request plugin.FinalizeRequest) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	var nameServers []string
+	for _, ns := range p.zones[request.Zone] {
+		if ns != request.LocalNameServer {
+			nameServers = append(nameServers, ns)
+		}
+	}
+	if len(nameServers) == 0 {
+		delete(p.zones, request.Zone)
+	} else {
+		p.zones[request.Zone] = nameServers
+	}
+	delete(p.glue, request.LocalNameServer)
+	return nil
+}
+
+func (p *Plugin) GslbIngressExposedIPs(plugin.GslbIngressIPsRequest) ([]string, error) {
+	return nil, plugin.ErrNotImplemented
+}
+
+func (p *Plugin) GetExternalTargets(plugin.ExternalTargetsRequest) ([]string, error) {
+	return nil, plugin.ErrNotImplemented
+}
+
+func (p *Plugin) SaveDNSEndpoint(plugin.SaveDNSEndpointRequest) error {
+	return plugin.ErrNotImplemented
+}
+
+// NameServers returns nameservers of the delegated zone
+func (p *Plugin) NameServers(zone string) []string {
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+	return sorted(p.zones[zone])
+}
+
+// Addresses returns glue addresses of the nameserver
+func (p *Plugin) Addresses(nameServer string) []string {
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+	return sorted(p.glue[nameServer])
+}
+
+// sorted returns sorted copy of s
+func sorted(s []string) []string {
+	c := append([]string{}, s...)
+	sort.Strings(c)
+	return c
+}
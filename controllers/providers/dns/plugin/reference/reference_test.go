@@ -0,0 +1,49 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AbsaOSS/k8gb/controllers/providers/dns/plugin"
+	"github.com/AbsaOSS/k8gb/controllers/providers/dns/plugin/conformance"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferencePluginConformance(t *testing.T) {
+	// arrange
+	server := httptest.NewServer(plugin.NewHandler(NewPlugin()))
+	defer server.Close()
+	// act,assert
+	conformance.Run(t, server.URL)
+}
+
+func TestReferencePluginKeepsExternalNameServersOnFinalize(t *testing.T) {
+	// arrange
+	p := NewPlugin()
+	_ = p.CreateZoneDelegation(plugin.ZoneDelegationRequest{Zone: "cloud.example.com", LocalNameServer: "ns-eu.example.com",
+		NameServers: []string{"ns-us.example.com", "ns-eu.example.com"}, LocalAddresses: []string{"10.0.0.2", "10.0.0.1"}})
+	// act
+	addresses := p.Addresses("ns-eu.example.com")
+	err := p.Finalize(plugin.FinalizeRequest{Zone: "cloud.example.com", LocalNameServer: "ns-eu.example.com"})
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, addresses)
+	assert.Equal(t, []string{"ns-us.example.com"}, p.NameServers("cloud.example.com"))
+	assert.Empty(t, p.Addresses("ns-eu.example.com"))
+}
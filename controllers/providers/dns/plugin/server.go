@@ -0,0 +1,147 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Plugin is implemented by edge DNS plugins written in Go. NewHandler exposes it over the protocol.
+// Optional operations return ErrNotImplemented when not supported
+type Plugin interface {
+	Info() InfoResponse
+	CreateZoneDelegation(request ZoneDelegationRequest) error
+	Finalize(request FinalizeRequest) error
+	GslbIngressExposedIPs(request GslbIngressIPsRequest) ([]string, error)
+	GetExternalTargets(request ExternalTargetsRequest) ([]string, error)
+	SaveDNSEndpoint(request SaveDNSEndpointRequest) error
+}
+
+// badRequestError is returned by handlers when request is malformed or incomplete
+type badRequestError struct {
+	err error
+}
+
+func (e badRequestError) Error() string {
+	return e.err.Error()
+}
+
+// NewHandler returns http.Handler serving plugin over the protocol
+func NewHandler(plugin Plugin) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathInfo, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+			return
+		}
+		writeJSON(w, http.StatusOK, plugin.Info())
+	})
+	mux.HandleFunc(PathZoneDelegation, post(func(decode func(interface{}) error) (interface{}, error) {
+		request := ZoneDelegationRequest{}
+		if err := decode(&request); err != nil {
+			return nil, err
+		}
+		if request.Zone == "" || request.LocalNameServer == "" {
+			return nil, badRequestError{fmt.Errorf("zone and localNameServer must be set")}
+		}
+		return struct{}{}, plugin.CreateZoneDelegation(request)
+	}))
+	mux.HandleFunc(PathFinalize, post(func(decode func(interface{}) error) (interface{}, error) {
+		request := FinalizeRequest{}
+		if err := decode(&request); err != nil {
+			return nil, err
+		}
+		if request.Zone == "" || request.LocalNameServer == "" {
+			return nil, badRequestError{fmt.Errorf("zone and localNameServer must be set")}
+		}
+		return struct{}{}, plugin.Finalize(request)
+	}))
+	mux.HandleFunc(PathGslbIngressIPs, post(func(decode func(interface{}) error) (interface{}, error) {
+		request := GslbIngressIPsRequest{}
+		if err := decode(&request); err != nil {
+			return nil, err
+		}
+		if request.Gslb == nil {
+			return nil, badRequestError{fmt.Errorf("gslb must be set")}
+		}
+		addresses, err := plugin.GslbIngressExposedIPs(request)
+		return GslbIngressIPsResponse{Addresses: addresses}, err
+	}))
+	mux.HandleFunc(PathExternalTargets, post(func(decode func(interface{}) error) (interface{}, error) {
+		request := ExternalTargetsRequest{}
+		if err := decode(&request); err != nil {
+			return nil, err
+		}
+		if request.Host == "" {
+			return nil, badRequestError{fmt.Errorf("host must be set")}
+		}
+		targets, err := plugin.GetExternalTargets(request)
+		return ExternalTargetsResponse{Targets: targets}, err
+	}))
+	mux.HandleFunc(PathSaveDNSEndpoint, post(func(decode func(interface{}) error) (interface{}, error) {
+		request := SaveDNSEndpointRequest{}
+		if err := decode(&request); err != nil {
+			return nil, err
+		}
+		if request.Gslb == nil || request.Endpoint == nil {
+			return nil, badRequestError{fmt.Errorf("gslb and endpoint must be set")}
+		}
+		return struct{}{}, plugin.SaveDNSEndpoint(request)
+	}))
+	return mux
+}
+
+// post wraps fn into POST handler. fn decodes request body via decode function and returns the response body
+func post(fn func(decode func(interface{}) error) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+			return
+		}
+		decode := func(v interface{}) error {
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyInBytes)).Decode(v); err != nil {
+				return badRequestError{err}
+			}
+			return nil
+		}
+		result, err := fn(decode)
+		var badRequest badRequestError
+		switch {
+		case err == nil:
+			writeJSON(w, http.StatusOK, result)
+		case errors.Is(err, ErrNotImplemented):
+			writeError(w, http.StatusNotImplemented, err)
+		case errors.As(err, &badRequest):
+			writeError(w, http.StatusBadRequest, err)
+		default:
+			writeError(w, http.StatusInternalServerError, err)
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set(headerContentType, ContentTypeJSON)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
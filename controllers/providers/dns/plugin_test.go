@@ -0,0 +1,137 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/internal/utils"
+	"github.com/AbsaOSS/k8gb/controllers/providers/assistant"
+	"github.com/AbsaOSS/k8gb/controllers/providers/dns/plugin"
+	"github.com/AbsaOSS/k8gb/controllers/providers/dns/plugin/reference"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// exposedIPsPlugin implements optional GslbIngressExposedIPs on top of reference plugin
+type exposedIPsPlugin struct {
+	*reference.Plugin
+}
+
+func (p exposedIPsPlugin) GslbIngressExposedIPs(plugin.GslbIngressIPsRequest) ([]string, error) {
+	return []string{"192.168.0.1"}, nil
+}
+
+func TestPluginCreatesZoneDelegationWithFallbackToIngressIPs(t *testing.T) {
+	// arrange
+	ref := reference.NewPlugin()
+	provider, gslb := newTestPluginProvider(t, ref)
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gslb-ns-cloud-example-com-us-east-1.example.com", "gslb-ns-cloud-example-com-us-west-1.example.com"},
+		ref.NameServers("cloud.example.com"))
+	assert.Equal(t, []string{"10.0.0.1"}, ref.Addresses("gslb-ns-cloud-example-com-us-west-1.example.com"))
+}
+
+func TestPluginCreatesZoneDelegationWithPluginIngressIPs(t *testing.T) {
+	// arrange
+	ref := reference.NewPlugin()
+	provider, gslb := newTestPluginProvider(t, exposedIPsPlugin{ref})
+	// act
+	err := provider.CreateZoneDelegationForExternalDNS(gslb)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.168.0.1"}, ref.Addresses("gslb-ns-cloud-example-com-us-west-1.example.com"))
+}
+
+func TestPluginFinalize(t *testing.T) {
+	// arrange
+	ref := reference.NewPlugin()
+	provider, gslb := newTestPluginProvider(t, ref)
+	require.NoError(t, provider.CreateZoneDelegationForExternalDNS(gslb))
+	// act
+	err := provider.Finalize(gslb, nil)
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gslb-ns-cloud-example-com-us-east-1.example.com"}, ref.NameServers("cloud.example.com"))
+	assert.Empty(t, ref.Addresses("gslb-ns-cloud-example-com-us-west-1.example.com"))
+}
+
+func TestPluginFinalizeKeepsDelegationOfOtherGslbs(t *testing.T) {
+	// arrange
+	ref := reference.NewPlugin()
+	provider, gslb := newTestPluginProvider(t, ref)
+	require.NoError(t, provider.CreateZoneDelegationForExternalDNS(gslb))
+	other := *gslb
+	other.Name = "other-gslb"
+	// act
+	err := provider.Finalize(gslb, []k8gbv1beta1.Gslb{other})
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gslb-ns-cloud-example-com-us-east-1.example.com", "gslb-ns-cloud-example-com-us-west-1.example.com"},
+		ref.NameServers("cloud.example.com"))
+}
+
+func TestPluginIsNotAvailable(t *testing.T) {
+	// arrange
+	gslb := getGSLB(t)
+	config := predefinedConfig
+	config.Plugin = depresolver.Plugin{Endpoint: "http://127.0.0.1:1", Timeout: 1}
+	provider := NewPluginDNS(config, newTestAssistant())
+	// act
+	err := provider.Finalize(gslb, nil)
+	// assert
+	assert.Error(t, err)
+}
+
+func TestFactoryPlugin(t *testing.T) {
+	// arrange
+	log := ctrl.Log.WithName("dummy")
+	client := fake.NewFakeClientWithScheme(scheme.Scheme, []runtime.Object{}...)
+	customConfig := predefinedConfig
+	customConfig.EdgeDNSType = depresolver.DNSTypePlugin
+	customConfig.Plugin = depresolver.Plugin{Endpoint: "http://localhost:8090", Timeout: 20}
+	// act
+	f, err := NewDNSProviderFactory(client, customConfig, log, nil)
+	require.NoError(t, err)
+	provider := f.Provider()
+	// assert
+	assert.NotNil(t, provider)
+	assert.Equal(t, "*PluginProvider", utils.GetType(provider))
+	assert.Equal(t, "Plugin", fmt.Sprintf("%s", provider))
+}
+
+func newTestPluginProvider(t *testing.T, p plugin.Plugin) (*PluginProvider, *k8gbv1beta1.Gslb) {
+	server := httptest.NewServer(plugin.NewHandler(p))
+	t.Cleanup(server.Close)
+	gslb := getGSLB(t)
+	client := fake.NewFakeClientWithScheme(scheme.Scheme, []runtime.Object{gslbIngress(gslb, "10.0.0.1")}...)
+	a := assistant.NewGslbAssistant(client, ctrl.Log.WithName("dummy"), predefinedConfig.K8gbNamespace, predefinedConfig.EdgeDNSServer)
+	config := predefinedConfig
+	config.Plugin = depresolver.Plugin{Endpoint: server.URL, Timeout: 5}
+	return NewPluginDNS(config, a), gslb
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"sync"
+
+	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
+	externaldns "sigs.k8s.io/external-dns/endpoint"
+)
+
+// ReloadableDNSProvider delegates to provider which is replaced when configuration of the operator is hot-reloaded.
+// Operations in progress finish with the replaced provider, following operations are executed by the new one
+type ReloadableDNSProvider struct {
+	mux      sync.RWMutex
+	provider IDnsProvider
+}
+
+func NewReloadableDNS(provider IDnsProvider) *ReloadableDNSProvider {
+	return &ReloadableDNSProvider{provider: provider}
+}
+
+// Reload replaces wrapped provider
+func (p *ReloadableDNSProvider) Reload(provider IDnsProvider) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.provider = provider
+}
+
+func (p *ReloadableDNSProvider) CreateZoneDelegationForExternalDNS(gslb *k8gbv1beta1.Gslb) error {
+	return p.current().CreateZoneDelegationForExternalDNS(gslb)
+}
+
+func (p *ReloadableDNSProvider) GslbIngressExposedIPs(gslb *k8gbv1beta1.Gslb) ([]string, error) {
+	return p.current().GslbIngressExposedIPs(gslb)
+}
+
+func (p *ReloadableDNSProvider) GetExternalTargets(host string) []string {
+	return p.current().GetExternalTargets(host)
+}
+
+func (p *ReloadableDNSProvider) ExternalClustersStatus(gslb *k8gbv1beta1.Gslb) []k8gbv1beta1.PeerStatus {
+	return p.current().ExternalClustersStatus(gslb)
+}
+
+func (p *ReloadableDNSProvider) ExternalHeartbeats(gslb *k8gbv1beta1.Gslb) []Heartbeat {
+	return p.current().ExternalHeartbeats(gslb)
+}
+
+func (p *ReloadableDNSProvider) SaveDNSEndpoint(gslb *k8gbv1beta1.Gslb, i *externaldns.DNSEndpoint) error {
+	return p.current().SaveDNSEndpoint(gslb, i)
+}
+
+func (p *ReloadableDNSProvider) Finalize(gslb *k8gbv1beta1.Gslb, others []k8gbv1beta1.Gslb) error {
+	return p.current().Finalize(gslb, others)
+}
+
+func (p *ReloadableDNSProvider) String() string {
+	return fmt.Sprint(p.current())
+}
+
+func (p *ReloadableDNSProvider) current() IDnsProvider {
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+	return p.provider
+}
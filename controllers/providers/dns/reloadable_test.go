@@ -0,0 +1,45 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadableDelegatesToReloadedProvider(t *testing.T) {
+	// arrange
+	old, reloaded := &stubProvider{name: "old"}, &stubProvider{name: "reloaded"}
+	provider := NewReloadableDNS(old)
+	gslb := getGSLB(t)
+	// act
+	errBefore := provider.CreateZoneDelegationForExternalDNS(gslb)
+	provider.Reload(reloaded)
+	errAfter := provider.CreateZoneDelegationForExternalDNS(gslb)
+	errFinalize := provider.Finalize(gslb, nil)
+	// assert
+	assert.NoError(t, errBefore)
+	assert.NoError(t, errAfter)
+	assert.NoError(t, errFinalize)
+	assert.Equal(t, 1, old.delegations)
+	assert.Equal(t, 1, reloaded.delegations)
+	assert.Equal(t, 0, old.finalized)
+	assert.Equal(t, 1, reloaded.finalized)
+	assert.Equal(t, []string{"reloaded"}, provider.GetExternalTargets("roundrobin.cloud.example.com"))
+	assert.Equal(t, "reloaded", provider.String())
+}
@@ -19,6 +19,7 @@ package metrics
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
 	"github.com/AbsaOSS/k8gb/controllers/depresolver"
@@ -36,6 +37,10 @@ const (
 type PrometheusMetrics struct {
 	healthyRecordsMetric        *prometheus.GaugeVec
 	ingressHostsPerStatusMetric *prometheus.GaugeVec
+	peerQueryDurationMetric     *prometheus.HistogramVec
+	peerQueryErrorsMetric       *prometheus.CounterVec
+	peerAuthFailuresMetric      *prometheus.CounterVec
+	configGenerationMetric      prometheus.Gauge
 	once                        sync.Once
 }
 
@@ -60,9 +65,53 @@ func NewPrometheusMetrics(config depresolver.Config) (metrics *PrometheusMetrics
 		},
 		[]string{"namespace", "name", "status"},
 	)
+	metrics.peerQueryDurationMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: config.K8gbNamespace,
+			Subsystem: gslbSubsystem,
+			Name:      "peer_query_duration_seconds",
+			Help:      "Duration of DNS queries resolving targets in external clusters.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"peer"},
+	)
+	metrics.peerQueryErrorsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: config.K8gbNamespace,
+			Subsystem: gslbSubsystem,
+			Name:      "peer_query_errors_total",
+			Help:      "Number of failed DNS queries resolving targets in external clusters.",
+		},
+		[]string{"peer"},
+	)
+	metrics.peerAuthFailuresMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: config.K8gbNamespace,
+			Subsystem: gslbSubsystem,
+			Name:      "peer_auth_failures_total",
+			Help:      "Number of answers and heartbeats of external clusters rejected by authentication.",
+		},
+		[]string{"peer", "reason"},
+	)
+	metrics.configGenerationMetric = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: config.K8gbNamespace,
+			Subsystem: gslbSubsystem,
+			Name:      "config_generation",
+			Help:      "Generation of operator configuration, incremented by every hot reload.",
+		},
+	)
 	return
 }
 
+// ObservePeerQuery records duration of DNS query sent to external cluster and counts the failed ones
+func (m *PrometheusMetrics) ObservePeerQuery(peer string, duration time.Duration, err error) {
+	m.peerQueryDurationMetric.With(prometheus.Labels{"peer": peer}).Observe(duration.Seconds())
+	if err != nil {
+		m.peerQueryErrorsMetric.With(prometheus.Labels{"peer": peer}).Inc()
+	}
+}
+
 func (m *PrometheusMetrics) UpdateIngressHostsPerStatusMetric(gslb *k8gbv1beta1.Gslb, serviceHealth map[string]string) error {
 	var healthyHostsCount, unhealthyHostsCount, notFoundHostsCount int
 	for _, hs := range serviceHealth {
@@ -93,6 +142,16 @@ func (m *PrometheusMetrics) UpdateHealthyRecordsMetric(gslb *k8gbv1beta1.Gslb, h
 	return nil
 }
 
+// ObservePeerAuthFailure counts data of external cluster rejected by TSIG or heartbeat signature verification
+func (m *PrometheusMetrics) ObservePeerAuthFailure(peer, reason string) {
+	m.peerAuthFailuresMetric.With(prometheus.Labels{"peer": peer, "reason": reason}).Inc()
+}
+
+// SetConfigGeneration records generation of operator configuration applied by the operator
+func (m *PrometheusMetrics) SetConfigGeneration(generation int) {
+	m.configGenerationMetric.Set(float64(generation))
+}
+
 // Register prometheus metrics. Read register documentation, but shortly:
 // You can register metric with given name only once
 func (m *PrometheusMetrics) Register() (err error) {
@@ -103,6 +162,18 @@ func (m *PrometheusMetrics) Register() (err error) {
 		if err = crm.Registry.Register(m.ingressHostsPerStatusMetric); err != nil {
 			return
 		}
+		if err = crm.Registry.Register(m.peerQueryDurationMetric); err != nil {
+			return
+		}
+		if err = crm.Registry.Register(m.peerQueryErrorsMetric); err != nil {
+			return
+		}
+		if err = crm.Registry.Register(m.peerAuthFailuresMetric); err != nil {
+			return
+		}
+		if err = crm.Registry.Register(m.configGenerationMetric); err != nil {
+			return
+		}
 	})
 	if err != nil {
 		return fmt.Errorf("can't register prometheus metrics: %s", err)
@@ -114,6 +185,10 @@ func (m *PrometheusMetrics) Register() (err error) {
 func (m *PrometheusMetrics) Unregister() {
 	crm.Registry.Unregister(m.healthyRecordsMetric)
 	crm.Registry.Unregister(m.ingressHostsPerStatusMetric)
+	crm.Registry.Unregister(m.peerQueryDurationMetric)
+	crm.Registry.Unregister(m.peerQueryErrorsMetric)
+	crm.Registry.Unregister(m.peerAuthFailuresMetric)
+	crm.Registry.Unregister(m.configGenerationMetric)
 }
 
 // GetHealthyRecordsMetric retrieves actual copy of healthy record metric
@@ -0,0 +1,274 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/providers/assistant"
+	"github.com/AbsaOSS/k8gb/controllers/providers/dns"
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// configReloader reloads configuration of the operator whenever its sources change. Config file, usually mounted
+// from ConfigMap, and referenced credentials are read again, so that changed values are applied without restart.
+// Kubelet updates mounted ConfigMaps and Secrets by swapping symlinks, so directories of the files are watched rather
+// than the files. Referenced Secrets are watched by informer restricted to the namespace of k8gb
+type configReloader struct {
+	resolver *depresolver.DependencyResolver
+	// secrets caches Secrets in K8gbNamespace; nil when no credential is referenced by Secret
+	secrets cache.Cache
+	reload  func(*depresolver.Config) error
+	// changed is notified by watches of files and Secrets; buffered, so that bursts of changes reload once
+	changed chan struct{}
+	// lastError and lastRestart suppress repeated logs of the same state
+	lastError   string
+	lastRestart string
+}
+
+// +kubebuilder:rbac:groups="",namespace=system,resources=secrets,verbs=get;list;watch
+
+// NewConfigReloader creates runnable reloading configuration whenever config file or referenced credentials change.
+// secrets caches Secrets in K8gbNamespace and can be nil when no credential is referenced by Secret. reload is called
+// with live configuration whenever hot-reloadable fields change
+func NewConfigReloader(resolver *depresolver.DependencyResolver, secrets cache.Cache,
+	reload func(*depresolver.Config) error) manager.Runnable {
+	return &configReloader{
+		resolver: resolver,
+		secrets:  secrets,
+		reload:   reload,
+		changed:  make(chan struct{}, 1),
+	}
+}
+
+// NewSecretCache creates cache of Secrets in K8gbNamespace read by the config reloader. Returns nil when no
+// credential is referenced by Secret. Manager cache isn't used, it would watch Secrets in all watched namespaces
+func NewSecretCache(restConfig *rest.Config, config *depresolver.Config) (cache.Cache, error) {
+	for _, ref := range config.Credentials {
+		if ref.SecretName != "" {
+			return cache.New(restConfig, cache.Options{Scheme: scheme.Scheme, Namespace: config.K8gbNamespace})
+		}
+	}
+	return nil, nil
+}
+
+// Start watches config file, credential files and referenced Secrets and reloads configuration on their changes
+// until stop is closed
+func (w *configReloader) Start(stop <-chan struct{}) error {
+	files, err := w.watchFiles()
+	if err != nil {
+		return err
+	}
+	defer files.Close()
+	if err = w.watchSecrets(stop); err != nil {
+		return err
+	}
+	// changes missed between startup resolution and the watches
+	w.notify()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-files.Events:
+			w.notify()
+		case err = <-files.Errors:
+			log.Error(err, "Can't watch configuration files")
+		case <-w.changed:
+			w.check()
+		}
+	}
+}
+
+// NeedLeaderElection returns false, every replica keeps its configuration up to date
+func (w *configReloader) NeedLeaderElection() bool {
+	return false
+}
+
+// watchFiles watches directories of config file and of credentials referenced by file
+func (w *configReloader) watchFiles() (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	config := w.resolver.LiveConfig()
+	files := []string{config.ConfigFile}
+	for _, ref := range config.Credentials {
+		files = append(files, ref.File)
+	}
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		if err = watcher.Add(filepath.Dir(file)); err != nil {
+			_ = watcher.Close()
+			return nil, fmt.Errorf("can't watch %s: %w", file, err)
+		}
+	}
+	return watcher, nil
+}
+
+// watchSecrets starts cache of Secrets, waits for its sync and reads referenced Secrets from it since then
+func (w *configReloader) watchSecrets(stop <-chan struct{}) error {
+	if w.secrets == nil {
+		return nil
+	}
+	informer, err := w.secrets.GetInformer(context.TODO(), &corev1.Secret{})
+	if err != nil {
+		return err
+	}
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    w.secretChanged,
+		UpdateFunc: func(_, obj interface{}) { w.secretChanged(obj) },
+		DeleteFunc: w.secretChanged,
+	})
+	go func() {
+		if err := w.secrets.Start(stop); err != nil {
+			log.Error(err, "Can't watch Secrets")
+		}
+	}()
+	if !w.secrets.WaitForCacheSync(stop) {
+		return fmt.Errorf("can't sync cache of Secrets")
+	}
+	w.resolver.WithSecretReader(w.secrets)
+	return nil
+}
+
+// secretChanged notifies reloader when changed Secret is referenced by a credential
+func (w *configReloader) secretChanged(obj interface{}) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return
+	}
+	for _, ref := range w.resolver.LiveConfig().Credentials {
+		if ref.SecretName == secret.Name {
+			w.notify()
+			return
+		}
+	}
+}
+
+// notify schedules reload unless one is already scheduled
+func (w *configReloader) notify() {
+	select {
+	case w.changed <- struct{}{}:
+	default:
+	}
+}
+
+// check reloads configuration and applies it when hot-reloadable fields changed. Invalid configuration is rejected
+// as a whole and the previous configuration stays in use
+func (w *configReloader) check() {
+	generation := w.resolver.ConfigGeneration()
+	config, restart, err := w.resolver.ReloadOperatorConfig()
+	if err != nil {
+		if err.Error() != w.lastError {
+			log.Error(err, fmt.Sprintf("Invalid configuration, keeping configuration generation %d", generation))
+		}
+		w.lastError = err.Error()
+		return
+	}
+	w.lastError = ""
+	if changed := strings.Join(restart, ", "); changed != w.lastRestart {
+		if changed != "" {
+			log.Info(fmt.Sprintf("Changes of %s are applied after restart of the operator", changed))
+		}
+		w.lastRestart = changed
+	}
+	if w.resolver.ConfigGeneration() == generation {
+		return
+	}
+	if err = w.reload(config); err != nil {
+		log.Error(err, "Can't apply reloaded configuration")
+		return
+	}
+	log.Info(fmt.Sprintf("Configuration generation %d reloaded", w.resolver.ConfigGeneration()))
+}
+
+// ReloadConfig applies hot-reloaded configuration. Log level is changed and DNS provider is recreated, so that
+// geo tags of external clusters, draining, capacity and credentials are used by the following reconciliations and
+// by the peer watcher. Provider is kept when none of them changed, and the recreated one takes over cached answers
+// and observations of external clusters. Requeue interval is read from DepResolver by every reconciliation
+func (r *GslbReconciler) ReloadConfig(config *depresolver.Config) error {
+	provider, ok := r.DNSProvider.(*dns.ReloadableDNSProvider)
+	if !ok {
+		return fmt.Errorf("DNS provider %s can't be reloaded", r.DNSProvider)
+	}
+	if providerChanged(r.providerConfig(), config) {
+		f, err := dns.NewDNSProviderFactory(r.Client, *config, r.Log, r.Metrics)
+		if err != nil {
+			return err
+		}
+		provider.Reload(f.WithEventRecorder(r.EventRecorder()).WithPeerState(r.PeerState()).Provider())
+		r.reloadedConfig = config
+	}
+	zerolog.SetGlobalLevel(config.Log.Level)
+	if r.Metrics != nil {
+		r.Metrics.SetConfigGeneration(r.DepResolver.ConfigGeneration())
+	}
+	return nil
+}
+
+// PeerState returns state of external clusters shared by DNS providers of the reconciler
+func (r *GslbReconciler) PeerState() *assistant.PeerState {
+	r.peerStateOnce.Do(func() {
+		r.peerState = assistant.NewPeerState()
+	})
+	return r.peerState
+}
+
+// providerConfig returns configuration the current DNS provider is created from
+func (r *GslbReconciler) providerConfig() *depresolver.Config {
+	if r.reloadedConfig != nil {
+		return r.reloadedConfig
+	}
+	return r.Config
+}
+
+// providerChanged returns true if fields of reloaded configuration used by DNS provider differ from previous.
+// Log level and requeue interval are applied without the provider
+func providerChanged(previous, reloaded *depresolver.Config) bool {
+	a, b := *previous, *reloaded
+	a.Log, b.Log = depresolver.Log{}, depresolver.Log{}
+	a.ReconcileRequeueSeconds, b.ReconcileRequeueSeconds = 0, 0
+	return !reflect.DeepEqual(a, b)
+}
+
+// liveConfig returns configuration with hot-reloaded fields of resolver. Returns config when resolver doesn't
+// hold any configuration
+func liveConfig(resolver *depresolver.DependencyResolver, config *depresolver.Config) *depresolver.Config {
+	if resolver != nil {
+		if live := resolver.LiveConfig(); live != nil {
+			return live
+		}
+	}
+	return config
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2021 Absa Group Limited
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/providers/dns"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestConfigReloaderReloadsChangedConfigFile(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	path := writeConfigFile(t, "extClustersGeoTags: [us-east-1]")
+	_ = os.Setenv(depresolver.ConfigFileKey, path)
+	resolver := depresolver.NewDependencyResolver()
+	_, err := resolver.ResolveOperatorConfig()
+	require.NoError(t, err)
+	var reloaded []*depresolver.Config
+	reloader := NewConfigReloader(resolver, nil, func(config *depresolver.Config) error {
+		reloaded = append(reloaded, config)
+		return nil
+	}).(*configReloader)
+	// act
+	reloader.check()
+	require.NoError(t, ioutil.WriteFile(path, []byte("extClustersGeoTags: [us-east-1, eu-west-1]"), 0600))
+	reloader.check()
+	reloader.check()
+	// assert
+	require.Len(t, reloaded, 1, "configuration must be reloaded only when the file changes")
+	assert.Equal(t, []string{"us-east-1", "eu-west-1"}, reloaded[0].ExtClustersGeoTags)
+	assert.Equal(t, 2, resolver.ConfigGeneration())
+}
+
+func TestConfigReloaderKeepsConfigOfInvalidConfigFile(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	path := writeConfigFile(t, "reconcileRequeueSeconds: 30")
+	_ = os.Setenv(depresolver.ConfigFileKey, path)
+	resolver := depresolver.NewDependencyResolver()
+	config, err := resolver.ResolveOperatorConfig()
+	require.NoError(t, err)
+	reloads := 0
+	reloader := NewConfigReloader(resolver, nil, func(*depresolver.Config) error {
+		reloads++
+		return nil
+	}).(*configReloader)
+	require.NoError(t, ioutil.WriteFile(path, []byte("reconcileRequeueSeconds: 0"), 0600))
+	// act
+	reloader.check()
+	// assert
+	assert.Equal(t, 0, reloads)
+	assert.Equal(t, config, resolver.LiveConfig())
+	assert.Equal(t, 1, resolver.ConfigGeneration())
+}
+
+func TestConfigReloaderReloadsRotatedCredential(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	path := writeConfigFile(t, "secret\n")
+	_ = os.Setenv(depresolver.InfobloxPasswordKey+depresolver.CredentialFileSuffix, path)
+	resolver := depresolver.NewDependencyResolver()
+	_, err := resolver.ResolveOperatorConfig()
+	require.NoError(t, err)
+	var reloaded []*depresolver.Config
+	reloader := NewConfigReloader(resolver, nil, func(config *depresolver.Config) error {
+		reloaded = append(reloaded, config)
+		return nil
+	}).(*configReloader)
+	// act
+	reloader.check()
+	require.NoError(t, ioutil.WriteFile(path, []byte("rotated\n"), 0600))
+	reloader.check()
+	// assert
+	require.Len(t, reloaded, 1)
+	assert.Equal(t, "rotated", reloaded[0].Infoblox.Password)
+	assert.Equal(t, 2, resolver.ConfigGeneration())
+}
+
+func TestConfigReloaderWatchesConfigFile(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	path := writeConfigFile(t, "extClustersGeoTags: [us-east-1]")
+	_ = os.Setenv(depresolver.ConfigFileKey, path)
+	resolver := depresolver.NewDependencyResolver()
+	_, err := resolver.ResolveOperatorConfig()
+	require.NoError(t, err)
+	reloaded := make(chan *depresolver.Config, 1)
+	reloader := NewConfigReloader(resolver, nil, func(config *depresolver.Config) error {
+		reloaded <- config
+		return nil
+	})
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() { _ = reloader.Start(stop) }()
+	// act
+	require.Eventually(t, func() bool {
+		_ = ioutil.WriteFile(path, []byte("extClustersGeoTags: [us-east-1, eu-west-1]"), 0600)
+		return len(reloaded) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+	// assert
+	assert.Equal(t, []string{"us-east-1", "eu-west-1"}, (<-reloaded).ExtClustersGeoTags)
+}
+
+func TestConfigReloaderIsNotifiedOfReferencedSecretsOnly(t *testing.T) {
+	// arrange
+	defer cleanup()
+	configureEnvVar(predefinedConfig)
+	_ = os.Setenv(depresolver.InfobloxPasswordKey+depresolver.CredentialSecretSuffix, "infoblox/password")
+	resolver := depresolver.NewDependencyResolver().WithSecretReader(fake.NewFakeClientWithScheme(scheme.Scheme,
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: predefinedConfig.K8gbNamespace, Name: "infoblox"},
+			Data: map[string][]byte{"password": []byte("secret")}}))
+	_, err := resolver.ResolveOperatorConfig()
+	require.NoError(t, err)
+	reloader := NewConfigReloader(resolver, nil, func(*depresolver.Config) error { return nil }).(*configReloader)
+	// act
+	reloader.secretChanged(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "cloudflare"}})
+	unreferenced := len(reloader.changed)
+	reloader.secretChanged(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "infoblox"}})
+	reloader.secretChanged(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "infoblox"}})
+	// assert
+	assert.Equal(t, 0, unreferenced)
+	assert.Equal(t, 1, len(reloader.changed), "changes must be coalesced")
+}
+
+func TestReconcileRequeuesByReloadedInterval(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	path := writeConfigFile(t, "reconcileRequeueSeconds: 30")
+	_ = os.Setenv(depresolver.ConfigFileKey, path)
+	resolver := depresolver.NewDependencyResolver()
+	_, err := resolver.ResolveOperatorConfig()
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, []byte("reconcileRequeueSeconds: 60"), 0600))
+	_, _, err = resolver.ReloadOperatorConfig()
+	require.NoError(t, err)
+	settings.reconciler.DepResolver = resolver
+	// act
+	result, err := settings.reconciler.Reconcile(settings.request)
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, 60*time.Second, result.RequeueAfter)
+}
+
+func TestReloadConfigReplacesDNSProvider(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	stub := newWatchedProvider()
+	provider := dns.NewReloadableDNS(stub)
+	settings.reconciler.DNSProvider = provider
+	config := *settings.reconciler.Config
+	config.ExtClustersGeoTags = []string{"us-east-1", "eu-west-1"}
+	f, err := dns.NewDNSProviderFactory(settings.client, config, settings.reconciler.Log, nil)
+	require.NoError(t, err)
+	// act
+	err = settings.reconciler.ReloadConfig(&config)
+	provider.GetExternalTargets("app.cloud.example.com")
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprint(f.Provider()), provider.String())
+	assert.Empty(t, stub.queries, "reloaded provider must replace the previous one")
+}
+
+func TestReloadConfigKeepsDNSProviderWhenOnlyLogLevelChanges(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	stub := newWatchedProvider()
+	provider := dns.NewReloadableDNS(stub)
+	settings.reconciler.DNSProvider = provider
+	config := *settings.reconciler.Config
+	config.Log.Level = zerolog.DebugLevel
+	config.ReconcileRequeueSeconds = 60
+	// act
+	err := settings.reconciler.ReloadConfig(&config)
+	provider.GetExternalTargets("app.cloud.example.com")
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stub.queries["app.cloud.example.com"], "provider must be kept")
+}
+
+func TestReloadConfigRequiresReloadableDNSProvider(t *testing.T) {
+	// arrange
+	defer cleanup()
+	settings := provideSettings(t, predefinedConfig)
+	settings.reconciler.DNSProvider = newWatchedProvider()
+	// act
+	err := settings.reconciler.ReloadConfig(settings.reconciler.Config)
+	// assert
+	assert.Error(t, err)
+}
+
+// writeConfigFile writes content into temporary config file and returns its path
+func writeConfigFile(t *testing.T, content string) string {
+	file, err := ioutil.TempFile("", "k8gb-config-*.yaml")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Remove(file.Name()) })
+	_, err = file.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	return file.Name()
+}
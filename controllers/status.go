@@ -48,13 +48,16 @@ func (r *GslbReconciler) updateGslbStatus(gslb *k8gbv1beta1.Gslb) error {
 
 	gslb.Status.GeoTag = r.Config.ClusterGeoTag
 
+	gslb.Status.Peers = r.DNSProvider.ExternalClustersStatus(gslb)
+
 	err = r.Metrics.UpdateHealthyRecordsMetric(gslb, gslb.Status.HealthyRecords)
 	if err != nil {
 		return err
 	}
 
-	err = r.Status().Update(context.TODO(), gslb)
-	return err
+	ready(gslb)
+
+	return r.updateStatus(gslb)
 }
 
 func (r *GslbReconciler) getServiceHealthStatus(gslb *k8gbv1beta1.Gslb) (map[string]string, error) {
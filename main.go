@@ -21,6 +21,7 @@ import (
 	k8gbv1beta1 "github.com/AbsaOSS/k8gb/api/v1beta1"
 	"github.com/AbsaOSS/k8gb/controllers"
 	"github.com/AbsaOSS/k8gb/controllers/depresolver"
+	"github.com/AbsaOSS/k8gb/controllers/peerstatus"
 	"github.com/AbsaOSS/k8gb/controllers/providers/dns"
 	"github.com/AbsaOSS/k8gb/controllers/providers/metrics"
 
@@ -30,8 +31,10 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	externaldns "sigs.k8s.io/external-dns/endpoint"
 	// +kubebuilder:scaffold:imports
 )
@@ -57,10 +60,15 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.Parse()
 
-	resolver := depresolver.NewDependencyResolver()
+	// credentials referenced by Secrets are read before manager and its cache are started
+	secretReader, secretReaderErr := newSecretReader()
+	resolver := depresolver.NewDependencyResolver().WithSecretReader(secretReader)
 	config, err := resolver.ResolveOperatorConfig()
 	// LoggerFactory creates logger ALWAYS - no matter what isn't resolved
 	logger := controllers.NewLogger(config).Get()
+	if secretReaderErr != nil {
+		logger.Err(secretReaderErr).Msg("can't create client reading Secrets")
+	}
 	if err != nil {
 		logger.Err(err).Msg("can't resolve environment variables")
 		os.Exit(1)
@@ -74,6 +82,7 @@ func main() {
 		Port:               9443,
 		LeaderElection:     enableLeaderElection,
 		LeaderElectionID:   "8020e9ff.absa.oss",
+		NewCache:           controllers.NewScopedCache(config),
 	})
 	if err != nil {
 		logger.Err(err).Msg("unable to start manager")
@@ -91,22 +100,42 @@ func main() {
 		os.Exit(1)
 	}
 
+	if config.PeerStatus.Address != "" {
+		logger.Info().Msgf("starting peer status API on %s", config.PeerStatus.Address)
+		if err := peerstatus.IndexLocalTargets(mgr.GetFieldIndexer()); err != nil {
+			logger.Err(err).Msg("unable to index local targets of peer status API")
+			os.Exit(1)
+		}
+		handler := peerstatus.NewRotatingHandler(mgr.GetClient(), config.ClusterGeoTag, func() string {
+			return resolver.LiveConfig().PeerStatus.Token
+		})
+		err = mgr.Add(peerstatus.NewServer(config.PeerStatus.Address, config.PeerStatus.CertFile, config.PeerStatus.KeyFile, handler))
+		if err != nil {
+			logger.Err(err).Msg("unable to add peer status API")
+			os.Exit(1)
+		}
+	}
+
 	reconciler := &controllers.GslbReconciler{
 		Config:      config,
 		Client:      mgr.GetClient(),
+		APIReader:   mgr.GetAPIReader(),
 		DepResolver: resolver,
 		Log:         ctrl.Log.WithName("controllers").WithName("Gslb"),
 		Scheme:      mgr.GetScheme(),
+		Recorder:    mgr.GetEventRecorderFor("k8gb"),
 	}
 
-	logger.Info().Msg("starting DNS provider")
-	f, err = dns.NewDNSProviderFactory(reconciler.Client, *reconciler.Config, reconciler.Log)
-	if err != nil {
-		logger.Err(err).Msgf("unable to create factory (%s)", err)
-		os.Exit(1)
+	if config.WebhookEnabled {
+		logger.Info().Msg("registering Gslb webhooks")
+		mgr.GetWebhookServer().Register(controllers.GslbMutatingWebhookPath, &webhook.Admission{
+			Handler: &controllers.GslbDefaulter{},
+		})
+		mgr.GetWebhookServer().Register(controllers.GslbValidatingWebhookPath, &webhook.Admission{
+			Handler: &controllers.GslbValidator{Client: mgr.GetClient(), Config: config, DepResolver: resolver},
+		})
 	}
-	reconciler.DNSProvider = f.Provider()
-	logger.Info().Msgf("provider: %s", reconciler.DNSProvider)
+
 	logger.Info().Msg("starting metrics")
 	reconciler.Metrics = metrics.NewPrometheusMetrics(*reconciler.Config)
 	err = reconciler.Metrics.Register()
@@ -114,10 +143,42 @@ func main() {
 		logger.Err(err).Msg("register metrics error")
 		os.Exit(1)
 	}
+	reconciler.Metrics.SetConfigGeneration(resolver.ConfigGeneration())
+	logger.Info().Msg("starting DNS provider")
+	f, err = dns.NewDNSProviderFactory(reconciler.Client, *reconciler.Config, reconciler.Log, reconciler.Metrics)
+	if err != nil {
+		logger.Err(err).Msgf("unable to create factory (%s)", err)
+		os.Exit(1)
+	}
+	reconciler.DNSProvider = dns.NewReloadableDNS(f.WithEventRecorder(reconciler.EventRecorder()).WithPeerState(reconciler.PeerState()).Provider())
+	logger.Info().Msgf("provider: %s", reconciler.DNSProvider)
+	if config.ConfigFile != "" || len(config.Credentials) > 0 {
+		logger.Info().Msgf("watching config file %q and %d referenced credentials", config.ConfigFile, len(config.Credentials))
+		secretCache, err := controllers.NewSecretCache(mgr.GetConfig(), config)
+		if err != nil {
+			logger.Err(err).Msg("unable to create cache of Secrets")
+			os.Exit(1)
+		}
+		err = mgr.Add(controllers.NewConfigReloader(resolver, secretCache, reconciler.ReloadConfig))
+		if err != nil {
+			logger.Err(err).Msg("unable to add config reloader")
+			os.Exit(1)
+		}
+	}
 	if err = reconciler.SetupWithManager(mgr); err != nil {
 		logger.Err(err).Msg("unable to create controller Gslb")
 		os.Exit(1)
 	}
+	if err = (&controllers.IngressReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("Ingress"),
+		Scheme:   mgr.GetScheme(),
+		Config:   config,
+		Recorder: mgr.GetEventRecorderFor("k8gb"),
+	}).SetupWithManager(mgr); err != nil {
+		logger.Err(err).Msg("unable to create controller Ingress")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 	logger.Info().Msg("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
@@ -126,3 +187,13 @@ func main() {
 	}
 	reconciler.Metrics.Unregister()
 }
+
+// newSecretReader creates client reading Secrets directly from API server. Manager client can't be used, its cache is
+// not started when configuration is resolved
+func newSecretReader() (client.Reader, error) {
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return client.New(cfg, client.Options{Scheme: runtimescheme})
+}